@@ -6,23 +6,46 @@ import (
 	"log/slog"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strconv"
+	"time"
+	// Embedded so that --timezone works on hosts without a time zone database
+	_ "time/tzdata"
 
 	"hot-coffee/internal/handler"
+	"hot-coffee/internal/notify"
 	"hot-coffee/internal/repository"
 	"hot-coffee/internal/service"
 )
 
 const (
-	defaultPort = 8080
-	defaultDir  = "./data"
+	defaultPort    = 8080
+	defaultDir     = "./data"
+	defaultStorage = storageJSON
+	defaultDBFile  = "hot-coffee.db"
+
+	defaultExpiryInterval = time.Hour
+
+	storageJSON   = "json"
+	storageSQLite = "sqlite"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:])
+		return
+	}
+
 	var (
-		port     = flag.Int("port", defaultPort, "Port number")
-		dataDir  = flag.String("dir", defaultDir, "Path to the data directory")
-		showHelp = flag.Bool("help", false, "Show this screen")
+		port      = flag.Int("port", defaultPort, "Port number")
+		dataDir   = flag.String("dir", defaultDir, "Path to the data directory")
+		storage   = flag.String("storage", defaultStorage, "Storage driver: json or sqlite")
+		dbPath    = flag.String("db", "", "Path to the SQLite database (default <dir>/"+defaultDBFile+")")
+		alertURL  = flag.String("alert-webhook", "", "URL to post low stock alerts to")
+		alertFile = flag.String("alert-file", "", "File to append low stock alerts to")
+		expiry    = flag.Duration("expiry-interval", defaultExpiryInterval, "How often expired lots are moved to waste (0 disables)")
+		timezone  = flag.String("timezone", "", "IANA time zone of the shop's dates and menu schedules (default the system time zone)")
+		showHelp  = flag.Bool("help", false, "Show this screen")
 	)
 
 	flag.Parse()
@@ -32,11 +55,17 @@ func main() {
 		return
 	}
 
-	// Setup logger
-	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
-	}))
-	slog.SetDefault(logger)
+	setupLogger()
+
+	// Menu schedules, expiry dates and report dates follow the shop's wall clock
+	zone := time.Local
+	if *timezone != "" {
+		var err error
+		if zone, err = time.LoadLocation(*timezone); err != nil {
+			slog.Error("Invalid time zone", "timezone", *timezone, "error", err)
+			os.Exit(1)
+		}
+	}
 
 	// Create data directory if it doesn't exist
 	if err := os.MkdirAll(*dataDir, 0o755); err != nil {
@@ -45,21 +74,64 @@ func main() {
 	}
 
 	// Initialize repositories
-	orderRepo := repository.NewOrderRepository(*dataDir)
-	menuRepo := repository.NewMenuRepository(*dataDir)
-	inventoryRepo := repository.NewInventoryRepository(*dataDir)
+	repos, uow, closeStorage, err := openStorage(*storage, *dataDir, *dbPath)
+	if err != nil {
+		slog.Error("Failed to open storage", "storage", *storage, "error", err)
+		os.Exit(1)
+	}
+	defer closeStorage()
+
+	// Low stock alerts are always logged and optionally sent on
+	var notifiers []notify.Notifier
+	if *alertURL != "" {
+		notifiers = append(notifiers, notify.NewWebhookNotifier(*alertURL))
+	}
+	if *alertFile != "" {
+		notifiers = append(notifiers, notify.NewFileNotifier(*alertFile))
+	}
 
 	// Initialize services
-	orderService := service.NewOrderService(orderRepo, menuRepo, inventoryRepo)
-	menuService := service.NewMenuService(menuRepo)
-	inventoryService := service.NewInventoryService(inventoryRepo)
-	reportsService := service.NewReportsService(orderRepo, menuRepo)
+	orderService := service.NewOrderService(repos.Orders, repos.Menu, repos.Inventory, uow, notify.Multi(notifiers...), zone)
+	menuService := service.NewMenuService(repos.Menu, repos.Inventory, repos.Orders, repos.Locations, repos.Categories, uow, zone)
+	inventoryService := service.NewInventoryService(repos.Inventory, repos.Menu, repos.Orders, repos.Movements, repos.Locations, uow, zone)
+	reportsService := service.NewReportsService(repos.Orders, repos.Menu, repos.Inventory, repos.Movements, repos.StockCounts, repos.Categories)
+	supplierService := service.NewSupplierService(repos.Suppliers, repos.Inventory, repos.PurchaseOrders)
+	purchaseOrderService := service.NewPurchaseOrderService(repos.PurchaseOrders, repos.Suppliers, repos.Inventory, repos.Locations, uow)
+	locationService := service.NewLocationService(repos.Locations, repos.Inventory, repos.PurchaseOrders, repos.StockCounts)
+	stockCountService := service.NewStockCountService(repos.StockCounts, repos.Inventory, repos.Locations, uow, zone)
+	categoryService := service.NewCategoryService(repos.Categories, repos.Menu)
+
+	// Data from before locations existed keeps all its stock at the default location
+	if err := locationService.EnsureDefaultLocation(); err != nil {
+		slog.Error("Failed to create default location", "error", err)
+		os.Exit(1)
+	}
+
+	// Give stock that predates the ledger an opening balance
+	if err := inventoryService.ReconcileLedger(); err != nil {
+		slog.Error("Failed to reconcile inventory ledger", "error", err)
+		os.Exit(1)
+	}
+
+	// Waste lots that expired while the server was down, then keep checking
+	if _, err := inventoryService.ExpireLots(); err != nil {
+		slog.Error("Failed to expire lots", "error", err)
+		os.Exit(1)
+	}
+	if *expiry > 0 {
+		go runExpiryJob(inventoryService, *expiry)
+	}
 
 	// Initialize handlers
 	orderHandler := handler.NewOrderHandler(orderService)
 	menuHandler := handler.NewMenuHandler(menuService)
-	inventoryHandler := handler.NewInventoryHandler(inventoryService)
-	reportsHandler := handler.NewReportsHandler(reportsService)
+	inventoryHandler := handler.NewInventoryHandler(inventoryService, zone)
+	reportsHandler := handler.NewReportsHandler(reportsService, zone)
+	supplierHandler := handler.NewSupplierHandler(supplierService)
+	purchaseOrderHandler := handler.NewPurchaseOrderHandler(purchaseOrderService, zone)
+	stockCountHandler := handler.NewStockCountHandler(stockCountService)
+	locationHandler := handler.NewLocationHandler(locationService)
+	categoryHandler := handler.NewCategoryHandler(categoryService)
 
 	// Setup routes
 	mux := http.NewServeMux()
@@ -71,43 +143,224 @@ func main() {
 	mux.HandleFunc("PUT /orders/{id}", orderHandler.UpdateOrder)
 	mux.HandleFunc("DELETE /orders/{id}", orderHandler.DeleteOrder)
 	mux.HandleFunc("POST /orders/{id}/close", orderHandler.CloseOrder)
+	mux.HandleFunc("POST /orders/{id}/start", orderHandler.StartOrder)
+	mux.HandleFunc("POST /orders/{id}/ready", orderHandler.MarkOrderReady)
+	mux.HandleFunc("POST /orders/{id}/complete", orderHandler.CompleteOrder)
+	mux.HandleFunc("POST /orders/{id}/cancel", orderHandler.CancelOrder)
+	mux.HandleFunc("POST /orders/{id}/refund", orderHandler.RefundOrder)
 
 	// Menu routes
 	mux.HandleFunc("POST /menu", menuHandler.CreateMenuItem)
 	mux.HandleFunc("GET /menu", menuHandler.GetAllMenuItems)
+	mux.HandleFunc("GET /menu/structured", menuHandler.GetStructuredMenu)
+	mux.HandleFunc("GET /menu/preview", menuHandler.PreviewMenu)
 	mux.HandleFunc("GET /menu/{id}", menuHandler.GetMenuItem)
 	mux.HandleFunc("PUT /menu/{id}", menuHandler.UpdateMenuItem)
 	mux.HandleFunc("DELETE /menu/{id}", menuHandler.DeleteMenuItem)
+	mux.HandleFunc("GET /menu/{id}/usage", menuHandler.GetMenuItemUsage)
+	mux.HandleFunc("GET /menu/{id}/costing", menuHandler.GetMenuItemCosting)
+	mux.HandleFunc("POST /menu/{id}/sold-out", menuHandler.MarkSoldOut)
+	mux.HandleFunc("DELETE /menu/{id}/sold-out", menuHandler.ClearSoldOut)
 
 	// Inventory routes
 	mux.HandleFunc("POST /inventory", inventoryHandler.CreateInventoryItem)
 	mux.HandleFunc("GET /inventory", inventoryHandler.GetAllInventoryItems)
+	mux.HandleFunc("GET /inventory/low-stock", inventoryHandler.GetLowStockItems)
+	mux.HandleFunc("GET /inventory/expiring", inventoryHandler.GetExpiringLots)
+	mux.HandleFunc("POST /inventory/expire", inventoryHandler.ExpireLots)
+	mux.HandleFunc("POST /inventory/waste", inventoryHandler.RecordWaste)
+	mux.HandleFunc("POST /inventory/transfers", inventoryHandler.TransferStock)
 	mux.HandleFunc("GET /inventory/{id}", inventoryHandler.GetInventoryItem)
 	mux.HandleFunc("PUT /inventory/{id}", inventoryHandler.UpdateInventoryItem)
 	mux.HandleFunc("DELETE /inventory/{id}", inventoryHandler.DeleteInventoryItem)
+	mux.HandleFunc("GET /inventory/{id}/usage", inventoryHandler.GetInventoryItemUsage)
+	mux.HandleFunc("GET /inventory/{id}/movements", inventoryHandler.GetMovements)
+	mux.HandleFunc("POST /inventory/{id}/movements", inventoryHandler.RecordMovement)
+
+	// Category routes
+	mux.HandleFunc("POST /categories", categoryHandler.CreateCategory)
+	mux.HandleFunc("GET /categories", categoryHandler.GetAllCategories)
+	mux.HandleFunc("GET /categories/{id}", categoryHandler.GetCategory)
+	mux.HandleFunc("PUT /categories/{id}", categoryHandler.UpdateCategory)
+	mux.HandleFunc("DELETE /categories/{id}", categoryHandler.DeleteCategory)
+
+	// Location routes
+	mux.HandleFunc("POST /locations", locationHandler.CreateLocation)
+	mux.HandleFunc("GET /locations", locationHandler.GetAllLocations)
+	mux.HandleFunc("GET /locations/{id}", locationHandler.GetLocation)
+	mux.HandleFunc("PUT /locations/{id}", locationHandler.UpdateLocation)
+	mux.HandleFunc("DELETE /locations/{id}", locationHandler.DeleteLocation)
+
+	// Supplier routes
+	mux.HandleFunc("POST /suppliers", supplierHandler.CreateSupplier)
+	mux.HandleFunc("GET /suppliers", supplierHandler.GetAllSuppliers)
+	mux.HandleFunc("GET /suppliers/{id}", supplierHandler.GetSupplier)
+	mux.HandleFunc("PUT /suppliers/{id}", supplierHandler.UpdateSupplier)
+	mux.HandleFunc("DELETE /suppliers/{id}", supplierHandler.DeleteSupplier)
+
+	// Purchase order routes
+	mux.HandleFunc("POST /purchase-orders", purchaseOrderHandler.CreatePurchaseOrder)
+	mux.HandleFunc("GET /purchase-orders", purchaseOrderHandler.GetAllPurchaseOrders)
+	mux.HandleFunc("GET /purchase-orders/suggested", purchaseOrderHandler.GetSuggestedPurchaseOrders)
+	mux.HandleFunc("GET /purchase-orders/{id}", purchaseOrderHandler.GetPurchaseOrder)
+	mux.HandleFunc("POST /purchase-orders/{id}/receive", purchaseOrderHandler.ReceivePurchaseOrder)
+	mux.HandleFunc("POST /purchase-orders/{id}/cancel", purchaseOrderHandler.CancelPurchaseOrder)
+
+	// Stock count routes
+	mux.HandleFunc("POST /stock-counts", stockCountHandler.StartStockCount)
+	mux.HandleFunc("GET /stock-counts", stockCountHandler.GetAllStockCounts)
+	mux.HandleFunc("GET /stock-counts/{id}", stockCountHandler.GetStockCount)
+	mux.HandleFunc("POST /stock-counts/{id}/lines", stockCountHandler.RecordCounts)
+	mux.HandleFunc("POST /stock-counts/{id}/commit", stockCountHandler.CommitStockCount)
+	mux.HandleFunc("POST /stock-counts/{id}/cancel", stockCountHandler.CancelStockCount)
 
 	// Reports routes
 	mux.HandleFunc("GET /reports/total-sales", reportsHandler.GetTotalSales)
 	mux.HandleFunc("GET /reports/popular-items", reportsHandler.GetPopularItems)
+	mux.HandleFunc("GET /reports/waste", reportsHandler.GetWasteReport)
+	mux.HandleFunc("GET /reports/shrinkage", reportsHandler.GetShrinkageReport)
+	mux.HandleFunc("GET /reports/margin", reportsHandler.GetMarginReport)
+	mux.HandleFunc("GET /reports/sales-by-category", reportsHandler.GetCategorySales)
 
 	addr := ":" + strconv.Itoa(*port)
-	slog.Info("Starting server", "port", *port, "data_dir", *dataDir)
+	slog.Info("Starting server", "port", *port, "data_dir", *dataDir, "storage", *storage)
 
 	if err := http.ListenAndServe(addr, mux); err != nil {
 		slog.Error("Server failed to start", "error", err)
+		closeStorage()
 		os.Exit(1)
 	}
 }
 
+func setupLogger() {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+	}))
+	slog.SetDefault(logger)
+}
+
+// runExpiryJob moves expired lots to waste every interval.
+func runExpiryJob(inventoryService service.InventoryService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if _, err := inventoryService.ExpireLots(); err != nil {
+			slog.Error("Expiry job failed", "error", err)
+		}
+	}
+}
+
+// openStorage returns the repositories for the selected storage driver, the
+// unit of work that writes to them and a function that releases the
+// underlying storage.
+func openStorage(storage, dataDir, dbPath string) (repository.Repositories, repository.UnitOfWork, func() error, error) {
+	switch storage {
+	case storageJSON:
+		// Repair data files left half-written by a crash
+		if err := repository.RecoverDataDir(dataDir); err != nil {
+			return repository.Repositories{}, nil, nil, err
+		}
+
+		// Report records written with the same ID before duplicates were rejected
+		duplicates, err := repository.FindDuplicateIDs(dataDir)
+		if err != nil {
+			return repository.Repositories{}, nil, nil, err
+		}
+		for _, duplicate := range duplicates {
+			slog.Warn("Duplicate ID in data file, only the first record is used",
+				"file", duplicate.File, "id", duplicate.ID, "records", duplicate.Count)
+		}
+
+		repos := repository.Repositories{
+			Orders:         repository.NewOrderRepository(dataDir),
+			Menu:           repository.NewMenuRepository(dataDir),
+			Inventory:      repository.NewInventoryRepository(dataDir),
+			Suppliers:      repository.NewSupplierRepository(dataDir),
+			PurchaseOrders: repository.NewPurchaseOrderRepository(dataDir),
+			StockCounts:    repository.NewStockCountRepository(dataDir),
+			Locations:      repository.NewLocationRepository(dataDir),
+			Categories:     repository.NewCategoryRepository(dataDir),
+			Movements:      repository.NewMovementRepository(dataDir),
+		}
+		return repos, repository.NewUnitOfWork(repos), func() error { return nil }, nil
+
+	case storageSQLite:
+		if dbPath == "" {
+			dbPath = filepath.Join(dataDir, defaultDBFile)
+		}
+		db, err := repository.OpenSQLite(dbPath)
+		if err != nil {
+			return repository.Repositories{}, nil, nil, err
+		}
+		return repository.NewSQLiteRepositories(db), repository.NewSQLiteUnitOfWork(db), db.Close, nil
+
+	default:
+		return repository.Repositories{}, nil, nil, fmt.Errorf("unknown storage driver: %s", storage)
+	}
+}
+
+// runMigrate copies a JSON data directory into a SQLite database.
+func runMigrate(args []string) {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	dataDir := flags.String("dir", defaultDir, "Path to the JSON data directory")
+	dbPath := flags.String("db", "", "Path to the SQLite database (default <dir>/"+defaultDBFile+")")
+	flags.Usage = printUsage
+	flags.Parse(args)
+
+	setupLogger()
+
+	if *dbPath == "" {
+		*dbPath = filepath.Join(*dataDir, defaultDBFile)
+	}
+
+	db, err := repository.OpenSQLite(*dbPath)
+	if err != nil {
+		slog.Error("Failed to open database", "db", *dbPath, "error", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	result, err := repository.MigrateJSONToSQLite(*dataDir, db)
+	if err != nil {
+		slog.Error("Migration failed", "error", err)
+		db.Close()
+		os.Exit(1)
+	}
+
+	slog.Info("Migration completed", "db", *dbPath,
+		"orders", result.Orders, "menu_items", result.MenuItems, "inventory_items", result.InventoryItems,
+		"suppliers", result.Suppliers, "purchase_orders", result.PurchaseOrders,
+		"stock_counts", result.StockCounts, "locations", result.Locations, "categories", result.Categories,
+		"inventory_movements", result.Movements)
+}
+
 func printUsage() {
 	fmt.Println("Coffee Shop Management System")
 	fmt.Println()
 	fmt.Println("Usage:")
-	fmt.Println("  hot-coffee [--port <N>] [--dir <S>]")
+	fmt.Println("  hot-coffee [--port <N>] [--dir <S>] [--storage <json|sqlite>] [--db <S>]")
+	fmt.Println("             [--alert-webhook <URL>] [--alert-file <S>] [--expiry-interval <D>]")
+	fmt.Println("             [--timezone <S>]")
+	fmt.Println("  hot-coffee migrate [--dir <S>] [--db <S>]")
 	fmt.Println("  hot-coffee --help")
 	fmt.Println()
+	fmt.Println("Commands:")
+	fmt.Println("  migrate      Copy the JSON data directory into a SQLite database.")
+	fmt.Println()
 	fmt.Println("Options:")
 	fmt.Println("  --help       Show this screen.")
 	fmt.Println("  --port N     Port number.")
 	fmt.Println("  --dir S      Path to the data directory.")
+	fmt.Println("  --storage S  Storage driver: json (default) or sqlite.")
+	fmt.Println("  --db S       Path to the SQLite database. Defaults to <dir>/hot-coffee.db.")
+	fmt.Println("  --alert-webhook URL")
+	fmt.Println("               Post low stock alerts as JSON to URL.")
+	fmt.Println("  --alert-file S")
+	fmt.Println("               Append low stock alerts as JSON lines to the file S.")
+	fmt.Println("  --expiry-interval D")
+	fmt.Println("               How often expired lots are moved to waste, e.g. 30m. Defaults to 1h; 0 disables.")
+	fmt.Println("  --timezone S The IANA time zone of the shop, e.g. Europe/Berlin, which menu")
+	fmt.Println("               schedules, lot expiry dates and report date filters follow.")
+	fmt.Println("               Defaults to the system time zone.")
 }
@@ -0,0 +1,123 @@
+// internal/handler/category_handler.go
+package handler
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"hot-coffee/internal/service"
+	"hot-coffee/models"
+)
+
+type CategoryHandler struct {
+	categoryService service.CategoryService
+}
+
+func NewCategoryHandler(categoryService service.CategoryService) *CategoryHandler {
+	return &CategoryHandler{
+		categoryService: categoryService,
+	}
+}
+
+func (h *CategoryHandler) CreateCategory(w http.ResponseWriter, r *http.Request) {
+	var category models.Category
+	if err := json.NewDecoder(r.Body).Decode(&category); err != nil {
+		slog.Warn("Invalid JSON in create category request", "error", err)
+		writeErrorResponse(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	if err := validateCategory(&category); err != nil {
+		slog.Warn("Category validation failed", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	if err := h.categoryService.CreateCategory(&category); err != nil {
+		slog.Error("Failed to create category", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(category)
+}
+
+func (h *CategoryHandler) GetAllCategories(w http.ResponseWriter, r *http.Request) {
+	categories, err := h.categoryService.GetAllCategories()
+	if err != nil {
+		slog.Error("Failed to get all categories", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(categories)
+}
+
+func (h *CategoryHandler) GetCategory(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		writeErrorResponse(w, "Category ID is required", http.StatusBadRequest)
+		return
+	}
+
+	category, err := h.categoryService.GetCategoryByID(id)
+	if err != nil {
+		slog.Error("Failed to get category", "categoryID", id, "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(category)
+}
+
+func (h *CategoryHandler) UpdateCategory(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		writeErrorResponse(w, "Category ID is required", http.StatusBadRequest)
+		return
+	}
+
+	var category models.Category
+	if err := json.NewDecoder(r.Body).Decode(&category); err != nil {
+		slog.Warn("Invalid JSON in update category request", "error", err)
+		writeErrorResponse(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	category.ID = id
+	if err := validateCategory(&category); err != nil {
+		slog.Warn("Category validation failed", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	if err := h.categoryService.UpdateCategory(&category); err != nil {
+		slog.Error("Failed to update category", "categoryID", id, "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(category)
+}
+
+func (h *CategoryHandler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		writeErrorResponse(w, "Category ID is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.categoryService.DeleteCategory(id); err != nil {
+		slog.Error("Failed to delete category", "categoryID", id, "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -5,18 +5,26 @@ import (
 	"encoding/json"
 	"log/slog"
 	"net/http"
+	"time"
 
 	"hot-coffee/internal/service"
 	"hot-coffee/models"
 )
 
+// defaultExpiringDays is how far ahead expiring lots are listed by default.
+const defaultExpiringDays = 7
+
 type InventoryHandler struct {
 	inventoryService service.InventoryService
+	zone             *time.Location
 }
 
-func NewInventoryHandler(inventoryService service.InventoryService) *InventoryHandler {
+// NewInventoryHandler returns a handler that reads dates in the shop's time
+// zone.
+func NewInventoryHandler(inventoryService service.InventoryService, zone *time.Location) *InventoryHandler {
 	return &InventoryHandler{
 		inventoryService: inventoryService,
+		zone:             zone,
 	}
 }
 
@@ -30,13 +38,13 @@ func (h *InventoryHandler) CreateInventoryItem(w http.ResponseWriter, r *http.Re
 
 	if err := validateInventoryItem(&item); err != nil {
 		slog.Warn("Inventory item validation failed", "error", err)
-		writeErrorResponse(w, err.Error(), http.StatusBadRequest)
+		writeServiceError(w, err)
 		return
 	}
 
-	if err := h.inventoryService.CreateInventoryItem(&item); err != nil {
+	if err := h.inventoryService.CreateInventoryItem(&item, actorFrom(r)); err != nil {
 		slog.Error("Failed to create inventory item", "error", err)
-		writeErrorResponse(w, "Internal server error", http.StatusInternalServerError)
+		writeServiceError(w, err)
 		return
 	}
 
@@ -49,7 +57,19 @@ func (h *InventoryHandler) GetAllInventoryItems(w http.ResponseWriter, r *http.R
 	items, err := h.inventoryService.GetAllInventoryItems()
 	if err != nil {
 		slog.Error("Failed to get all inventory items", "error", err)
-		writeErrorResponse(w, "Internal server error", http.StatusInternalServerError)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(items)
+}
+
+func (h *InventoryHandler) GetLowStockItems(w http.ResponseWriter, r *http.Request) {
+	items, err := h.inventoryService.GetLowStockItems(r.URL.Query().Get("location"))
+	if err != nil {
+		slog.Error("Failed to get low stock items", "error", err)
+		writeServiceError(w, err)
 		return
 	}
 
@@ -67,12 +87,7 @@ func (h *InventoryHandler) GetInventoryItem(w http.ResponseWriter, r *http.Reque
 	item, err := h.inventoryService.GetInventoryItemByID(id)
 	if err != nil {
 		slog.Error("Failed to get inventory item", "itemID", id, "error", err)
-		writeErrorResponse(w, "Internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	if item == nil {
-		writeErrorResponse(w, "Inventory item not found", http.StatusNotFound)
+		writeServiceError(w, err)
 		return
 	}
 
@@ -97,17 +112,13 @@ func (h *InventoryHandler) UpdateInventoryItem(w http.ResponseWriter, r *http.Re
 	item.IngredientID = id
 	if err := validateInventoryItem(&item); err != nil {
 		slog.Warn("Inventory item validation failed", "error", err)
-		writeErrorResponse(w, err.Error(), http.StatusBadRequest)
+		writeServiceError(w, err)
 		return
 	}
 
-	if err := h.inventoryService.UpdateInventoryItem(&item); err != nil {
+	if err := h.inventoryService.UpdateInventoryItem(&item, actorFrom(r)); err != nil {
 		slog.Error("Failed to update inventory item", "itemID", id, "error", err)
-		if err.Error() == "inventory item not found" {
-			writeErrorResponse(w, err.Error(), http.StatusNotFound)
-		} else {
-			writeErrorResponse(w, err.Error(), http.StatusInternalServerError)
-		}
+		writeServiceError(w, err)
 		return
 	}
 
@@ -122,11 +133,174 @@ func (h *InventoryHandler) DeleteInventoryItem(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	if err := h.inventoryService.DeleteInventoryItem(id); err != nil {
+	cascade, err := parseCascade(r)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	if err := h.inventoryService.DeleteInventoryItem(id, cascade, actorFrom(r)); err != nil {
 		slog.Error("Failed to delete inventory item", "itemID", id, "error", err)
-		writeErrorResponse(w, "Internal server error", http.StatusInternalServerError)
+		writeServiceError(w, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+func (h *InventoryHandler) GetInventoryItemUsage(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		writeErrorResponse(w, "Inventory item ID is required", http.StatusBadRequest)
+		return
+	}
+
+	usage, err := h.inventoryService.GetInventoryItemUsage(id)
+	if err != nil {
+		slog.Error("Failed to get inventory item usage", "itemID", id, "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(usage)
+}
+
+func (h *InventoryHandler) RecordMovement(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		writeErrorResponse(w, "Inventory item ID is required", http.StatusBadRequest)
+		return
+	}
+
+	var movement models.InventoryMovement
+	if err := json.NewDecoder(r.Body).Decode(&movement); err != nil {
+		slog.Warn("Invalid JSON in inventory movement request", "error", err)
+		writeErrorResponse(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	if err := validateMovement(&movement, h.zone); err != nil {
+		slog.Warn("Inventory movement validation failed", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+	movement.OrderID = ""
+	movement.Actor = actorFrom(r)
+
+	if err := h.inventoryService.RecordMovement(id, &movement); err != nil {
+		slog.Error("Failed to record inventory movement", "itemID", id, "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(movement)
+}
+
+func (h *InventoryHandler) GetMovements(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		writeErrorResponse(w, "Inventory item ID is required", http.StatusBadRequest)
+		return
+	}
+
+	from, to, err := parseDateRange(r, h.zone)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	movements, err := h.inventoryService.GetMovements(id, from, to)
+	if err != nil {
+		slog.Error("Failed to get inventory movements", "itemID", id, "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(movements)
+}
+
+func (h *InventoryHandler) GetExpiringLots(w http.ResponseWriter, r *http.Request) {
+	days, err := parseDays(r, defaultExpiringDays)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	lots, err := h.inventoryService.GetExpiringLots(days)
+	if err != nil {
+		slog.Error("Failed to get expiring lots", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(lots)
+}
+
+func (h *InventoryHandler) ExpireLots(w http.ResponseWriter, r *http.Request) {
+	lots, err := h.inventoryService.ExpireLots()
+	if err != nil {
+		slog.Error("Failed to expire lots", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(lots)
+}
+
+func (h *InventoryHandler) RecordWaste(w http.ResponseWriter, r *http.Request) {
+	var waste models.WasteRecord
+	if err := json.NewDecoder(r.Body).Decode(&waste); err != nil {
+		slog.Warn("Invalid JSON in record waste request", "error", err)
+		writeErrorResponse(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	if err := validateWaste(&waste); err != nil {
+		slog.Warn("Waste validation failed", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+	waste.Actor = actorFrom(r)
+
+	if err := h.inventoryService.RecordWaste(&waste); err != nil {
+		slog.Error("Failed to record waste", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(waste)
+}
+
+func (h *InventoryHandler) TransferStock(w http.ResponseWriter, r *http.Request) {
+	var transfer models.StockTransfer
+	if err := json.NewDecoder(r.Body).Decode(&transfer); err != nil {
+		slog.Warn("Invalid JSON in transfer stock request", "error", err)
+		writeErrorResponse(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	if err := validateTransfer(&transfer); err != nil {
+		slog.Warn("Stock transfer validation failed", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+	transfer.Actor = actorFrom(r)
+
+	if err := h.inventoryService.TransferStock(&transfer); err != nil {
+		slog.Error("Failed to transfer stock", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(transfer)
+}
@@ -0,0 +1,123 @@
+// internal/handler/location_handler.go
+package handler
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"hot-coffee/internal/service"
+	"hot-coffee/models"
+)
+
+type LocationHandler struct {
+	locationService service.LocationService
+}
+
+func NewLocationHandler(locationService service.LocationService) *LocationHandler {
+	return &LocationHandler{
+		locationService: locationService,
+	}
+}
+
+func (h *LocationHandler) CreateLocation(w http.ResponseWriter, r *http.Request) {
+	var location models.Location
+	if err := json.NewDecoder(r.Body).Decode(&location); err != nil {
+		slog.Warn("Invalid JSON in create location request", "error", err)
+		writeErrorResponse(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	if err := validateLocation(&location); err != nil {
+		slog.Warn("Location validation failed", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	if err := h.locationService.CreateLocation(&location); err != nil {
+		slog.Error("Failed to create location", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(location)
+}
+
+func (h *LocationHandler) GetAllLocations(w http.ResponseWriter, r *http.Request) {
+	locations, err := h.locationService.GetAllLocations()
+	if err != nil {
+		slog.Error("Failed to get all locations", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(locations)
+}
+
+func (h *LocationHandler) GetLocation(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		writeErrorResponse(w, "Location ID is required", http.StatusBadRequest)
+		return
+	}
+
+	location, err := h.locationService.GetLocationByID(id)
+	if err != nil {
+		slog.Error("Failed to get location", "locationID", id, "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(location)
+}
+
+func (h *LocationHandler) UpdateLocation(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		writeErrorResponse(w, "Location ID is required", http.StatusBadRequest)
+		return
+	}
+
+	var location models.Location
+	if err := json.NewDecoder(r.Body).Decode(&location); err != nil {
+		slog.Warn("Invalid JSON in update location request", "error", err)
+		writeErrorResponse(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	location.ID = id
+	if err := validateLocation(&location); err != nil {
+		slog.Warn("Location validation failed", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	if err := h.locationService.UpdateLocation(&location); err != nil {
+		slog.Error("Failed to update location", "locationID", id, "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(location)
+}
+
+func (h *LocationHandler) DeleteLocation(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		writeErrorResponse(w, "Location ID is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.locationService.DeleteLocation(id); err != nil {
+		slog.Error("Failed to delete location", "locationID", id, "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
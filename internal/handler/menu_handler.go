@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"log/slog"
 	"net/http"
+	"time"
 
 	"hot-coffee/internal/service"
 	"hot-coffee/models"
@@ -30,13 +31,13 @@ func (h *MenuHandler) CreateMenuItem(w http.ResponseWriter, r *http.Request) {
 
 	if err := validateMenuItem(&item); err != nil {
 		slog.Warn("Menu item validation failed", "error", err)
-		writeErrorResponse(w, err.Error(), http.StatusBadRequest)
+		writeServiceError(w, err)
 		return
 	}
 
 	if err := h.menuService.CreateMenuItem(&item); err != nil {
 		slog.Error("Failed to create menu item", "error", err)
-		writeErrorResponse(w, "Internal server error", http.StatusInternalServerError)
+		writeServiceError(w, err)
 		return
 	}
 
@@ -46,10 +47,16 @@ func (h *MenuHandler) CreateMenuItem(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *MenuHandler) GetAllMenuItems(w http.ResponseWriter, r *http.Request) {
-	items, err := h.menuService.GetAllMenuItems()
+	availableOnly, err := parseBoolParam(r, "available")
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	items, err := h.menuService.GetAllMenuItems(r.URL.Query().Get("location"), availableOnly)
 	if err != nil {
 		slog.Error("Failed to get all menu items", "error", err)
-		writeErrorResponse(w, "Internal server error", http.StatusInternalServerError)
+		writeServiceError(w, err)
 		return
 	}
 
@@ -57,6 +64,54 @@ func (h *MenuHandler) GetAllMenuItems(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(items)
 }
 
+func (h *MenuHandler) GetStructuredMenu(w http.ResponseWriter, r *http.Request) {
+	availableOnly, err := parseBoolParam(r, "available")
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	menu, err := h.menuService.GetStructuredMenu(r.URL.Query().Get("location"), availableOnly)
+	if err != nil {
+		slog.Error("Failed to get structured menu", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(menu)
+}
+
+// PreviewMenu shows the structured menu as its schedules make it at the time
+// given in the at query parameter.
+func (h *MenuHandler) PreviewMenu(w http.ResponseWriter, r *http.Request) {
+	value := r.URL.Query().Get("at")
+	if value == "" {
+		writeServiceError(w, service.FieldError("at", "at is required"))
+		return
+	}
+	at, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		writeServiceError(w, service.FieldError("at", "at must be an RFC 3339 timestamp"))
+		return
+	}
+	availableOnly, err := parseBoolParam(r, "available")
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	menu, err := h.menuService.PreviewMenu(at, r.URL.Query().Get("location"), availableOnly)
+	if err != nil {
+		slog.Error("Failed to preview menu", "at", value, "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(menu)
+}
+
 func (h *MenuHandler) GetMenuItem(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 	if id == "" {
@@ -64,15 +119,10 @@ func (h *MenuHandler) GetMenuItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	item, err := h.menuService.GetMenuItemByID(id)
+	item, err := h.menuService.GetMenuItemByID(id, r.URL.Query().Get("location"))
 	if err != nil {
 		slog.Error("Failed to get menu item", "itemID", id, "error", err)
-		writeErrorResponse(w, "Internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	if item == nil {
-		writeErrorResponse(w, "Menu item not found", http.StatusNotFound)
+		writeServiceError(w, err)
 		return
 	}
 
@@ -97,17 +147,13 @@ func (h *MenuHandler) UpdateMenuItem(w http.ResponseWriter, r *http.Request) {
 	item.ID = id
 	if err := validateMenuItem(&item); err != nil {
 		slog.Warn("Menu item validation failed", "error", err)
-		writeErrorResponse(w, err.Error(), http.StatusBadRequest)
+		writeServiceError(w, err)
 		return
 	}
 
 	if err := h.menuService.UpdateMenuItem(&item); err != nil {
 		slog.Error("Failed to update menu item", "itemID", id, "error", err)
-		if err.Error() == "menu item not found" {
-			writeErrorResponse(w, err.Error(), http.StatusNotFound)
-		} else {
-			writeErrorResponse(w, err.Error(), http.StatusInternalServerError)
-		}
+		writeServiceError(w, err)
 		return
 	}
 
@@ -122,11 +168,81 @@ func (h *MenuHandler) DeleteMenuItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.menuService.DeleteMenuItem(id); err != nil {
+	cascade, err := parseCascade(r)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	if err := h.menuService.DeleteMenuItem(id, cascade); err != nil {
 		slog.Error("Failed to delete menu item", "itemID", id, "error", err)
-		writeErrorResponse(w, "Internal server error", http.StatusInternalServerError)
+		writeServiceError(w, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+func (h *MenuHandler) GetMenuItemUsage(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		writeErrorResponse(w, "Menu item ID is required", http.StatusBadRequest)
+		return
+	}
+
+	usage, err := h.menuService.GetMenuItemUsage(id)
+	if err != nil {
+		slog.Error("Failed to get menu item usage", "itemID", id, "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(usage)
+}
+
+func (h *MenuHandler) GetMenuItemCosting(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		writeErrorResponse(w, "Menu item ID is required", http.StatusBadRequest)
+		return
+	}
+
+	costing, err := h.menuService.GetMenuItemCosting(id)
+	if err != nil {
+		slog.Error("Failed to get menu item costing", "itemID", id, "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(costing)
+}
+
+// MarkSoldOut 86es a menu item at a location: it stays on the menu but cannot
+// be ordered there.
+func (h *MenuHandler) MarkSoldOut(w http.ResponseWriter, r *http.Request) {
+	h.setSoldOut(w, r, true)
+}
+
+func (h *MenuHandler) ClearSoldOut(w http.ResponseWriter, r *http.Request) {
+	h.setSoldOut(w, r, false)
+}
+
+func (h *MenuHandler) setSoldOut(w http.ResponseWriter, r *http.Request, soldOut bool) {
+	id := r.PathValue("id")
+	if id == "" {
+		writeErrorResponse(w, "Menu item ID is required", http.StatusBadRequest)
+		return
+	}
+
+	item, err := h.menuService.SetSoldOut(id, r.URL.Query().Get("location"), soldOut)
+	if err != nil {
+		slog.Error("Failed to change menu item sold out", "itemID", id, "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
+}
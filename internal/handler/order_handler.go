@@ -3,6 +3,8 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
 	"log/slog"
 	"net/http"
 
@@ -30,13 +32,13 @@ func (h *OrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
 
 	if err := validateOrder(&order); err != nil {
 		slog.Warn("Order validation failed", "error", err)
-		writeErrorResponse(w, err.Error(), http.StatusBadRequest)
+		writeServiceError(w, err)
 		return
 	}
 
 	if err := h.orderService.CreateOrder(&order); err != nil {
 		slog.Error("Failed to create order", "error", err)
-		writeErrorResponse(w, err.Error(), http.StatusBadRequest)
+		writeServiceError(w, err)
 		return
 	}
 
@@ -49,7 +51,7 @@ func (h *OrderHandler) GetAllOrders(w http.ResponseWriter, r *http.Request) {
 	orders, err := h.orderService.GetAllOrders()
 	if err != nil {
 		slog.Error("Failed to get all orders", "error", err)
-		writeErrorResponse(w, "Internal server error", http.StatusInternalServerError)
+		writeServiceError(w, err)
 		return
 	}
 
@@ -67,12 +69,7 @@ func (h *OrderHandler) GetOrder(w http.ResponseWriter, r *http.Request) {
 	order, err := h.orderService.GetOrderByID(id)
 	if err != nil {
 		slog.Error("Failed to get order", "orderID", id, "error", err)
-		writeErrorResponse(w, "Internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	if order == nil {
-		writeErrorResponse(w, "Order not found", http.StatusNotFound)
+		writeServiceError(w, err)
 		return
 	}
 
@@ -97,17 +94,13 @@ func (h *OrderHandler) UpdateOrder(w http.ResponseWriter, r *http.Request) {
 	order.ID = id
 	if err := validateOrder(&order); err != nil {
 		slog.Warn("Order validation failed", "error", err)
-		writeErrorResponse(w, err.Error(), http.StatusBadRequest)
+		writeServiceError(w, err)
 		return
 	}
 
 	if err := h.orderService.UpdateOrder(&order); err != nil {
 		slog.Error("Failed to update order", "orderID", id, "error", err)
-		if err.Error() == "order not found" {
-			writeErrorResponse(w, err.Error(), http.StatusNotFound)
-		} else {
-			writeErrorResponse(w, err.Error(), http.StatusInternalServerError)
-		}
+		writeServiceError(w, err)
 		return
 	}
 
@@ -124,7 +117,7 @@ func (h *OrderHandler) DeleteOrder(w http.ResponseWriter, r *http.Request) {
 
 	if err := h.orderService.DeleteOrder(id); err != nil {
 		slog.Error("Failed to delete order", "orderID", id, "error", err)
-		writeErrorResponse(w, "Internal server error", http.StatusInternalServerError)
+		writeServiceError(w, err)
 		return
 	}
 
@@ -140,13 +133,55 @@ func (h *OrderHandler) CloseOrder(w http.ResponseWriter, r *http.Request) {
 
 	if err := h.orderService.CloseOrder(id); err != nil {
 		slog.Error("Failed to close order", "orderID", id, "error", err)
-		if err.Error() == "order not found" {
-			writeErrorResponse(w, err.Error(), http.StatusNotFound)
-		} else {
-			writeErrorResponse(w, err.Error(), http.StatusInternalServerError)
-		}
+		writeServiceError(w, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+func (h *OrderHandler) StartOrder(w http.ResponseWriter, r *http.Request) {
+	h.changeOrderStatus(w, r, models.OrderStatusInPreparation)
+}
+
+func (h *OrderHandler) MarkOrderReady(w http.ResponseWriter, r *http.Request) {
+	h.changeOrderStatus(w, r, models.OrderStatusReady)
+}
+
+func (h *OrderHandler) CompleteOrder(w http.ResponseWriter, r *http.Request) {
+	h.changeOrderStatus(w, r, models.OrderStatusCompleted)
+}
+
+func (h *OrderHandler) CancelOrder(w http.ResponseWriter, r *http.Request) {
+	h.changeOrderStatus(w, r, models.OrderStatusCancelled)
+}
+
+func (h *OrderHandler) RefundOrder(w http.ResponseWriter, r *http.Request) {
+	h.changeOrderStatus(w, r, models.OrderStatusRefunded)
+}
+
+func (h *OrderHandler) changeOrderStatus(w http.ResponseWriter, r *http.Request, status string) {
+	id := r.PathValue("id")
+	if id == "" {
+		writeErrorResponse(w, "Order ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// The body is optional and only carries a reason
+	var req models.OrderStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		slog.Warn("Invalid JSON in order status request", "error", err)
+		writeErrorResponse(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	order, err := h.orderService.ChangeOrderStatus(id, status, req.Reason)
+	if err != nil {
+		slog.Error("Failed to change order status", "orderID", id, "status", status, "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(order)
+}
@@ -0,0 +1,115 @@
+// internal/handler/order_handler_test.go
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"hot-coffee/internal/notify"
+	"hot-coffee/internal/repository"
+	"hot-coffee/internal/service"
+	"hot-coffee/models"
+)
+
+func TestCloseOrder(t *testing.T) {
+	tests := []struct {
+		name        string
+		steps       []string
+		wantCode    int
+		wantHistory []string
+	}{
+		{
+			name:        "open order",
+			wantCode:    http.StatusNoContent,
+			wantHistory: []string{"open", "in_preparation", "ready", "completed"},
+		},
+		{
+			name:        "ready order",
+			steps:       []string{"in_preparation", "ready"},
+			wantCode:    http.StatusNoContent,
+			wantHistory: []string{"open", "in_preparation", "ready", "completed"},
+		},
+		{
+			name:        "completed order",
+			steps:       []string{"in_preparation", "ready", "completed"},
+			wantCode:    http.StatusConflict,
+			wantHistory: []string{"open", "in_preparation", "ready", "completed"},
+		},
+		{
+			name:        "cancelled order",
+			steps:       []string{"cancelled"},
+			wantCode:    http.StatusConflict,
+			wantHistory: []string{"open", "cancelled"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			repos := repository.Repositories{
+				Orders:    repository.NewOrderRepository(dir),
+				Menu:      repository.NewMenuRepository(dir),
+				Inventory: repository.NewInventoryRepository(dir),
+				Locations: repository.NewLocationRepository(dir),
+				Movements: repository.NewMovementRepository(dir),
+			}
+			if err := repos.Locations.Create(&models.Location{ID: models.DefaultLocationID, Name: "Main"}); err != nil {
+				t.Fatal(err)
+			}
+			if err := repos.Menu.Create(&models.MenuItem{ID: "water", Name: "Water", Price: 1, Ingredients: []models.MenuItemIngredient{}}); err != nil {
+				t.Fatal(err)
+			}
+			orders := service.NewOrderService(repos.Orders, repos.Menu, repos.Inventory, repository.NewUnitOfWork(repos), notify.Multi(), time.UTC)
+			order := &models.Order{CustomerName: "Ann", Items: []models.OrderItem{{ProductID: "water", Quantity: 1}}}
+			if err := orders.CreateOrder(order); err != nil {
+				t.Fatal(err)
+			}
+			for _, status := range tt.steps {
+				if _, err := orders.ChangeOrderStatus(order.ID, status, ""); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("POST /orders/{id}/close", NewOrderHandler(orders).CloseOrder)
+			recorder := httptest.NewRecorder()
+			mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/orders/"+order.ID+"/close", nil))
+			if recorder.Code != tt.wantCode {
+				t.Fatalf("POST /orders/{id}/close = %d %s, want %d", recorder.Code, recorder.Body, tt.wantCode)
+			}
+
+			stored, err := orders.GetOrderByID(order.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var history []string
+			for _, change := range stored.StatusHistory {
+				history = append(history, change.Status)
+			}
+			if !slices.Equal(history, tt.wantHistory) {
+				t.Errorf("status history = %v, want %v", history, tt.wantHistory)
+			}
+		})
+	}
+}
+
+func TestCloseUnknownOrder(t *testing.T) {
+	dir := t.TempDir()
+	repos := repository.Repositories{
+		Orders:    repository.NewOrderRepository(dir),
+		Menu:      repository.NewMenuRepository(dir),
+		Inventory: repository.NewInventoryRepository(dir),
+	}
+	orders := service.NewOrderService(repos.Orders, repos.Menu, repos.Inventory, repository.NewUnitOfWork(repos), notify.Multi(), time.UTC)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /orders/{id}/close", NewOrderHandler(orders).CloseOrder)
+	recorder := httptest.NewRecorder()
+	mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/orders/missing/close", nil))
+	if recorder.Code != http.StatusNotFound {
+		t.Fatalf("POST /orders/missing/close = %d %s, want %d", recorder.Code, recorder.Body, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,155 @@
+// internal/handler/purchase_order_handler.go
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"hot-coffee/internal/service"
+	"hot-coffee/models"
+)
+
+type PurchaseOrderHandler struct {
+	purchaseOrderService service.PurchaseOrderService
+	zone                 *time.Location
+}
+
+// NewPurchaseOrderHandler returns a handler that reads expiry dates in the
+// shop's time zone.
+func NewPurchaseOrderHandler(purchaseOrderService service.PurchaseOrderService, zone *time.Location) *PurchaseOrderHandler {
+	return &PurchaseOrderHandler{
+		purchaseOrderService: purchaseOrderService,
+		zone:                 zone,
+	}
+}
+
+func (h *PurchaseOrderHandler) CreatePurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	var order models.PurchaseOrder
+	if err := json.NewDecoder(r.Body).Decode(&order); err != nil {
+		slog.Warn("Invalid JSON in create purchase order request", "error", err)
+		writeErrorResponse(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	if err := validatePurchaseOrder(&order); err != nil {
+		slog.Warn("Purchase order validation failed", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	if err := h.purchaseOrderService.CreatePurchaseOrder(&order); err != nil {
+		slog.Error("Failed to create purchase order", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(order)
+}
+
+func (h *PurchaseOrderHandler) GetAllPurchaseOrders(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", models.PurchaseOrderStatusOpen, models.PurchaseOrderStatusPartiallyReceived,
+		models.PurchaseOrderStatusReceived, models.PurchaseOrderStatusCancelled:
+	default:
+		writeServiceError(w, service.FieldError("status", "unknown purchase order status: %s", status))
+		return
+	}
+
+	orders, err := h.purchaseOrderService.GetAllPurchaseOrders(status)
+	if err != nil {
+		slog.Error("Failed to get all purchase orders", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(orders)
+}
+
+func (h *PurchaseOrderHandler) GetSuggestedPurchaseOrders(w http.ResponseWriter, r *http.Request) {
+	suggestions, err := h.purchaseOrderService.SuggestPurchaseOrders(r.URL.Query().Get("location"))
+	if err != nil {
+		slog.Error("Failed to suggest purchase orders", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(suggestions)
+}
+
+func (h *PurchaseOrderHandler) GetPurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		writeErrorResponse(w, "Purchase order ID is required", http.StatusBadRequest)
+		return
+	}
+
+	order, err := h.purchaseOrderService.GetPurchaseOrderByID(id)
+	if err != nil {
+		slog.Error("Failed to get purchase order", "purchaseOrderID", id, "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(order)
+}
+
+func (h *PurchaseOrderHandler) ReceivePurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		writeErrorResponse(w, "Purchase order ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Without a body everything outstanding is received
+	var receipt models.PurchaseOrderReceipt
+	if err := json.NewDecoder(r.Body).Decode(&receipt); err != nil && !errors.Is(err, io.EOF) {
+		slog.Warn("Invalid JSON in receive purchase order request", "error", err)
+		writeErrorResponse(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	if err := validateReceipt(&receipt, h.zone); err != nil {
+		slog.Warn("Purchase order receipt validation failed", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+	receipt.Actor = actorFrom(r)
+
+	order, err := h.purchaseOrderService.ReceivePurchaseOrder(id, &receipt)
+	if err != nil {
+		slog.Error("Failed to receive purchase order", "purchaseOrderID", id, "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(order)
+}
+
+func (h *PurchaseOrderHandler) CancelPurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		writeErrorResponse(w, "Purchase order ID is required", http.StatusBadRequest)
+		return
+	}
+
+	order, err := h.purchaseOrderService.CancelPurchaseOrder(id)
+	if err != nil {
+		slog.Error("Failed to cancel purchase order", "purchaseOrderID", id, "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(order)
+}
@@ -5,25 +5,30 @@ import (
 	"encoding/json"
 	"log/slog"
 	"net/http"
+	"time"
 
 	"hot-coffee/internal/service"
 )
 
 type ReportsHandler struct {
 	reportsService service.ReportsService
+	zone           *time.Location
 }
 
-func NewReportsHandler(reportsService service.ReportsService) *ReportsHandler {
+// NewReportsHandler returns a handler that reads report dates in the shop's
+// time zone.
+func NewReportsHandler(reportsService service.ReportsService, zone *time.Location) *ReportsHandler {
 	return &ReportsHandler{
 		reportsService: reportsService,
+		zone:           zone,
 	}
 }
 
 func (h *ReportsHandler) GetTotalSales(w http.ResponseWriter, r *http.Request) {
-	totalSales, err := h.reportsService.GetTotalSales()
+	totalSales, err := h.reportsService.GetTotalSales(r.URL.Query().Get("location"))
 	if err != nil {
 		slog.Error("Failed to get total sales", "error", err)
-		writeErrorResponse(w, "Internal server error", http.StatusInternalServerError)
+		writeServiceError(w, err)
 		return
 	}
 
@@ -32,13 +37,96 @@ func (h *ReportsHandler) GetTotalSales(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *ReportsHandler) GetPopularItems(w http.ResponseWriter, r *http.Request) {
-	popularItems, err := h.reportsService.GetPopularItems()
+	byVariant, err := parseGrouping(r)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	popularItems, err := h.reportsService.GetPopularItems(byVariant, r.URL.Query().Get("location"))
 	if err != nil {
 		slog.Error("Failed to get popular items", "error", err)
-		writeErrorResponse(w, "Internal server error", http.StatusInternalServerError)
+		writeServiceError(w, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(popularItems)
 }
+
+func (h *ReportsHandler) GetWasteReport(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parseDateRange(r, h.zone)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	report, err := h.reportsService.GetWasteReport(from, to, r.URL.Query().Get("location"))
+	if err != nil {
+		slog.Error("Failed to get waste report", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+func (h *ReportsHandler) GetShrinkageReport(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parseDateRange(r, h.zone)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	report, err := h.reportsService.GetShrinkageReport(from, to, r.URL.Query().Get("location"))
+	if err != nil {
+		slog.Error("Failed to get shrinkage report", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+func (h *ReportsHandler) GetMarginReport(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parseDateRange(r, h.zone)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	byVariant, err := parseGrouping(r)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	report, err := h.reportsService.GetMarginReport(from, to, byVariant, r.URL.Query().Get("location"))
+	if err != nil {
+		slog.Error("Failed to get margin report", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+func (h *ReportsHandler) GetCategorySales(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parseDateRange(r, h.zone)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	report, err := h.reportsService.GetCategorySales(from, to, r.URL.Query().Get("location"))
+	if err != nil {
+		slog.Error("Failed to get category sales", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
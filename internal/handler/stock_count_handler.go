@@ -0,0 +1,156 @@
+// internal/handler/stock_count_handler.go
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"hot-coffee/internal/service"
+	"hot-coffee/models"
+)
+
+type StockCountHandler struct {
+	stockCountService service.StockCountService
+}
+
+func NewStockCountHandler(stockCountService service.StockCountService) *StockCountHandler {
+	return &StockCountHandler{
+		stockCountService: stockCountService,
+	}
+}
+
+func (h *StockCountHandler) StartStockCount(w http.ResponseWriter, r *http.Request) {
+	// Without a body the whole inventory is counted
+	var count models.StockCount
+	if err := json.NewDecoder(r.Body).Decode(&count); err != nil && !errors.Is(err, io.EOF) {
+		slog.Warn("Invalid JSON in start stock count request", "error", err)
+		writeErrorResponse(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	if err := validateStockCount(&count); err != nil {
+		slog.Warn("Stock count validation failed", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+	count.StartedBy = actorFrom(r)
+
+	if err := h.stockCountService.StartStockCount(&count); err != nil {
+		slog.Error("Failed to start stock count", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(count)
+}
+
+func (h *StockCountHandler) GetAllStockCounts(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", models.StockCountStatusOpen, models.StockCountStatusCommitted, models.StockCountStatusCancelled:
+	default:
+		writeServiceError(w, service.FieldError("status", "unknown stock count status: %s", status))
+		return
+	}
+
+	counts, err := h.stockCountService.GetAllStockCounts(status)
+	if err != nil {
+		slog.Error("Failed to get all stock counts", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(counts)
+}
+
+func (h *StockCountHandler) GetStockCount(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		writeErrorResponse(w, "Stock count ID is required", http.StatusBadRequest)
+		return
+	}
+
+	count, err := h.stockCountService.GetStockCountByID(id)
+	if err != nil {
+		slog.Error("Failed to get stock count", "countID", id, "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(count)
+}
+
+func (h *StockCountHandler) RecordCounts(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		writeErrorResponse(w, "Stock count ID is required", http.StatusBadRequest)
+		return
+	}
+
+	var submission models.CountSubmission
+	if err := json.NewDecoder(r.Body).Decode(&submission); err != nil {
+		slog.Warn("Invalid JSON in record stock count request", "error", err)
+		writeErrorResponse(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	if err := validateCountSubmission(&submission); err != nil {
+		slog.Warn("Stock count submission validation failed", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+	submission.Actor = actorFrom(r)
+
+	count, err := h.stockCountService.RecordCounts(id, &submission)
+	if err != nil {
+		slog.Error("Failed to record stock count", "countID", id, "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(count)
+}
+
+func (h *StockCountHandler) CommitStockCount(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		writeErrorResponse(w, "Stock count ID is required", http.StatusBadRequest)
+		return
+	}
+
+	count, err := h.stockCountService.CommitStockCount(id, actorFrom(r))
+	if err != nil {
+		slog.Error("Failed to commit stock count", "countID", id, "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(count)
+}
+
+func (h *StockCountHandler) CancelStockCount(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		writeErrorResponse(w, "Stock count ID is required", http.StatusBadRequest)
+		return
+	}
+
+	count, err := h.stockCountService.CancelStockCount(id)
+	if err != nil {
+		slog.Error("Failed to cancel stock count", "countID", id, "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(count)
+}
@@ -0,0 +1,123 @@
+// internal/handler/supplier_handler.go
+package handler
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"hot-coffee/internal/service"
+	"hot-coffee/models"
+)
+
+type SupplierHandler struct {
+	supplierService service.SupplierService
+}
+
+func NewSupplierHandler(supplierService service.SupplierService) *SupplierHandler {
+	return &SupplierHandler{
+		supplierService: supplierService,
+	}
+}
+
+func (h *SupplierHandler) CreateSupplier(w http.ResponseWriter, r *http.Request) {
+	var supplier models.Supplier
+	if err := json.NewDecoder(r.Body).Decode(&supplier); err != nil {
+		slog.Warn("Invalid JSON in create supplier request", "error", err)
+		writeErrorResponse(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	if err := validateSupplier(&supplier); err != nil {
+		slog.Warn("Supplier validation failed", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	if err := h.supplierService.CreateSupplier(&supplier); err != nil {
+		slog.Error("Failed to create supplier", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(supplier)
+}
+
+func (h *SupplierHandler) GetAllSuppliers(w http.ResponseWriter, r *http.Request) {
+	suppliers, err := h.supplierService.GetAllSuppliers()
+	if err != nil {
+		slog.Error("Failed to get all suppliers", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(suppliers)
+}
+
+func (h *SupplierHandler) GetSupplier(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		writeErrorResponse(w, "Supplier ID is required", http.StatusBadRequest)
+		return
+	}
+
+	supplier, err := h.supplierService.GetSupplierByID(id)
+	if err != nil {
+		slog.Error("Failed to get supplier", "supplierID", id, "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(supplier)
+}
+
+func (h *SupplierHandler) UpdateSupplier(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		writeErrorResponse(w, "Supplier ID is required", http.StatusBadRequest)
+		return
+	}
+
+	var supplier models.Supplier
+	if err := json.NewDecoder(r.Body).Decode(&supplier); err != nil {
+		slog.Warn("Invalid JSON in update supplier request", "error", err)
+		writeErrorResponse(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	supplier.ID = id
+	if err := validateSupplier(&supplier); err != nil {
+		slog.Warn("Supplier validation failed", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	if err := h.supplierService.UpdateSupplier(&supplier); err != nil {
+		slog.Error("Failed to update supplier", "supplierID", id, "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(supplier)
+}
+
+func (h *SupplierHandler) DeleteSupplier(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		writeErrorResponse(w, "Supplier ID is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.supplierService.DeleteSupplier(id); err != nil {
+		slog.Error("Failed to delete supplier", "supplierID", id, "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
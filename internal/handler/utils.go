@@ -4,78 +4,860 @@ package handler
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
+	"hot-coffee/internal/service"
+	"hot-coffee/internal/units"
 	"hot-coffee/models"
 )
 
 func writeErrorResponse(w http.ResponseWriter, message string, statusCode int) {
+	writeJSONError(w, statusCode, models.ErrorResponse{Code: errorCodeFor(statusCode), Error: message})
+}
+
+// writeServiceError translates an error returned by a service into a status
+// code and error response. Untyped errors are internal failures whose message
+// is logged by the caller but never returned to the client.
+func writeServiceError(w http.ResponseWriter, err error) {
+	var serviceErr *service.Error
+	if !errors.As(err, &serviceErr) {
+		writeErrorResponse(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	statusCode := statusCodeFor(serviceErr.Kind)
+	message := serviceErr.Message
+	if statusCode == http.StatusInternalServerError {
+		message = "Internal server error"
+	}
+	writeJSONError(w, statusCode, models.ErrorResponse{
+		Code:    string(serviceErr.Kind),
+		Error:   message,
+		Details: serviceErr.Details,
+	})
+}
+
+func statusCodeFor(kind service.ErrorKind) int {
+	switch kind {
+	case service.KindNotFound:
+		return http.StatusNotFound
+	case service.KindValidation:
+		return http.StatusBadRequest
+	case service.KindConflict, service.KindInsufficientStock:
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func errorCodeFor(statusCode int) string {
+	switch statusCode {
+	case http.StatusNotFound:
+		return string(service.KindNotFound)
+	case http.StatusBadRequest:
+		return string(service.KindValidation)
+	case http.StatusConflict:
+		return string(service.KindConflict)
+	default:
+		return string(service.KindInternal)
+	}
+}
+
+func writeJSONError(w http.ResponseWriter, statusCode int, response models.ErrorResponse) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
-	json.NewEncoder(w).Encode(models.ErrorResponse{Error: message})
+	json.NewEncoder(w).Encode(response)
+}
+
+// parseCascade reads the optional cascade query parameter of a delete request.
+func parseCascade(r *http.Request) (bool, error) {
+	return parseBoolParam(r, "cascade")
+}
+
+// parseBoolParam reads an optional true or false query parameter, false when
+// it is missing.
+func parseBoolParam(r *http.Request, name string) (bool, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return false, nil
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, service.FieldError(name, "%s must be true or false", name)
+	}
+	return parsed, nil
+}
+
+// actorFrom returns who made a request, as named by the X-Actor header.
+func actorFrom(r *http.Request) string {
+	return strings.TrimSpace(r.Header.Get("X-Actor"))
+}
+
+// parseDateRange reads the optional from and to query parameters, given as
+// RFC 3339 timestamps or dates in zone. A date in to includes the whole day.
+func parseDateRange(r *http.Request, zone *time.Location) (from, to time.Time, err error) {
+	query := r.URL.Query()
+	if value := query.Get("from"); value != "" {
+		if from, err = parseTime(value, false, zone); err != nil {
+			return time.Time{}, time.Time{}, service.FieldError("from", "from must be a date or RFC 3339 timestamp")
+		}
+	}
+	if value := query.Get("to"); value != "" {
+		if to, err = parseTime(value, true, zone); err != nil {
+			return time.Time{}, time.Time{}, service.FieldError("to", "to must be a date or RFC 3339 timestamp")
+		}
+	}
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		return time.Time{}, time.Time{}, service.FieldError("to", "to must not be before from")
+	}
+	return from, to, nil
+}
+
+// parseGrouping reads the optional by query parameter of the sales reports and
+// reports whether they group by product variant.
+func parseGrouping(r *http.Request) (byVariant bool, err error) {
+	switch r.URL.Query().Get("by") {
+	case "", "product":
+		return false, nil
+	case "variant":
+		return true, nil
+	default:
+		return false, service.FieldError("by", "by must be product or variant")
+	}
+}
+
+// parseTime reads an RFC 3339 timestamp, or a date in zone as its start or,
+// with endOfDay, its last instant.
+func parseTime(value string, endOfDay bool, zone *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	day, err := time.ParseInLocation(time.DateOnly, value, zone)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		return day.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+	}
+	return day, nil
 }
 
 func validateOrder(order *models.Order) error {
 	if strings.TrimSpace(order.CustomerName) == "" {
-		return errors.New("customer name is required")
+		return service.FieldError("customer_name", "customer name is required")
 	}
+	order.LocationID = strings.TrimSpace(order.LocationID)
 
 	if len(order.Items) == 0 {
-		return errors.New("order must contain at least one item")
+		return service.FieldError("items", "order must contain at least one item")
+	}
+
+	for i := range order.Items {
+		if err := validateOrderItem(fmt.Sprintf("items[%d]", i), &order.Items[i]); err != nil {
+			return err
+		}
 	}
 
-	for _, item := range order.Items {
-		if strings.TrimSpace(item.ProductID) == "" {
-			return errors.New("product ID is required for all items")
+	return nil
+}
+
+// validateOrderItem checks one ordered item, reporting errors under field.
+func validateOrderItem(field string, item *models.OrderItem) error {
+	if strings.TrimSpace(item.ProductID) == "" {
+		return service.FieldError(field+".product_id", "product ID is required for all items")
+	}
+	if item.Quantity <= 0 {
+		return service.FieldError(field+".quantity", "quantity must be greater than 0")
+	}
+
+	for j, modifier := range item.Modifiers {
+		modifierField := fmt.Sprintf("%s.modifiers[%d]", field, j)
+		if strings.TrimSpace(modifier.ModifierID) == "" {
+			return service.FieldError(modifierField+".modifier_id", "modifier ID is required for all modifiers")
 		}
-		if item.Quantity <= 0 {
-			return errors.New("quantity must be greater than 0")
+		if modifier.Quantity < 0 {
+			return service.FieldError(modifierField+".quantity", "modifier quantity cannot be negative")
+		}
+	}
+
+	for j, substitution := range item.Substitutions {
+		substitutionField := fmt.Sprintf("%s.substitutions[%d]", field, j)
+		if strings.TrimSpace(substitution.GroupID) == "" {
+			return service.FieldError(substitutionField+".group_id", "substitution group ID is required for all substitutions")
+		}
+		if strings.TrimSpace(substitution.IngredientID) == "" {
+			return service.FieldError(substitutionField+".ingredient_id", "ingredient ID is required for all substitutions")
 		}
 	}
 
 	return nil
 }
 
+// validateMenuItem checks a menu item. The product ID may be left empty on
+// create for the service to derive one from the name.
 func validateMenuItem(item *models.MenuItem) error {
-	if strings.TrimSpace(item.ID) == "" {
-		return errors.New("product ID is required")
-	}
+	item.ID = strings.TrimSpace(item.ID)
 	if strings.TrimSpace(item.Name) == "" {
-		return errors.New("name is required")
+		return service.FieldError("name", "name is required")
+	}
+	item.CategoryID = strings.TrimSpace(item.CategoryID)
+	if err := normalizeTags(item); err != nil {
+		return err
+	}
+	if err := validateSchedules(item.Schedules); err != nil {
+		return err
+	}
+	if item.Price < 0 {
+		return service.FieldError("price", "price cannot be negative")
+	}
+
+	// With variants, each variant carries its own price and recipe
+	if len(item.Variants) == 0 {
+		if item.Price <= 0 {
+			return service.FieldError("price", "price must be greater than 0")
+		}
+		if len(item.Ingredients) == 0 {
+			return service.FieldError("ingredients", "menu item must have at least one ingredient")
+		}
+	}
+
+	recipe := make(map[string]bool, len(item.Ingredients))
+	if err := validateRecipe("ingredients", item.Ingredients, recipe); err != nil {
+		return err
+	}
+	if err := validateVariants(item.Variants, recipe); err != nil {
+		return err
+	}
+
+	if err := validateModifiers(item.Modifiers); err != nil {
+		return err
 	}
-	if item.Price <= 0 {
-		return errors.New("price must be greater than 0")
+	if err := validateSubstitutionGroups(item.SubstitutionGroups, recipe); err != nil {
+		return err
 	}
-	if len(item.Ingredients) == 0 {
-		return errors.New("menu item must have at least one ingredient")
+	return validateRecipeUnits(item)
+}
+
+// validateRecipeUnits rejects a menu item that measures one ingredient in units
+// of different dimensions, such as milk in ml and in g. Whether the units
+// convert to the ingredient's stock unit is checked against the inventory.
+func validateRecipeUnits(item *models.MenuItem) error {
+	type measure struct {
+		unit  units.Unit
+		field string
+	}
+	seen := make(map[string]measure)
+	check := func(field string, ingredients []models.MenuItemIngredient) error {
+		for i := range ingredients {
+			ingredient := &ingredients[i]
+			ingredient.Unit = strings.TrimSpace(ingredient.Unit)
+			unit, ok := units.Lookup(ingredient.Unit)
+			if !ok {
+				continue
+			}
+			ingredientField := fmt.Sprintf("%s[%d].unit", field, i)
+			first, ok := seen[ingredient.IngredientID]
+			if !ok {
+				seen[ingredient.IngredientID] = measure{unit: unit, field: ingredientField}
+				continue
+			}
+			if first.unit.Dimension != unit.Dimension {
+				return service.FieldError(ingredientField, "%s is measured in %s here but in %s at %s", ingredient.IngredientID, unit.Symbol, first.unit.Symbol, first.field)
+			}
+		}
+		return nil
+	}
+
+	if err := check("ingredients", item.Ingredients); err != nil {
+		return err
+	}
+	for i, variant := range item.Variants {
+		if err := check(fmt.Sprintf("variants[%d].ingredients", i), variant.Ingredients); err != nil {
+			return err
+		}
 	}
+	for i, modifier := range item.Modifiers {
+		if err := check(fmt.Sprintf("modifiers[%d].ingredients", i), modifier.Ingredients); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	for _, ingredient := range item.Ingredients {
+// validateRecipe checks recipe ingredients and adds their IDs to recipe.
+func validateRecipe(field string, ingredients []models.MenuItemIngredient, recipe map[string]bool) error {
+	for i, ingredient := range ingredients {
+		ingredientField := fmt.Sprintf("%s[%d]", field, i)
 		if strings.TrimSpace(ingredient.IngredientID) == "" {
-			return errors.New("ingredient ID is required")
+			return service.FieldError(ingredientField+".ingredient_id", "ingredient ID is required")
 		}
 		if ingredient.Quantity <= 0 {
-			return errors.New("ingredient quantity must be greater than 0")
+			return service.FieldError(ingredientField+".quantity", "ingredient quantity must be greater than 0")
 		}
+		recipe[ingredient.IngredientID] = true
 	}
 
 	return nil
 }
 
-func validateInventoryItem(item *models.InventoryItem) error {
-	if strings.TrimSpace(item.IngredientID) == "" {
-		return errors.New("ingredient ID is required")
+func validateVariants(variants []models.MenuItemVariant, recipe map[string]bool) error {
+	seen := make(map[string]bool, len(variants))
+	for i, variant := range variants {
+		field := fmt.Sprintf("variants[%d]", i)
+		if strings.TrimSpace(variant.ID) == "" {
+			return service.FieldError(field+".variant_id", "variant ID is required")
+		}
+		if seen[variant.ID] {
+			return service.FieldError(field+".variant_id", "duplicate variant ID: %s", variant.ID)
+		}
+		seen[variant.ID] = true
+
+		if strings.TrimSpace(variant.Name) == "" {
+			return service.FieldError(field+".name", "variant name is required")
+		}
+		if variant.Price <= 0 {
+			return service.FieldError(field+".price", "price of variant %s must be greater than 0", variant.ID)
+		}
+		if len(variant.Ingredients) == 0 {
+			return service.FieldError(field+".ingredients", "variant %s must have at least one ingredient", variant.ID)
+		}
+		if err := validateRecipe(field+".ingredients", variant.Ingredients, recipe); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validateModifiers(modifiers []models.MenuModifier) error {
+	seen := make(map[string]bool, len(modifiers))
+	for i, modifier := range modifiers {
+		field := fmt.Sprintf("modifiers[%d]", i)
+		if strings.TrimSpace(modifier.ID) == "" {
+			return service.FieldError(field+".modifier_id", "modifier ID is required")
+		}
+		if seen[modifier.ID] {
+			return service.FieldError(field+".modifier_id", "duplicate modifier ID: %s", modifier.ID)
+		}
+		seen[modifier.ID] = true
+
+		if strings.TrimSpace(modifier.Name) == "" {
+			return service.FieldError(field+".name", "modifier name is required")
+		}
+		if modifier.Price < 0 {
+			return service.FieldError(field+".price", "modifier price cannot be negative")
+		}
+		if modifier.MaxQuantity < 0 {
+			return service.FieldError(field+".max_quantity", "modifier max quantity cannot be negative")
+		}
+		for j, ingredient := range modifier.Ingredients {
+			ingredientField := fmt.Sprintf("%s.ingredients[%d]", field, j)
+			if strings.TrimSpace(ingredient.IngredientID) == "" {
+				return service.FieldError(ingredientField+".ingredient_id", "modifier ingredient ID is required")
+			}
+			if ingredient.Quantity == 0 {
+				return service.FieldError(ingredientField+".quantity", "modifier ingredient quantity cannot be 0")
+			}
+		}
 	}
+
+	return nil
+}
+
+// validateSubstitutionGroups checks each group replaces an ingredient of the recipe.
+func validateSubstitutionGroups(groups []models.SubstitutionGroup, recipe map[string]bool) error {
+	seen := make(map[string]bool, len(groups))
+	for i, group := range groups {
+		field := fmt.Sprintf("substitution_groups[%d]", i)
+		if strings.TrimSpace(group.ID) == "" {
+			return service.FieldError(field+".group_id", "substitution group ID is required")
+		}
+		if seen[group.ID] {
+			return service.FieldError(field+".group_id", "duplicate substitution group ID: %s", group.ID)
+		}
+		seen[group.ID] = true
+
+		if strings.TrimSpace(group.Name) == "" {
+			return service.FieldError(field+".name", "substitution group name is required")
+		}
+		if !recipe[group.IngredientID] {
+			return service.FieldError(field+".ingredient_id", "substitution group %s must replace an ingredient of the recipe", group.ID)
+		}
+		if len(group.Options) == 0 {
+			return service.FieldError(field+".options", "substitution group %s must have at least one option", group.ID)
+		}
+		for j, option := range group.Options {
+			optionField := fmt.Sprintf("%s.options[%d]", field, j)
+			if strings.TrimSpace(option.IngredientID) == "" {
+				return service.FieldError(optionField+".ingredient_id", "substitution option ingredient ID is required")
+			}
+			if strings.TrimSpace(option.Name) == "" {
+				return service.FieldError(optionField+".name", "substitution option name is required")
+			}
+			if option.Price < 0 {
+				return service.FieldError(optionField+".price", "substitution option price cannot be negative")
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateInventoryItem checks an inventory item. The ingredient ID may be left
+// empty on create for the service to derive one from the name.
+func validateInventoryItem(item *models.InventoryItem) error {
+	item.IngredientID = strings.TrimSpace(item.IngredientID)
 	if strings.TrimSpace(item.Name) == "" {
-		return errors.New("name is required")
+		return service.FieldError("name", "name is required")
 	}
 	if strings.TrimSpace(item.Unit) == "" {
-		return errors.New("unit is required")
+		return service.FieldError("unit", "unit is required")
 	}
+	item.SupplierID = strings.TrimSpace(item.SupplierID)
 	if item.Quantity < 0 {
-		return errors.New("quantity cannot be negative")
+		return service.FieldError("quantity", "quantity cannot be negative")
+	}
+	if item.UnitCost < 0 {
+		return service.FieldError("unit_cost", "unit cost cannot be negative")
+	}
+	if item.ReorderPoint < 0 {
+		return service.FieldError("reorder_point", "reorder point cannot be negative")
+	}
+	if item.ParLevel < 0 {
+		return service.FieldError("par_level", "par level cannot be negative")
+	}
+	if item.ParLevel > 0 && item.ParLevel <= item.ReorderPoint {
+		return service.FieldError("par_level", "par level must be above the reorder point")
+	}
+
+	return validateConversions(item)
+}
+
+// validateConversions checks an ingredient's custom units, which must not
+// redefine a known unit and must convert to the unit it is stocked in.
+func validateConversions(item *models.InventoryItem) error {
+	seen := make(map[string]bool, len(item.Conversions))
+	for i := range item.Conversions {
+		conversion := &item.Conversions[i]
+		field := fmt.Sprintf("conversions[%d]", i)
+		conversion.Unit = strings.TrimSpace(conversion.Unit)
+		conversion.To = strings.TrimSpace(conversion.To)
+		if conversion.Unit == "" {
+			return service.FieldError(field+".unit", "unit is required")
+		}
+		if _, known := units.Lookup(conversion.Unit); known {
+			return service.FieldError(field+".unit", "%s is a standard unit and cannot be redefined", conversion.Unit)
+		}
+		if seen[units.Normalize(conversion.Unit)] {
+			return service.FieldError(field+".unit", "duplicate unit: %s", conversion.Unit)
+		}
+		seen[units.Normalize(conversion.Unit)] = true
+		if conversion.Quantity <= 0 {
+			return service.FieldError(field+".quantity", "quantity must be greater than 0")
+		}
+		if conversion.To == "" {
+			return service.FieldError(field+".to", "unit to convert to is required")
+		}
+	}
+
+	converter := units.NewConverter(item.Conversions...)
+	for i, conversion := range item.Conversions {
+		if !converter.Compatible(conversion.Unit, item.Unit) {
+			return service.FieldError(fmt.Sprintf("conversions[%d].to", i), "%s does not convert to %s, the unit %s is stocked in", conversion.Unit, item.Unit, item.Name)
+		}
+	}
+	return nil
+}
+
+func validateSupplier(supplier *models.Supplier) error {
+	supplier.ID = strings.TrimSpace(supplier.ID)
+	if strings.TrimSpace(supplier.Name) == "" {
+		return service.FieldError("name", "name is required")
+	}
+	if supplier.LeadTimeDays < 0 {
+		return service.FieldError("lead_time_days", "lead time cannot be negative")
+	}
+
+	return nil
+}
+
+func validateLocation(location *models.Location) error {
+	location.ID = strings.TrimSpace(location.ID)
+	if strings.TrimSpace(location.Name) == "" {
+		return service.FieldError("name", "name is required")
+	}
+
+	return nil
+}
+
+func validateCategory(category *models.Category) error {
+	category.ID = strings.TrimSpace(category.ID)
+	if strings.TrimSpace(category.Name) == "" {
+		return service.FieldError("name", "name is required")
+	}
+
+	return nil
+}
+
+var weekdays = []string{"mon", "tue", "wed", "thu", "fri", "sat", "sun"}
+
+// validateSchedules checks the availability windows of a menu item and
+// shortens weekday names to their first three letters.
+func validateSchedules(schedules []models.MenuSchedule) error {
+	for i := range schedules {
+		schedule := &schedules[i]
+		field := fmt.Sprintf("schedules[%d]", i)
+
+		for j, day := range schedule.Days {
+			day = strings.ToLower(strings.TrimSpace(day))
+			if len(day) >= 3 {
+				day = day[:3]
+			}
+			if !slices.Contains(weekdays, day) {
+				return service.FieldError(fmt.Sprintf("%s.days[%d]", field, j), "day must be a weekday such as mon or monday")
+			}
+			schedule.Days[j] = day
+		}
+
+		if (schedule.From == "") != (schedule.To == "") {
+			return service.FieldError(field, "from and to must be given together")
+		}
+		for _, clock := range []struct {
+			name  string
+			value *string
+		}{{"from", &schedule.From}, {"to", &schedule.To}} {
+			if *clock.value == "" {
+				continue
+			}
+			parsed, err := time.Parse("15:04", *clock.value)
+			if err != nil {
+				return service.FieldError(field+"."+clock.name, "%s must be a time such as 07:30", clock.name)
+			}
+			*clock.value = parsed.Format("15:04")
+		}
+		if schedule.From != "" && schedule.From == schedule.To {
+			return service.FieldError(field+".to", "to must differ from from")
+		}
+
+		yearly := false
+		for _, date := range []struct {
+			name  string
+			value string
+		}{{"start_date", schedule.StartDate}, {"end_date", schedule.EndDate}} {
+			if date.value == "" {
+				continue
+			}
+			if _, err := time.Parse("01-02", date.value); err == nil {
+				yearly = true
+				continue
+			}
+			if _, err := time.Parse(time.DateOnly, date.value); err != nil {
+				return service.FieldError(field+"."+date.name, "%s must be a date such as 2026-09-01, or 09-01 to repeat every year", date.name)
+			}
+		}
+		if schedule.StartDate != "" && schedule.EndDate != "" {
+			if len(schedule.StartDate) != len(schedule.EndDate) {
+				return service.FieldError(field+".end_date", "start_date and end_date must both repeat every year or neither")
+			}
+			if !yearly && schedule.EndDate < schedule.StartDate {
+				return service.FieldError(field+".end_date", "end_date must not be before start_date")
+			}
+		}
+	}
+	return nil
+}
+
+// normalizeTags lowercases and trims a menu item's tags and drops repeated
+// ones.
+func normalizeTags(item *models.MenuItem) error {
+	tags := make([]string, 0, len(item.Tags))
+	for i, tag := range item.Tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" {
+			return service.FieldError(fmt.Sprintf("tags[%d]", i), "tag cannot be empty")
+		}
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	item.Tags = tags
+	return nil
+}
+
+// validatePurchaseOrder checks a new purchase order. Each ingredient may only
+// appear on one line.
+func validatePurchaseOrder(order *models.PurchaseOrder) error {
+	order.SupplierID = strings.TrimSpace(order.SupplierID)
+	if order.SupplierID == "" {
+		return service.FieldError("supplier_id", "supplier ID is required")
+	}
+	order.LocationID = strings.TrimSpace(order.LocationID)
+	if len(order.Lines) == 0 {
+		return service.FieldError("lines", "purchase order must have at least one line")
+	}
+
+	seen := make(map[string]bool, len(order.Lines))
+	for i := range order.Lines {
+		line := &order.Lines[i]
+		field := fmt.Sprintf("lines[%d]", i)
+		line.IngredientID = strings.TrimSpace(line.IngredientID)
+		line.Unit = strings.TrimSpace(line.Unit)
+		if line.IngredientID == "" {
+			return service.FieldError(field+".ingredient_id", "ingredient ID is required")
+		}
+		if seen[line.IngredientID] {
+			return service.FieldError(field+".ingredient_id", "ingredient %s is listed more than once", line.IngredientID)
+		}
+		seen[line.IngredientID] = true
+		if line.Quantity <= 0 {
+			return service.FieldError(field+".quantity", "quantity must be greater than 0")
+		}
+		if line.UnitCost < 0 {
+			return service.FieldError(field+".unit_cost", "unit cost cannot be negative")
+		}
+	}
+
+	return nil
+}
+
+// validateReceipt checks a delivery against a purchase order. A receipt
+// without lines receives everything outstanding. Expiry dates are in zone.
+func validateReceipt(receipt *models.PurchaseOrderReceipt, zone *time.Location) error {
+	seen := make(map[string]bool, len(receipt.Lines))
+	for i := range receipt.Lines {
+		line := &receipt.Lines[i]
+		field := fmt.Sprintf("lines[%d]", i)
+		line.IngredientID = strings.TrimSpace(line.IngredientID)
+		if line.IngredientID == "" {
+			return service.FieldError(field+".ingredient_id", "ingredient ID is required")
+		}
+		if seen[line.IngredientID] {
+			return service.FieldError(field+".ingredient_id", "ingredient %s is listed more than once", line.IngredientID)
+		}
+		seen[line.IngredientID] = true
+		if line.Quantity <= 0 {
+			return service.FieldError(field+".quantity", "quantity must be greater than 0")
+		}
+		line.LotID = ""
+		if err := validateExpiry(field+".expires_at", &line.ExpiresAt, zone); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateStockCount checks the ingredients a count is started for. A count
+// without lines covers the whole inventory.
+func validateStockCount(count *models.StockCount) error {
+	count.LocationID = strings.TrimSpace(count.LocationID)
+	count.Note = strings.TrimSpace(count.Note)
+	seen := make(map[string]bool, len(count.Lines))
+	for i := range count.Lines {
+		line := &count.Lines[i]
+		field := fmt.Sprintf("lines[%d].ingredient_id", i)
+		line.IngredientID = strings.TrimSpace(line.IngredientID)
+		if line.IngredientID == "" {
+			return service.FieldError(field, "ingredient ID is required")
+		}
+		if seen[line.IngredientID] {
+			return service.FieldError(field, "ingredient %s is listed more than once", line.IngredientID)
+		}
+		seen[line.IngredientID] = true
+	}
+
+	return nil
+}
+
+// validateCountSubmission checks quantities counted during a stock count.
+func validateCountSubmission(submission *models.CountSubmission) error {
+	if len(submission.Lines) == 0 {
+		return service.FieldError("lines", "submission must count at least one ingredient")
+	}
+
+	seen := make(map[string]bool, len(submission.Lines))
+	for i := range submission.Lines {
+		line := &submission.Lines[i]
+		field := fmt.Sprintf("lines[%d]", i)
+		line.IngredientID = strings.TrimSpace(line.IngredientID)
+		line.Unit = strings.TrimSpace(line.Unit)
+		if line.IngredientID == "" {
+			return service.FieldError(field+".ingredient_id", "ingredient ID is required")
+		}
+		if seen[line.IngredientID] {
+			return service.FieldError(field+".ingredient_id", "ingredient %s is listed more than once", line.IngredientID)
+		}
+		seen[line.IngredientID] = true
+		if line.Quantity < 0 {
+			return service.FieldError(field+".quantity", "quantity cannot be negative")
+		}
 	}
 
 	return nil
 }
+
+// validateMovement checks a manual stock change. Sales and cancellations are
+// only recorded by orders, and opening balances by creating an ingredient.
+// Expiry dates are in zone.
+func validateMovement(movement *models.InventoryMovement, zone *time.Location) error {
+	switch movement.Reason {
+	case models.MovementReasonRestock:
+		if movement.Delta <= 0 {
+			return service.FieldError("delta", "a restock must add stock")
+		}
+	case models.MovementReasonWaste:
+		if movement.Delta >= 0 {
+			return service.FieldError("delta", "waste must remove stock")
+		}
+		if movement.WasteReason == "" {
+			movement.WasteReason = models.WasteReasonOther
+		}
+		if !isWasteReason(movement.WasteReason) {
+			return service.FieldError("waste_reason", "waste reason must be one of %s", strings.Join(wasteReasons, ", "))
+		}
+	case models.MovementReasonAdjustment:
+		if movement.Delta == 0 {
+			return service.FieldError("delta", "delta cannot be 0")
+		}
+	case models.MovementReasonCostAdjustment:
+		if movement.Delta != 0 {
+			return service.FieldError("delta", "a cost adjustment cannot change stock")
+		}
+		if movement.UnitCost <= 0 {
+			return service.FieldError("unit_cost", "a cost adjustment must give the new unit cost")
+		}
+	default:
+		return service.FieldError("reason", "reason must be restock, waste, adjustment or cost_adjustment")
+	}
+
+	if movement.WasteReason != "" && movement.Reason != models.MovementReasonWaste {
+		return service.FieldError("waste_reason", "only waste can give a waste reason")
+	}
+
+	movement.LocationID = strings.TrimSpace(movement.LocationID)
+	movement.LotID = strings.TrimSpace(movement.LotID)
+	if movement.LotID != "" && movement.Delta > 0 {
+		return service.FieldError("lot_id", "only stock taken out can name a lot")
+	}
+	if movement.UnitCost < 0 {
+		return service.FieldError("unit_cost", "unit cost cannot be negative")
+	}
+	if movement.UnitCost > 0 && movement.Reason != models.MovementReasonRestock && movement.Reason != models.MovementReasonCostAdjustment {
+		return service.FieldError("unit_cost", "only a restock or a cost adjustment can give a unit cost")
+	}
+	if movement.ExpiresAt != "" && movement.Reason != models.MovementReasonRestock {
+		return service.FieldError("expires_at", "only a restock can set an expiry")
+	}
+	return validateExpiry("expires_at", &movement.ExpiresAt, zone)
+}
+
+var wasteReasons = []string{
+	models.WasteReasonSpillage,
+	models.WasteReasonDropped,
+	models.WasteReasonExpired,
+	models.WasteReasonDamaged,
+	models.WasteReasonQuality,
+	models.WasteReasonOther,
+}
+
+func isWasteReason(reason string) bool {
+	return slices.Contains(wasteReasons, reason)
+}
+
+// validateWaste checks a waste record, which names either one ingredient or
+// finished menu items but not both.
+func validateWaste(waste *models.WasteRecord) error {
+	waste.IngredientID = strings.TrimSpace(waste.IngredientID)
+	waste.Unit = strings.TrimSpace(waste.Unit)
+	waste.LotID = strings.TrimSpace(waste.LotID)
+	waste.LocationID = strings.TrimSpace(waste.LocationID)
+
+	switch {
+	case waste.IngredientID != "" && len(waste.Items) > 0:
+		return service.FieldError("ingredient_id", "waste must name either an ingredient or items, not both")
+	case waste.IngredientID != "":
+		if waste.Quantity <= 0 {
+			return service.FieldError("quantity", "quantity must be greater than 0")
+		}
+	case len(waste.Items) > 0:
+		if waste.Quantity != 0 || waste.Unit != "" || waste.LotID != "" {
+			return service.FieldError("items", "quantity, unit and lot_id only apply to an ingredient")
+		}
+		for i := range waste.Items {
+			if err := validateOrderItem(fmt.Sprintf("items[%d]", i), &waste.Items[i]); err != nil {
+				return err
+			}
+		}
+	default:
+		return service.FieldError("ingredient_id", "waste must name an ingredient or items")
+	}
+
+	if !isWasteReason(waste.Reason) {
+		return service.FieldError("reason", "reason must be one of %s", strings.Join(wasteReasons, ", "))
+	}
+	waste.Note = strings.TrimSpace(waste.Note)
+	return nil
+}
+
+// validateTransfer checks a move of stock between two locations.
+func validateTransfer(transfer *models.StockTransfer) error {
+	transfer.IngredientID = strings.TrimSpace(transfer.IngredientID)
+	transfer.FromLocationID = strings.TrimSpace(transfer.FromLocationID)
+	transfer.ToLocationID = strings.TrimSpace(transfer.ToLocationID)
+	transfer.Unit = strings.TrimSpace(transfer.Unit)
+	transfer.Note = strings.TrimSpace(transfer.Note)
+	if transfer.IngredientID == "" {
+		return service.FieldError("ingredient_id", "ingredient ID is required")
+	}
+	if transfer.FromLocationID == "" {
+		return service.FieldError("from_location_id", "source location is required")
+	}
+	if transfer.ToLocationID == "" {
+		return service.FieldError("to_location_id", "destination location is required")
+	}
+	if transfer.FromLocationID == transfer.ToLocationID {
+		return service.FieldError("to_location_id", "stock must move to another location")
+	}
+	if transfer.Quantity <= 0 {
+		return service.FieldError("quantity", "quantity must be greater than 0")
+	}
+
+	return nil
+}
+
+// validateExpiry checks the expiry of stock being received, which must be a
+// date in zone or RFC 3339 timestamp that has not passed.
+func validateExpiry(field string, expiresAt *string, zone *time.Location) error {
+	*expiresAt = strings.TrimSpace(*expiresAt)
+	if *expiresAt == "" {
+		return nil
+	}
+	expiry, err := parseTime(*expiresAt, true, zone)
+	if err != nil {
+		return service.FieldError(field, "expiry must be a date or RFC 3339 timestamp")
+	}
+	if expiry.Before(time.Now()) {
+		return service.FieldError(field, "stock expired on %s cannot be received", *expiresAt)
+	}
+	return nil
+}
+
+// parseDays reads the optional days query parameter, defaulting to def.
+func parseDays(r *http.Request, def int) (int, error) {
+	value := r.URL.Query().Get("days")
+	if value == "" {
+		return def, nil
+	}
+
+	days, err := strconv.Atoi(value)
+	if err != nil || days < 0 {
+		return 0, service.FieldError("days", "days must be a non-negative whole number")
+	}
+	return days, nil
+}
@@ -0,0 +1,40 @@
+// internal/notify/file.go
+package notify
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+
+	"hot-coffee/models"
+)
+
+// fileNotifier appends each alert as a line of JSON to a file.
+type fileNotifier struct {
+	path  string
+	mutex sync.Mutex
+}
+
+func NewFileNotifier(path string) Notifier {
+	return &fileNotifier{path: path}
+}
+
+func (n *fileNotifier) NotifyLowStock(alert models.LowStockAlert) error {
+	line, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	file, err := os.OpenFile(n.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
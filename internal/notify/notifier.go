@@ -0,0 +1,33 @@
+// internal/notify/notifier.go
+package notify
+
+import (
+	"errors"
+
+	"hot-coffee/models"
+)
+
+// Notifier delivers low-stock alerts to staff.
+type Notifier interface {
+	NotifyLowStock(alert models.LowStockAlert) error
+}
+
+// multiNotifier sends every alert to each of its notifiers.
+type multiNotifier struct {
+	notifiers []Notifier
+}
+
+// Multi returns a Notifier that sends every alert to all of notifiers.
+func Multi(notifiers ...Notifier) Notifier {
+	return &multiNotifier{notifiers: notifiers}
+}
+
+func (m *multiNotifier) NotifyLowStock(alert models.LowStockAlert) error {
+	var errs []error
+	for _, notifier := range m.notifiers {
+		if err := notifier.NotifyLowStock(alert); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,52 @@
+// internal/repository/category_repository.go
+package repository
+
+import (
+	"path/filepath"
+
+	"hot-coffee/models"
+)
+
+const categoriesFileName = "categories.json"
+
+type categoryRepository struct {
+	store *jsonStore[models.Category]
+}
+
+func NewCategoryRepository(dataDir string) CategoryRepository {
+	return &categoryRepository{
+		store: newJSONStore(filepath.Join(dataDir, categoriesFileName), categoryKey),
+	}
+}
+
+func categoryKey(category *models.Category) string {
+	return category.ID
+}
+
+func (r *categoryRepository) Create(category *models.Category) error {
+	return r.store.Insert(category)
+}
+
+func (r *categoryRepository) GetByID(id string) (*models.Category, error) {
+	return r.store.Get(id)
+}
+
+func (r *categoryRepository) GetAll() ([]*models.Category, error) {
+	return r.store.All()
+}
+
+func (r *categoryRepository) Update(category *models.Category) error {
+	found, err := r.store.Replace(category)
+	if err == nil && !found {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (r *categoryRepository) Delete(id string) error {
+	found, err := r.store.Remove(id)
+	if err == nil && !found {
+		return ErrNotFound
+	}
+	return err
+}
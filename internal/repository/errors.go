@@ -0,0 +1,12 @@
+// internal/repository/errors.go
+package repository
+
+import "errors"
+
+var (
+	// ErrDuplicateID is returned when creating a record whose ID is already taken.
+	ErrDuplicateID = errors.New("duplicate id")
+
+	// ErrNotFound is returned when updating or deleting a record that does not exist.
+	ErrNotFound = errors.New("record not found")
+)
@@ -0,0 +1,55 @@
+// internal/repository/integrity.go
+package repository
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// DuplicateID is an ID that several records of a data file share. Only the
+// first of them is reachable through the repositories.
+type DuplicateID struct {
+	File  string
+	ID    string
+	Count int
+}
+
+// FindDuplicateIDs scans the JSON data files in dataDir for records that share
+// an ID, as written by versions that did not reject duplicates.
+func FindDuplicateIDs(dataDir string) ([]DuplicateID, error) {
+	checks := []struct {
+		name       string
+		duplicates func() (map[string]int, error)
+	}{
+		{ordersFileName, newJSONStore(filepath.Join(dataDir, ordersFileName), orderKey).duplicates},
+		{menuItemsFileName, newJSONStore(filepath.Join(dataDir, menuItemsFileName), menuItemKey).duplicates},
+		{inventoryFileName, newJSONStore(filepath.Join(dataDir, inventoryFileName), inventoryItemKey).duplicates},
+		{suppliersFileName, newJSONStore(filepath.Join(dataDir, suppliersFileName), supplierKey).duplicates},
+		{purchaseOrdersFileName, newJSONStore(filepath.Join(dataDir, purchaseOrdersFileName), purchaseOrderKey).duplicates},
+		{locationsFileName, newJSONStore(filepath.Join(dataDir, locationsFileName), locationKey).duplicates},
+		{categoriesFileName, newJSONStore(filepath.Join(dataDir, categoriesFileName), categoryKey).duplicates},
+		{stockCountsFileName, newJSONStore(filepath.Join(dataDir, stockCountsFileName), stockCountKey).duplicates},
+		{movementsFileName, newMovementRepository(dataDir).duplicates},
+	}
+
+	var result []DuplicateID
+	for _, check := range checks {
+		counts, err := check.duplicates()
+		if err != nil {
+			return nil, fmt.Errorf("check %s: %w", check.name, err)
+		}
+		for id, count := range counts {
+			result = append(result, DuplicateID{File: check.name, ID: id, Count: count})
+		}
+	}
+
+	slices.SortFunc(result, func(a, b DuplicateID) int {
+		if c := strings.Compare(a.File, b.File); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return result, nil
+}
@@ -3,6 +3,9 @@ package repository
 
 import "hot-coffee/models"
 
+// Repositories return records owned by the caller: mutating a returned record
+// has no effect until it is passed back to Create or Update.
+
 type OrderRepository interface {
 	Create(order *models.Order) error
 	GetByID(id string) (*models.Order, error)
@@ -26,3 +29,52 @@ type InventoryRepository interface {
 	Update(item *models.InventoryItem) error
 	Delete(id string) error
 }
+
+type SupplierRepository interface {
+	Create(supplier *models.Supplier) error
+	GetByID(id string) (*models.Supplier, error)
+	GetAll() ([]*models.Supplier, error)
+	Update(supplier *models.Supplier) error
+	Delete(id string) error
+}
+
+type LocationRepository interface {
+	Create(location *models.Location) error
+	GetByID(id string) (*models.Location, error)
+	GetAll() ([]*models.Location, error)
+	Update(location *models.Location) error
+	Delete(id string) error
+}
+
+type CategoryRepository interface {
+	Create(category *models.Category) error
+	GetByID(id string) (*models.Category, error)
+	GetAll() ([]*models.Category, error)
+	Update(category *models.Category) error
+	Delete(id string) error
+}
+
+type PurchaseOrderRepository interface {
+	Create(order *models.PurchaseOrder) error
+	GetByID(id string) (*models.PurchaseOrder, error)
+	GetAll() ([]*models.PurchaseOrder, error)
+	Update(order *models.PurchaseOrder) error
+	Delete(id string) error
+}
+
+type StockCountRepository interface {
+	Create(count *models.StockCount) error
+	GetByID(id string) (*models.StockCount, error)
+	GetAll() ([]*models.StockCount, error)
+	Update(count *models.StockCount) error
+	Delete(id string) error
+}
+
+// MovementRepository stores the inventory ledger. Movements are never changed
+// or removed once appended.
+type MovementRepository interface {
+	// Append stores movements atomically: either all of them or none.
+	Append(movements ...*models.InventoryMovement) error
+	GetByIngredient(ingredientID string) ([]*models.InventoryMovement, error)
+	GetAll() ([]*models.InventoryMovement, error)
+}
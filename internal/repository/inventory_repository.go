@@ -2,130 +2,51 @@
 package repository
 
 import (
-	"encoding/json"
-	"os"
 	"path/filepath"
-	"sync"
 
 	"hot-coffee/models"
 )
 
+const inventoryFileName = "inventory.json"
+
 type inventoryRepository struct {
-	dataDir string
-	mutex   sync.RWMutex
+	store *jsonStore[models.InventoryItem]
 }
 
 func NewInventoryRepository(dataDir string) InventoryRepository {
 	return &inventoryRepository{
-		dataDir: dataDir,
-	}
-}
-
-func (r *inventoryRepository) getFilePath() string {
-	return filepath.Join(r.dataDir, "inventory.json")
-}
-
-func (r *inventoryRepository) loadInventoryItems() ([]*models.InventoryItem, error) {
-	filePath := r.getFilePath()
-
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		return []*models.InventoryItem{}, nil
-	}
-
-	data, err := os.ReadFile(filePath)
-	if err != nil {
-		return nil, err
-	}
-
-	var items []*models.InventoryItem
-	if err := json.Unmarshal(data, &items); err != nil {
-		return nil, err
+		store: newJSONStore(filepath.Join(dataDir, inventoryFileName), inventoryItemKey),
 	}
-
-	return items, nil
 }
 
-func (r *inventoryRepository) saveInventoryItems(items []*models.InventoryItem) error {
-	data, err := json.MarshalIndent(items, "", "  ")
-	if err != nil {
-		return err
-	}
-
-	return os.WriteFile(r.getFilePath(), data, 0o644)
+func inventoryItemKey(item *models.InventoryItem) string {
+	return item.IngredientID
 }
 
 func (r *inventoryRepository) Create(item *models.InventoryItem) error {
-	r.mutex.Lock()
-	defer r.mutex.Unlock()
-
-	items, err := r.loadInventoryItems()
-	if err != nil {
-		return err
-	}
-
-	items = append(items, item)
-	return r.saveInventoryItems(items)
+	return r.store.Insert(item)
 }
 
 func (r *inventoryRepository) GetByID(id string) (*models.InventoryItem, error) {
-	r.mutex.RLock()
-	defer r.mutex.RUnlock()
-
-	items, err := r.loadInventoryItems()
-	if err != nil {
-		return nil, err
-	}
-
-	for _, item := range items {
-		if item.IngredientID == id {
-			return item, nil
-		}
-	}
-
-	return nil, nil
+	return r.store.Get(id)
 }
 
 func (r *inventoryRepository) GetAll() ([]*models.InventoryItem, error) {
-	r.mutex.RLock()
-	defer r.mutex.RUnlock()
-
-	return r.loadInventoryItems()
+	return r.store.All()
 }
 
 func (r *inventoryRepository) Update(item *models.InventoryItem) error {
-	r.mutex.Lock()
-	defer r.mutex.Unlock()
-
-	items, err := r.loadInventoryItems()
-	if err != nil {
-		return err
+	found, err := r.store.Replace(item)
+	if err == nil && !found {
+		return ErrNotFound
 	}
-
-	for i, existingItem := range items {
-		if existingItem.IngredientID == item.IngredientID {
-			items[i] = item
-			return r.saveInventoryItems(items)
-		}
-	}
-
-	return nil
+	return err
 }
 
 func (r *inventoryRepository) Delete(id string) error {
-	r.mutex.Lock()
-	defer r.mutex.Unlock()
-
-	items, err := r.loadInventoryItems()
-	if err != nil {
-		return err
+	found, err := r.store.Remove(id)
+	if err == nil && !found {
+		return ErrNotFound
 	}
-
-	for i, item := range items {
-		if item.IngredientID == id {
-			items = append(items[:i], items[i+1:]...)
-			return r.saveInventoryItems(items)
-		}
-	}
-
-	return nil
+	return err
 }
@@ -0,0 +1,123 @@
+// internal/repository/journal.go
+package repository
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"time"
+)
+
+const (
+	journalSuffix = ".journal"
+	// maxJournalSize bounds the journal; once exceeded it is compacted to the latest entry
+	maxJournalSize = 4 << 20
+)
+
+// journal is an append-only log of full snapshots of a single data file.
+// Every snapshot is fsynced to the journal before the data file is replaced,
+// so the last complete entry always holds the latest committed state.
+type journal struct {
+	path string
+}
+
+type journalEntry struct {
+	Timestamp string          `json:"timestamp"`
+	Data      json.RawMessage `json:"data"`
+}
+
+func newJournal(dataFilePath string) *journal {
+	return &journal{path: dataFilePath + journalSuffix}
+}
+
+func (j *journal) encodeEntry(data []byte) ([]byte, error) {
+	line, err := json.Marshal(journalEntry{
+		Timestamp: time.Now().Format(time.RFC3339Nano),
+		Data:      data,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append(line, '\n'), nil
+}
+
+// Append durably records a snapshot of the data file contents.
+func (j *journal) Append(data []byte) error {
+	line, err := j.encodeEntry(data)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(j.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.Write(line); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// LastEntry returns the data of the newest complete entry, skipping a torn
+// trailing line left by a crash. It returns nil if the journal has no entries.
+func (j *journal) LastEntry() ([]byte, error) {
+	content, err := os.ReadFile(j.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var last []byte
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 64*1024), len(content)+1)
+	for scanner.Scan() {
+		var entry journalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		if !isValidJSONArray(entry.Data) {
+			continue
+		}
+		last = entry.Data
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if last == nil {
+		return nil, nil
+	}
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, last, "", "  "); err != nil {
+		return nil, err
+	}
+	return indented.Bytes(), nil
+}
+
+// Checkpoint replaces the journal with a single entry holding data.
+func (j *journal) Checkpoint(data []byte) error {
+	line, err := j.encodeEntry(data)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(j.path, line, 0o644)
+}
+
+// CheckpointIfNeeded compacts the journal once it grows past maxJournalSize.
+// It must only be called after data has been durably written to the data file.
+func (j *journal) CheckpointIfNeeded(data []byte) error {
+	info, err := os.Stat(j.path)
+	if err != nil {
+		return err
+	}
+	if info.Size() <= maxJournalSize {
+		return nil
+	}
+	return j.Checkpoint(data)
+}
@@ -0,0 +1,306 @@
+// internal/repository/json_store.go
+package repository
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// jsonStore keeps the records of one JSON data file in memory, indexed by ID,
+// and writes every change through to disk. Before each operation it checks the
+// file's size and modification time and reloads it if it changed on disk.
+//
+// Records are cached in their encoded form, so every read decodes a fresh copy
+// that the caller owns.
+type jsonStore[T any] struct {
+	path  string
+	keyOf func(*T) string
+	mutex sync.RWMutex
+
+	loaded  bool
+	state   fileState
+	records []json.RawMessage
+	keys    []string
+	index   map[string]int
+}
+
+type fileState struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+func (s fileState) equal(other fileState) bool {
+	return s.exists == other.exists && s.size == other.size && s.modTime.Equal(other.modTime)
+}
+
+func newJSONStore[T any](path string, keyOf func(*T) string) *jsonStore[T] {
+	return &jsonStore[T]{
+		path:  path,
+		keyOf: keyOf,
+	}
+}
+
+func (s *jsonStore[T]) stat() (fileState, error) {
+	info, err := os.Stat(s.path)
+	if os.IsNotExist(err) {
+		return fileState{}, nil
+	}
+	if err != nil {
+		return fileState{}, err
+	}
+	return fileState{exists: true, size: info.Size(), modTime: info.ModTime()}, nil
+}
+
+// reload replaces the cache with the file contents. The caller must hold the write lock.
+func (s *jsonStore[T]) reload(state fileState) error {
+	var records []json.RawMessage
+	if state.exists {
+		data, err := os.ReadFile(s.path)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(data, &records); err != nil {
+			return err
+		}
+	}
+
+	keys := make([]string, len(records))
+	for i, record := range records {
+		item, err := s.decode(record)
+		if err != nil {
+			return err
+		}
+		keys[i] = s.keyOf(item)
+	}
+
+	if s.loaded {
+		slog.Info("Data file changed on disk, reloading", "file", s.path)
+	}
+
+	s.records = records
+	s.keys = keys
+	s.index = buildIndex(keys)
+	s.state = state
+	s.loaded = true
+	return nil
+}
+
+// buildIndex maps each ID to its first record, matching a linear scan.
+func buildIndex(keys []string) map[string]int {
+	index := make(map[string]int, len(keys))
+	for i, key := range keys {
+		if _, ok := index[key]; !ok {
+			index[key] = i
+		}
+	}
+	return index
+}
+
+// ensureFresh reloads the cache if it was never loaded or the file changed.
+// The caller must hold the write lock.
+func (s *jsonStore[T]) ensureFresh() error {
+	state, err := s.stat()
+	if err != nil {
+		return err
+	}
+	if s.loaded && s.state.equal(state) {
+		return nil
+	}
+	return s.reload(state)
+}
+
+// read runs fn under the read lock once the cache is known to be fresh.
+func (s *jsonStore[T]) read(fn func() error) error {
+	s.mutex.RLock()
+	state, err := s.stat()
+	if err == nil && s.loaded && s.state.equal(state) {
+		defer s.mutex.RUnlock()
+		return fn()
+	}
+	s.mutex.RUnlock()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := s.ensureFresh(); err != nil {
+		return err
+	}
+	return fn()
+}
+
+// write runs fn under the write lock on a fresh cache.
+func (s *jsonStore[T]) write(fn func() error) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := s.ensureFresh(); err != nil {
+		return err
+	}
+	return fn()
+}
+
+// persist saves records to disk and makes them the cached state only once the write succeeded.
+func (s *jsonStore[T]) persist(records []json.RawMessage, keys []string) error {
+	if records == nil {
+		records = []json.RawMessage{}
+	}
+	if err := saveJSONFile(s.path, records); err != nil {
+		return err
+	}
+
+	state, err := s.stat()
+	if err != nil {
+		return err
+	}
+
+	s.records = records
+	s.keys = keys
+	s.index = buildIndex(keys)
+	s.state = state
+	return nil
+}
+
+func (s *jsonStore[T]) decode(record json.RawMessage) (*T, error) {
+	item := new(T)
+	if err := json.Unmarshal(record, item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// Get returns the record with the given ID, or nil if there is none.
+func (s *jsonStore[T]) Get(id string) (*T, error) {
+	var item *T
+	err := s.read(func() error {
+		i, ok := s.index[id]
+		if !ok {
+			return nil
+		}
+
+		var err error
+		item, err = s.decode(s.records[i])
+		return err
+	})
+	return item, err
+}
+
+// All returns every record in file order.
+func (s *jsonStore[T]) All() ([]*T, error) {
+	var items []*T
+	err := s.read(func() error {
+		items = make([]*T, 0, len(s.records))
+		for _, record := range s.records {
+			item, err := s.decode(record)
+			if err != nil {
+				return err
+			}
+			items = append(items, item)
+		}
+		return nil
+	})
+	return items, err
+}
+
+// Insert appends a record, failing with ErrDuplicateID if its ID is taken.
+func (s *jsonStore[T]) Insert(item *T) error {
+	return s.InsertAll([]*T{item})
+}
+
+// InsertAll appends records in a single write, failing with ErrDuplicateID
+// without writing any of them if one of their IDs is taken.
+func (s *jsonStore[T]) InsertAll(items []*T) error {
+	newRecords := make([]json.RawMessage, len(items))
+	newKeys := make([]string, len(items))
+	for i, item := range items {
+		record, err := json.Marshal(item)
+		if err != nil {
+			return err
+		}
+		newRecords[i] = record
+		newKeys[i] = s.keyOf(item)
+	}
+
+	return s.write(func() error {
+		seen := make(map[string]bool, len(newKeys))
+		for _, key := range newKeys {
+			if _, ok := s.index[key]; ok || seen[key] {
+				return ErrDuplicateID
+			}
+			seen[key] = true
+		}
+
+		records := append(s.records[:len(s.records):len(s.records)], newRecords...)
+		keys := append(s.keys[:len(s.keys):len(s.keys)], newKeys...)
+		return s.persist(records, keys)
+	})
+}
+
+// Replace overwrites the record with the same ID. It reports whether one existed.
+func (s *jsonStore[T]) Replace(item *T) (bool, error) {
+	record, err := json.Marshal(item)
+	if err != nil {
+		return false, err
+	}
+
+	found := false
+	err = s.write(func() error {
+		i, ok := s.index[s.keyOf(item)]
+		if !ok {
+			return nil
+		}
+		found = true
+
+		records := make([]json.RawMessage, len(s.records))
+		copy(records, s.records)
+		records[i] = record
+		return s.persist(records, s.keys)
+	})
+	return found, err
+}
+
+// Remove deletes the record with the given ID. It reports whether one existed.
+func (s *jsonStore[T]) Remove(id string) (bool, error) {
+	found := false
+	err := s.write(func() error {
+		i, ok := s.index[id]
+		if !ok {
+			return nil
+		}
+		found = true
+
+		records := make([]json.RawMessage, 0, len(s.records)-1)
+		records = append(records, s.records[:i]...)
+		records = append(records, s.records[i+1:]...)
+		keys := make([]string, 0, len(s.keys)-1)
+		keys = append(keys, s.keys[:i]...)
+		keys = append(keys, s.keys[i+1:]...)
+		return s.persist(records, keys)
+	})
+	return found, err
+}
+
+// duplicates returns the number of records of every ID that more than one
+// record shares.
+func (s *jsonStore[T]) duplicates() (map[string]int, error) {
+	counts := make(map[string]int)
+	err := s.read(func() error {
+		for _, key := range s.keys {
+			counts[key]++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for key, count := range counts {
+		if count < 2 {
+			delete(counts, key)
+		}
+	}
+	return counts, nil
+}
@@ -0,0 +1,52 @@
+// internal/repository/location_repository.go
+package repository
+
+import (
+	"path/filepath"
+
+	"hot-coffee/models"
+)
+
+const locationsFileName = "locations.json"
+
+type locationRepository struct {
+	store *jsonStore[models.Location]
+}
+
+func NewLocationRepository(dataDir string) LocationRepository {
+	return &locationRepository{
+		store: newJSONStore(filepath.Join(dataDir, locationsFileName), locationKey),
+	}
+}
+
+func locationKey(location *models.Location) string {
+	return location.ID
+}
+
+func (r *locationRepository) Create(location *models.Location) error {
+	return r.store.Insert(location)
+}
+
+func (r *locationRepository) GetByID(id string) (*models.Location, error) {
+	return r.store.Get(id)
+}
+
+func (r *locationRepository) GetAll() ([]*models.Location, error) {
+	return r.store.All()
+}
+
+func (r *locationRepository) Update(location *models.Location) error {
+	found, err := r.store.Replace(location)
+	if err == nil && !found {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (r *locationRepository) Delete(id string) error {
+	found, err := r.store.Remove(id)
+	if err == nil && !found {
+		return ErrNotFound
+	}
+	return err
+}
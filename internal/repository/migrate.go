@@ -0,0 +1,117 @@
+// internal/repository/migrate.go
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"hot-coffee/models"
+)
+
+const migrateBatchSize = 1000
+
+// MigrationResult reports how many records were copied per data file.
+type MigrationResult struct {
+	Orders         int
+	MenuItems      int
+	InventoryItems int
+	Suppliers      int
+	PurchaseOrders int
+	StockCounts    int
+	Locations      int
+	Categories     int
+	Movements      int
+}
+
+// MigrateJSONToSQLite copies every record from the JSON data files in dataDir
+// into db within a single transaction. It refuses to run against a database
+// that already holds data, so records are never merged or overwritten.
+func MigrateJSONToSQLite(dataDir string, db *sql.DB) (*MigrationResult, error) {
+	if err := RecoverDataDir(dataDir); err != nil {
+		return nil, err
+	}
+
+	duplicates, err := FindDuplicateIDs(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	if len(duplicates) > 0 {
+		return nil, fmt.Errorf("%s has %d records with ID %q; remove the duplicates before migrating",
+			duplicates[0].File, duplicates[0].Count, duplicates[0].ID)
+	}
+
+	for _, table := range []string{"orders", "menu_items", "inventory", "suppliers", "purchase_orders", "stock_counts", "locations", "categories", "inventory_movements"} {
+		var count int
+		if err := db.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&count); err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			return nil, fmt.Errorf("database table %s already contains data", table)
+		}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result := &MigrationResult{}
+	if result.Orders, err = copyRecords[models.Order](NewOrderRepository(dataDir), NewSQLiteOrderRepository(tx)); err != nil {
+		return nil, fmt.Errorf("migrate orders: %w", err)
+	}
+	if result.MenuItems, err = copyRecords[models.MenuItem](NewMenuRepository(dataDir), NewSQLiteMenuRepository(tx)); err != nil {
+		return nil, fmt.Errorf("migrate menu items: %w", err)
+	}
+	if result.InventoryItems, err = copyRecords[models.InventoryItem](NewInventoryRepository(dataDir), NewSQLiteInventoryRepository(tx)); err != nil {
+		return nil, fmt.Errorf("migrate inventory: %w", err)
+	}
+	if result.Suppliers, err = copyRecords[models.Supplier](NewSupplierRepository(dataDir), NewSQLiteSupplierRepository(tx)); err != nil {
+		return nil, fmt.Errorf("migrate suppliers: %w", err)
+	}
+	if result.PurchaseOrders, err = copyRecords[models.PurchaseOrder](NewPurchaseOrderRepository(dataDir), NewSQLitePurchaseOrderRepository(tx)); err != nil {
+		return nil, fmt.Errorf("migrate purchase orders: %w", err)
+	}
+	if result.StockCounts, err = copyRecords[models.StockCount](NewStockCountRepository(dataDir), NewSQLiteStockCountRepository(tx)); err != nil {
+		return nil, fmt.Errorf("migrate stock counts: %w", err)
+	}
+	if result.Locations, err = copyRecords[models.Location](NewLocationRepository(dataDir), NewSQLiteLocationRepository(tx)); err != nil {
+		return nil, fmt.Errorf("migrate locations: %w", err)
+	}
+	if result.Categories, err = copyRecords[models.Category](NewCategoryRepository(dataDir), NewSQLiteCategoryRepository(tx)); err != nil {
+		return nil, fmt.Errorf("migrate categories: %w", err)
+	}
+
+	movements, err := NewMovementRepository(dataDir).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("migrate inventory movements: %w", err)
+	}
+	// Appended in batches to stay below SQLite's limit on statement parameters
+	sqliteMovements := NewSQLiteMovementRepository(tx)
+	for start := 0; start < len(movements); start += migrateBatchSize {
+		end := min(start+migrateBatchSize, len(movements))
+		if err := sqliteMovements.Append(movements[start:end]...); err != nil {
+			return nil, fmt.Errorf("migrate inventory movements: %w", err)
+		}
+	}
+	result.Movements = len(movements)
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func copyRecords[T any](from, to crudRepository[T]) (int, error) {
+	items, err := from.GetAll()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, item := range items {
+		if err := to.Create(item); err != nil {
+			return 0, err
+		}
+	}
+	return len(items), nil
+}
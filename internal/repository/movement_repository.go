@@ -0,0 +1,281 @@
+// internal/repository/movement_repository.go
+package repository
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"hot-coffee/models"
+)
+
+const (
+	movementsFileName = "inventory_movements.jsonl"
+	// legacyMovementsFileName is the JSON array file the ledger was kept in
+	// before it became append-only. Startup recovery converts it.
+	legacyMovementsFileName = "inventory_movements.json"
+)
+
+// movementRepository keeps the inventory ledger in an append-only JSON Lines
+// file. Each line holds the movements of one append as a JSON array, so an
+// append is a single write of one line followed by an fsync, and a crash can
+// at most leave a torn last line, which is never read.
+//
+// Like jsonStore, it caches the ledger in its encoded form, indexed by
+// ingredient. Since the file only grows, catching up with it only reads the
+// lines appended since the last read.
+type movementRepository struct {
+	path  string
+	mutex sync.RWMutex
+
+	state        fileState
+	offset       int64
+	records      []json.RawMessage
+	ids          []string
+	seen         map[string]struct{}
+	byIngredient map[string][]int
+}
+
+// movementKeys are the fields of a movement the cache indexes it by.
+type movementKeys struct {
+	ID           string `json:"movement_id"`
+	IngredientID string `json:"ingredient_id"`
+}
+
+func NewMovementRepository(dataDir string) MovementRepository {
+	return newMovementRepository(dataDir)
+}
+
+func newMovementRepository(dataDir string) *movementRepository {
+	return &movementRepository{path: filepath.Join(dataDir, movementsFileName)}
+}
+
+func movementKey(movement *models.InventoryMovement) string {
+	return movement.ID
+}
+
+// catchUp loads the lines appended to the file since the last read, or the
+// whole file again if it shrank. The caller must hold the write lock.
+func (r *movementRepository) catchUp() error {
+	state, err := r.stat()
+	if err != nil {
+		return err
+	}
+	if r.seen != nil && r.state.equal(state) {
+		return nil
+	}
+	if r.seen == nil || state.size < r.offset {
+		if r.seen != nil {
+			slog.Info("Data file changed on disk, reloading", "file", r.path)
+		}
+		r.offset = 0
+		r.records = nil
+		r.ids = nil
+		r.seen = make(map[string]struct{})
+		r.byIngredient = make(map[string][]int)
+	}
+	if !state.exists {
+		r.state = state
+		return nil
+	}
+
+	f, err := os.Open(r.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := f.Seek(r.offset, io.SeekStart); err != nil {
+		return err
+	}
+	tail, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+
+	// A line without its newline is still being written or was torn by a crash
+	for {
+		end := bytes.IndexByte(tail, '\n')
+		if end < 0 {
+			break
+		}
+		r.index(tail[:end])
+		r.offset += int64(end) + 1
+		tail = tail[end+1:]
+	}
+	r.state = state
+	return nil
+}
+
+// index adds the movements of one ledger line to the cache.
+func (r *movementRepository) index(line []byte) {
+	var batch []json.RawMessage
+	if err := json.Unmarshal(line, &batch); err != nil {
+		slog.Warn("Skipping corrupt ledger line", "file", r.path, "offset", r.offset, "error", err)
+		return
+	}
+	for _, record := range batch {
+		var keys movementKeys
+		if err := json.Unmarshal(record, &keys); err != nil {
+			slog.Warn("Skipping corrupt ledger record", "file", r.path, "offset", r.offset, "error", err)
+			continue
+		}
+		r.byIngredient[keys.IngredientID] = append(r.byIngredient[keys.IngredientID], len(r.records))
+		r.records = append(r.records, record)
+		r.ids = append(r.ids, keys.ID)
+		r.seen[keys.ID] = struct{}{}
+	}
+}
+
+func (r *movementRepository) stat() (fileState, error) {
+	info, err := os.Stat(r.path)
+	if os.IsNotExist(err) {
+		return fileState{}, nil
+	}
+	if err != nil {
+		return fileState{}, err
+	}
+	return fileState{exists: true, size: info.Size(), modTime: info.ModTime()}, nil
+}
+
+// read runs fn under the read lock once the cache has caught up with the file.
+func (r *movementRepository) read(fn func() error) error {
+	r.mutex.RLock()
+	state, err := r.stat()
+	if err == nil && r.seen != nil && r.state.equal(state) {
+		defer r.mutex.RUnlock()
+		return fn()
+	}
+	r.mutex.RUnlock()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if err := r.catchUp(); err != nil {
+		return err
+	}
+	return fn()
+}
+
+// Append writes movements as one line, failing with ErrDuplicateID without
+// writing any of them if one of their IDs is taken.
+func (r *movementRepository) Append(movements ...*models.InventoryMovement) error {
+	if len(movements) == 0 {
+		return nil
+	}
+	line, err := json.Marshal(movements)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if err := r.catchUp(); err != nil {
+		return err
+	}
+	batch := make(map[string]struct{}, len(movements))
+	for _, movement := range movements {
+		if _, ok := r.seen[movement.ID]; ok {
+			return ErrDuplicateID
+		}
+		if _, ok := batch[movement.ID]; ok {
+			return ErrDuplicateID
+		}
+		batch[movement.ID] = struct{}{}
+	}
+
+	if err := appendLine(r.path, line, !r.state.exists); err != nil {
+		return err
+	}
+	return r.catchUp()
+}
+
+// appendLine appends line to the file at path and syncs it. When the append
+// creates the file, the directory is synced too so the file survives a crash.
+func appendLine(path string, line []byte, creates bool) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(line); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if creates {
+		return syncDir(filepath.Dir(path))
+	}
+	return nil
+}
+
+func (r *movementRepository) GetByIngredient(ingredientID string) ([]*models.InventoryMovement, error) {
+	result := []*models.InventoryMovement{}
+	err := r.read(func() error {
+		for _, i := range r.byIngredient[ingredientID] {
+			movement, err := decodeMovement(r.records[i])
+			if err != nil {
+				return err
+			}
+			result = append(result, movement)
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (r *movementRepository) GetAll() ([]*models.InventoryMovement, error) {
+	var result []*models.InventoryMovement
+	err := r.read(func() error {
+		result = make([]*models.InventoryMovement, 0, len(r.records))
+		for _, record := range r.records {
+			movement, err := decodeMovement(record)
+			if err != nil {
+				return err
+			}
+			result = append(result, movement)
+		}
+		return nil
+	})
+	return result, err
+}
+
+// duplicates returns the number of movements of every ID that more than one
+// movement shares.
+func (r *movementRepository) duplicates() (map[string]int, error) {
+	counts := make(map[string]int)
+	err := r.read(func() error {
+		for _, id := range r.ids {
+			counts[id]++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for id, count := range counts {
+		if count < 2 {
+			delete(counts, id)
+		}
+	}
+	return counts, nil
+}
+
+func decodeMovement(record json.RawMessage) (*models.InventoryMovement, error) {
+	movement := new(models.InventoryMovement)
+	if err := json.Unmarshal(record, movement); err != nil {
+		return nil, err
+	}
+	return movement, nil
+}
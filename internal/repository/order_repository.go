@@ -2,130 +2,51 @@
 package repository
 
 import (
-	"encoding/json"
-	"os"
 	"path/filepath"
-	"sync"
 
 	"hot-coffee/models"
 )
 
+const ordersFileName = "orders.json"
+
 type orderRepository struct {
-	dataDir string
-	mutex   sync.RWMutex
+	store *jsonStore[models.Order]
 }
 
 func NewOrderRepository(dataDir string) OrderRepository {
 	return &orderRepository{
-		dataDir: dataDir,
-	}
-}
-
-func (r *orderRepository) getFilePath() string {
-	return filepath.Join(r.dataDir, "orders.json")
-}
-
-func (r *orderRepository) loadOrders() ([]*models.Order, error) {
-	filePath := r.getFilePath()
-
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		return []*models.Order{}, nil
-	}
-
-	data, err := os.ReadFile(filePath)
-	if err != nil {
-		return nil, err
-	}
-
-	var orders []*models.Order
-	if err := json.Unmarshal(data, &orders); err != nil {
-		return nil, err
+		store: newJSONStore(filepath.Join(dataDir, ordersFileName), orderKey),
 	}
-
-	return orders, nil
 }
 
-func (r *orderRepository) saveOrders(orders []*models.Order) error {
-	data, err := json.MarshalIndent(orders, "", "  ")
-	if err != nil {
-		return err
-	}
-
-	return os.WriteFile(r.getFilePath(), data, 0o644)
+func orderKey(order *models.Order) string {
+	return order.ID
 }
 
 func (r *orderRepository) Create(order *models.Order) error {
-	r.mutex.Lock()
-	defer r.mutex.Unlock()
-
-	orders, err := r.loadOrders()
-	if err != nil {
-		return err
-	}
-
-	orders = append(orders, order)
-	return r.saveOrders(orders)
+	return r.store.Insert(order)
 }
 
 func (r *orderRepository) GetByID(id string) (*models.Order, error) {
-	r.mutex.RLock()
-	defer r.mutex.RUnlock()
-
-	orders, err := r.loadOrders()
-	if err != nil {
-		return nil, err
-	}
-
-	for _, order := range orders {
-		if order.ID == id {
-			return order, nil
-		}
-	}
-
-	return nil, nil
+	return r.store.Get(id)
 }
 
 func (r *orderRepository) GetAll() ([]*models.Order, error) {
-	r.mutex.RLock()
-	defer r.mutex.RUnlock()
-
-	return r.loadOrders()
+	return r.store.All()
 }
 
 func (r *orderRepository) Update(order *models.Order) error {
-	r.mutex.Lock()
-	defer r.mutex.Unlock()
-
-	orders, err := r.loadOrders()
-	if err != nil {
-		return err
+	found, err := r.store.Replace(order)
+	if err == nil && !found {
+		return ErrNotFound
 	}
-
-	for i, existingOrder := range orders {
-		if existingOrder.ID == order.ID {
-			orders[i] = order
-			return r.saveOrders(orders)
-		}
-	}
-
-	return nil
+	return err
 }
 
 func (r *orderRepository) Delete(id string) error {
-	r.mutex.Lock()
-	defer r.mutex.Unlock()
-
-	orders, err := r.loadOrders()
-	if err != nil {
-		return err
+	found, err := r.store.Remove(id)
+	if err == nil && !found {
+		return ErrNotFound
 	}
-
-	for i, order := range orders {
-		if order.ID == id {
-			orders = append(orders[:i], orders[i+1:]...)
-			return r.saveOrders(orders)
-		}
-	}
-
-	return nil
+	return err
 }
@@ -0,0 +1,52 @@
+// internal/repository/purchase_order_repository.go
+package repository
+
+import (
+	"path/filepath"
+
+	"hot-coffee/models"
+)
+
+const purchaseOrdersFileName = "purchase_orders.json"
+
+type purchaseOrderRepository struct {
+	store *jsonStore[models.PurchaseOrder]
+}
+
+func NewPurchaseOrderRepository(dataDir string) PurchaseOrderRepository {
+	return &purchaseOrderRepository{
+		store: newJSONStore(filepath.Join(dataDir, purchaseOrdersFileName), purchaseOrderKey),
+	}
+}
+
+func purchaseOrderKey(order *models.PurchaseOrder) string {
+	return order.ID
+}
+
+func (r *purchaseOrderRepository) Create(order *models.PurchaseOrder) error {
+	return r.store.Insert(order)
+}
+
+func (r *purchaseOrderRepository) GetByID(id string) (*models.PurchaseOrder, error) {
+	return r.store.Get(id)
+}
+
+func (r *purchaseOrderRepository) GetAll() ([]*models.PurchaseOrder, error) {
+	return r.store.All()
+}
+
+func (r *purchaseOrderRepository) Update(order *models.PurchaseOrder) error {
+	found, err := r.store.Replace(order)
+	if err == nil && !found {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (r *purchaseOrderRepository) Delete(id string) error {
+	found, err := r.store.Remove(id)
+	if err == nil && !found {
+		return ErrNotFound
+	}
+	return err
+}
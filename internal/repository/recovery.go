@@ -0,0 +1,195 @@
+// internal/repository/recovery.go
+package repository
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// dataFileNames lists every JSON data file managed by the repositories.
+var dataFileNames = []string{
+	ordersFileName,
+	menuItemsFileName,
+	inventoryFileName,
+	suppliersFileName,
+	purchaseOrdersFileName,
+	stockCountsFileName,
+	locationsFileName,
+	categoriesFileName,
+	legacyMovementsFileName,
+}
+
+// RecoverDataDir checks every data file in dataDir and repairs files that a
+// crash left truncated or corrupt, restoring the last journaled state. It then
+// moves a ledger kept in the legacy JSON file into the append-only ledger and
+// drops a line of the ledger torn by a crash.
+func RecoverDataDir(dataDir string) error {
+	for _, name := range dataFileNames {
+		if err := recoverDataFile(filepath.Join(dataDir, name)); err != nil {
+			return fmt.Errorf("recover %s: %w", name, err)
+		}
+	}
+	if err := convertLegacyMovements(dataDir); err != nil {
+		return fmt.Errorf("convert %s: %w", legacyMovementsFileName, err)
+	}
+	if err := recoverMovementLog(filepath.Join(dataDir, movementsFileName)); err != nil {
+		return fmt.Errorf("recover %s: %w", movementsFileName, err)
+	}
+	return nil
+}
+
+// convertLegacyMovements writes the movements of the legacy JSON ledger as the
+// first line of the append-only ledger and removes the legacy file and its
+// journal. A legacy file left next to an existing ledger was already converted
+// by a run that crashed before removing it.
+func convertLegacyMovements(dataDir string) error {
+	legacyPath := filepath.Join(dataDir, legacyMovementsFileName)
+	data, err := os.ReadFile(legacyPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(dataDir, movementsFileName)
+	if err := removeStaleTempFiles(path); err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		var movements []json.RawMessage
+		if err := json.Unmarshal(data, &movements); err != nil {
+			return err
+		}
+		var line bytes.Buffer
+		if len(movements) > 0 {
+			if err := json.Compact(&line, data); err != nil {
+				return err
+			}
+			line.WriteByte('\n')
+		}
+		if err := writeFileAtomic(path, line.Bytes(), 0o644); err != nil {
+			return err
+		}
+		slog.Info("Converted inventory ledger to an append-only file", "file", path, "movements", len(movements))
+	} else if err != nil {
+		return err
+	}
+
+	if err := os.Remove(newJournal(legacyPath).path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return os.Remove(legacyPath)
+}
+
+// recoverMovementLog truncates the ledger after its last complete line,
+// dropping the partial append a crash left behind. An intact ledger ends in a
+// newline, so only a torn one is read in full.
+func recoverMovementLog(path string) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() == 0 {
+		return nil
+	}
+	last := make([]byte, 1)
+	if _, err := f.ReadAt(last, info.Size()-1); err != nil {
+		return err
+	}
+	if last[0] == '\n' {
+		return nil
+	}
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+	complete := bytes.LastIndexByte(data, '\n') + 1
+	if complete == len(data) {
+		return nil
+	}
+	if err := os.Truncate(path, int64(complete)); err != nil {
+		return err
+	}
+	slog.Warn("Dropped a torn append from the inventory ledger", "file", path, "bytes", len(data)-complete)
+	return nil
+}
+
+func recoverDataFile(path string) error {
+	if err := removeStaleTempFiles(path); err != nil {
+		return err
+	}
+
+	journal := newJournal(path)
+	lastEntry, err := journal.LastEntry()
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	exists := err == nil
+
+	if exists && isValidJSONArray(data) {
+		// The file is intact; compact the journal so it mirrors the file
+		if lastEntry != nil {
+			return journal.Checkpoint(data)
+		}
+		return nil
+	}
+
+	if lastEntry != nil {
+		if err := writeFileAtomic(path, lastEntry, 0o644); err != nil {
+			return err
+		}
+		slog.Warn("Restored data file from journal", "file", path)
+		return journal.Checkpoint(lastEntry)
+	}
+
+	if !exists {
+		return nil
+	}
+
+	// Nothing to restore from: keep the damaged file for inspection and start empty
+	corruptPath := fmt.Sprintf("%s.corrupt-%d", path, time.Now().Unix())
+	if err := os.Rename(path, corruptPath); err != nil {
+		return err
+	}
+	slog.Warn("Data file is corrupt and no journal is available, starting empty",
+		"file", path, "backup", corruptPath)
+	return writeFileAtomic(path, []byte("[]"), 0o644)
+}
+
+// removeStaleTempFiles deletes temp files left by writes interrupted before their rename.
+func removeStaleTempFiles(path string) error {
+	dir, name := filepath.Split(path)
+	matches, err := filepath.Glob(filepath.Join(dir, "."+name+tempFilePattern))
+	if err != nil {
+		return err
+	}
+
+	for _, match := range matches {
+		if err := os.Remove(match); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
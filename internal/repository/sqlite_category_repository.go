@@ -0,0 +1,46 @@
+// internal/repository/sqlite_category_repository.go
+package repository
+
+import "hot-coffee/models"
+
+type sqliteCategoryRepository struct {
+	store *sqlStore[models.Category]
+}
+
+func NewSQLiteCategoryRepository(db DBTX) CategoryRepository {
+	return &sqliteCategoryRepository{
+		store: newSQLStore(db, "categories",
+			categoryKey,
+			[]string{"name"},
+			func(category *models.Category) []any { return []any{category.Name} },
+		),
+	}
+}
+
+func (r *sqliteCategoryRepository) Create(category *models.Category) error {
+	return r.store.Insert(category)
+}
+
+func (r *sqliteCategoryRepository) GetByID(id string) (*models.Category, error) {
+	return r.store.Get(id)
+}
+
+func (r *sqliteCategoryRepository) GetAll() ([]*models.Category, error) {
+	return r.store.All()
+}
+
+func (r *sqliteCategoryRepository) Update(category *models.Category) error {
+	found, err := r.store.Replace(category)
+	if err == nil && !found {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (r *sqliteCategoryRepository) Delete(id string) error {
+	found, err := r.store.Remove(id)
+	if err == nil && !found {
+		return ErrNotFound
+	}
+	return err
+}
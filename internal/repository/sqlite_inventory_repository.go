@@ -0,0 +1,46 @@
+// internal/repository/sqlite_inventory_repository.go
+package repository
+
+import "hot-coffee/models"
+
+type sqliteInventoryRepository struct {
+	store *sqlStore[models.InventoryItem]
+}
+
+func NewSQLiteInventoryRepository(db DBTX) InventoryRepository {
+	return &sqliteInventoryRepository{
+		store: newSQLStore(db, "inventory",
+			inventoryItemKey,
+			[]string{"name"},
+			func(item *models.InventoryItem) []any { return []any{item.Name} },
+		),
+	}
+}
+
+func (r *sqliteInventoryRepository) Create(item *models.InventoryItem) error {
+	return r.store.Insert(item)
+}
+
+func (r *sqliteInventoryRepository) GetByID(id string) (*models.InventoryItem, error) {
+	return r.store.Get(id)
+}
+
+func (r *sqliteInventoryRepository) GetAll() ([]*models.InventoryItem, error) {
+	return r.store.All()
+}
+
+func (r *sqliteInventoryRepository) Update(item *models.InventoryItem) error {
+	found, err := r.store.Replace(item)
+	if err == nil && !found {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (r *sqliteInventoryRepository) Delete(id string) error {
+	found, err := r.store.Remove(id)
+	if err == nil && !found {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,46 @@
+// internal/repository/sqlite_location_repository.go
+package repository
+
+import "hot-coffee/models"
+
+type sqliteLocationRepository struct {
+	store *sqlStore[models.Location]
+}
+
+func NewSQLiteLocationRepository(db DBTX) LocationRepository {
+	return &sqliteLocationRepository{
+		store: newSQLStore(db, "locations",
+			locationKey,
+			[]string{"name"},
+			func(location *models.Location) []any { return []any{location.Name} },
+		),
+	}
+}
+
+func (r *sqliteLocationRepository) Create(location *models.Location) error {
+	return r.store.Insert(location)
+}
+
+func (r *sqliteLocationRepository) GetByID(id string) (*models.Location, error) {
+	return r.store.Get(id)
+}
+
+func (r *sqliteLocationRepository) GetAll() ([]*models.Location, error) {
+	return r.store.All()
+}
+
+func (r *sqliteLocationRepository) Update(location *models.Location) error {
+	found, err := r.store.Replace(location)
+	if err == nil && !found {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (r *sqliteLocationRepository) Delete(id string) error {
+	found, err := r.store.Remove(id)
+	if err == nil && !found {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,46 @@
+// internal/repository/sqlite_menu_repository.go
+package repository
+
+import "hot-coffee/models"
+
+type sqliteMenuRepository struct {
+	store *sqlStore[models.MenuItem]
+}
+
+func NewSQLiteMenuRepository(db DBTX) MenuRepository {
+	return &sqliteMenuRepository{
+		store: newSQLStore(db, "menu_items",
+			menuItemKey,
+			[]string{"name"},
+			func(item *models.MenuItem) []any { return []any{item.Name} },
+		),
+	}
+}
+
+func (r *sqliteMenuRepository) Create(item *models.MenuItem) error {
+	return r.store.Insert(item)
+}
+
+func (r *sqliteMenuRepository) GetByID(id string) (*models.MenuItem, error) {
+	return r.store.Get(id)
+}
+
+func (r *sqliteMenuRepository) GetAll() ([]*models.MenuItem, error) {
+	return r.store.All()
+}
+
+func (r *sqliteMenuRepository) Update(item *models.MenuItem) error {
+	found, err := r.store.Replace(item)
+	if err == nil && !found {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (r *sqliteMenuRepository) Delete(id string) error {
+	found, err := r.store.Remove(id)
+	if err == nil && !found {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,32 @@
+// internal/repository/sqlite_movement_repository.go
+package repository
+
+import "hot-coffee/models"
+
+type sqliteMovementRepository struct {
+	store *sqlStore[models.InventoryMovement]
+}
+
+func NewSQLiteMovementRepository(db DBTX) MovementRepository {
+	return &sqliteMovementRepository{
+		store: newSQLStore(db, "inventory_movements",
+			movementKey,
+			[]string{"ingredient_id", "created_at"},
+			func(movement *models.InventoryMovement) []any {
+				return []any{movement.IngredientID, movement.CreatedAt}
+			},
+		),
+	}
+}
+
+func (r *sqliteMovementRepository) Append(movements ...*models.InventoryMovement) error {
+	return r.store.InsertAll(movements)
+}
+
+func (r *sqliteMovementRepository) GetByIngredient(ingredientID string) ([]*models.InventoryMovement, error) {
+	return r.store.query("WHERE ingredient_id = ? ORDER BY rowid", ingredientID)
+}
+
+func (r *sqliteMovementRepository) GetAll() ([]*models.InventoryMovement, error) {
+	return r.store.All()
+}
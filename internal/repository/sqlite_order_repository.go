@@ -0,0 +1,46 @@
+// internal/repository/sqlite_order_repository.go
+package repository
+
+import "hot-coffee/models"
+
+type sqliteOrderRepository struct {
+	store *sqlStore[models.Order]
+}
+
+func NewSQLiteOrderRepository(db DBTX) OrderRepository {
+	return &sqliteOrderRepository{
+		store: newSQLStore(db, "orders",
+			orderKey,
+			[]string{"customer_name", "status", "created_at"},
+			func(order *models.Order) []any { return []any{order.CustomerName, order.Status, order.CreatedAt} },
+		),
+	}
+}
+
+func (r *sqliteOrderRepository) Create(order *models.Order) error {
+	return r.store.Insert(order)
+}
+
+func (r *sqliteOrderRepository) GetByID(id string) (*models.Order, error) {
+	return r.store.Get(id)
+}
+
+func (r *sqliteOrderRepository) GetAll() ([]*models.Order, error) {
+	return r.store.All()
+}
+
+func (r *sqliteOrderRepository) Update(order *models.Order) error {
+	found, err := r.store.Replace(order)
+	if err == nil && !found {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (r *sqliteOrderRepository) Delete(id string) error {
+	found, err := r.store.Remove(id)
+	if err == nil && !found {
+		return ErrNotFound
+	}
+	return err
+}
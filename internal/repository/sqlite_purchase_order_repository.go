@@ -0,0 +1,46 @@
+// internal/repository/sqlite_purchase_order_repository.go
+package repository
+
+import "hot-coffee/models"
+
+type sqlitePurchaseOrderRepository struct {
+	store *sqlStore[models.PurchaseOrder]
+}
+
+func NewSQLitePurchaseOrderRepository(db DBTX) PurchaseOrderRepository {
+	return &sqlitePurchaseOrderRepository{
+		store: newSQLStore(db, "purchase_orders",
+			purchaseOrderKey,
+			[]string{"supplier_id", "status", "created_at"},
+			func(order *models.PurchaseOrder) []any { return []any{order.SupplierID, order.Status, order.CreatedAt} },
+		),
+	}
+}
+
+func (r *sqlitePurchaseOrderRepository) Create(order *models.PurchaseOrder) error {
+	return r.store.Insert(order)
+}
+
+func (r *sqlitePurchaseOrderRepository) GetByID(id string) (*models.PurchaseOrder, error) {
+	return r.store.Get(id)
+}
+
+func (r *sqlitePurchaseOrderRepository) GetAll() ([]*models.PurchaseOrder, error) {
+	return r.store.All()
+}
+
+func (r *sqlitePurchaseOrderRepository) Update(order *models.PurchaseOrder) error {
+	found, err := r.store.Replace(order)
+	if err == nil && !found {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (r *sqlitePurchaseOrderRepository) Delete(id string) error {
+	found, err := r.store.Remove(id)
+	if err == nil && !found {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,46 @@
+// internal/repository/sqlite_stock_count_repository.go
+package repository
+
+import "hot-coffee/models"
+
+type sqliteStockCountRepository struct {
+	store *sqlStore[models.StockCount]
+}
+
+func NewSQLiteStockCountRepository(db DBTX) StockCountRepository {
+	return &sqliteStockCountRepository{
+		store: newSQLStore(db, "stock_counts",
+			stockCountKey,
+			[]string{"status", "started_at"},
+			func(count *models.StockCount) []any { return []any{count.Status, count.StartedAt} },
+		),
+	}
+}
+
+func (r *sqliteStockCountRepository) Create(count *models.StockCount) error {
+	return r.store.Insert(count)
+}
+
+func (r *sqliteStockCountRepository) GetByID(id string) (*models.StockCount, error) {
+	return r.store.Get(id)
+}
+
+func (r *sqliteStockCountRepository) GetAll() ([]*models.StockCount, error) {
+	return r.store.All()
+}
+
+func (r *sqliteStockCountRepository) Update(count *models.StockCount) error {
+	found, err := r.store.Replace(count)
+	if err == nil && !found {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (r *sqliteStockCountRepository) Delete(id string) error {
+	found, err := r.store.Remove(id)
+	if err == nil && !found {
+		return ErrNotFound
+	}
+	return err
+}
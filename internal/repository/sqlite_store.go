@@ -0,0 +1,299 @@
+// internal/repository/sqlite_store.go
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// sqliteSchema holds the schema migrations in order. PRAGMA user_version
+// records how many of them have been applied to a database.
+var sqliteSchema = []string{
+	`CREATE TABLE orders (
+		id            TEXT PRIMARY KEY,
+		customer_name TEXT NOT NULL,
+		status        TEXT NOT NULL,
+		created_at    TEXT NOT NULL,
+		data          TEXT NOT NULL
+	);
+	CREATE INDEX idx_orders_status ON orders (status);
+	CREATE INDEX idx_orders_created_at ON orders (created_at);
+
+	CREATE TABLE menu_items (
+		id   TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		data TEXT NOT NULL
+	);
+	CREATE INDEX idx_menu_items_name ON menu_items (name);
+
+	CREATE TABLE inventory (
+		id   TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		data TEXT NOT NULL
+	);
+	CREATE INDEX idx_inventory_name ON inventory (name);`,
+
+	`CREATE TABLE inventory_movements (
+		id            TEXT PRIMARY KEY,
+		ingredient_id TEXT NOT NULL,
+		created_at    TEXT NOT NULL,
+		data          TEXT NOT NULL
+	);
+	CREATE INDEX idx_inventory_movements_ingredient ON inventory_movements (ingredient_id, created_at);`,
+
+	`CREATE TABLE suppliers (
+		id   TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		data TEXT NOT NULL
+	);
+
+	CREATE TABLE purchase_orders (
+		id          TEXT PRIMARY KEY,
+		supplier_id TEXT NOT NULL,
+		status      TEXT NOT NULL,
+		created_at  TEXT NOT NULL,
+		data        TEXT NOT NULL
+	);
+	CREATE INDEX idx_purchase_orders_supplier ON purchase_orders (supplier_id);
+	CREATE INDEX idx_purchase_orders_status ON purchase_orders (status);`,
+
+	`CREATE TABLE stock_counts (
+		id         TEXT PRIMARY KEY,
+		status     TEXT NOT NULL,
+		started_at TEXT NOT NULL,
+		data       TEXT NOT NULL
+	);
+	CREATE INDEX idx_stock_counts_status ON stock_counts (status);`,
+
+	`CREATE TABLE locations (
+		id   TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		data TEXT NOT NULL
+	);`,
+
+	`CREATE TABLE categories (
+		id   TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		data TEXT NOT NULL
+	);`,
+}
+
+// OpenSQLite opens the database file at path, creating it if needed, and
+// brings its schema up to date.
+func OpenSQLite(path string) (*sql.DB, error) {
+	dsn := "file:" + path + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=synchronous(FULL)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite allows one writer at a time; a single connection avoids busy errors
+	db.SetMaxOpenConns(1)
+
+	if err := migrateSQLiteSchema(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+func migrateSQLiteSchema(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+
+	for i := version; i < len(sqliteSchema); i++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(sqliteSchema[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("apply schema migration %d: %w", i+1, err)
+		}
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DBTX is satisfied by both *sql.DB and *sql.Tx, so repositories can run inside
+// a transaction, as migrations and unit of work commits do.
+type DBTX interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// sqlStore stores each record as a JSON document in the data column of a
+// table keyed by id. Fields that queries filter on are copied into extra
+// indexed columns.
+type sqlStore[T any] struct {
+	db      DBTX
+	table   string
+	keyOf   func(*T) string
+	columns []string
+	values  func(*T) []any
+}
+
+func newSQLStore[T any](db DBTX, table string, keyOf func(*T) string, columns []string, values func(*T) []any) *sqlStore[T] {
+	return &sqlStore[T]{
+		db:      db,
+		table:   table,
+		keyOf:   keyOf,
+		columns: columns,
+		values:  values,
+	}
+}
+
+func (s *sqlStore[T]) args(item *T) ([]any, error) {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []any{s.keyOf(item)}
+	if s.values != nil {
+		args = append(args, s.values(item)...)
+	}
+	return append(args, string(data)), nil
+}
+
+func (s *sqlStore[T]) decode(data string) (*T, error) {
+	item := new(T)
+	if err := json.Unmarshal([]byte(data), item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// Get returns the record with the given ID, or nil if there is none.
+func (s *sqlStore[T]) Get(id string) (*T, error) {
+	var data string
+	err := s.db.QueryRow("SELECT data FROM "+s.table+" WHERE id = ?", id).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.decode(data)
+}
+
+// All returns every record in insertion order.
+func (s *sqlStore[T]) All() ([]*T, error) {
+	return s.query("ORDER BY rowid")
+}
+
+// query returns the records matching a WHERE/ORDER BY clause.
+func (s *sqlStore[T]) query(clause string, args ...any) ([]*T, error) {
+	rows, err := s.db.Query("SELECT data FROM "+s.table+" "+clause, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []*T{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		item, err := s.decode(data)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// Insert adds a record, failing with ErrDuplicateID if its ID is taken.
+func (s *sqlStore[T]) Insert(item *T) error {
+	return s.InsertAll([]*T{item})
+}
+
+// InsertAll adds records with a single statement, so either all of them are
+// stored or none. It fails with ErrDuplicateID if one of their IDs is taken.
+func (s *sqlStore[T]) InsertAll(items []*T) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	columns := append(append([]string{"id"}, s.columns...), "data")
+	row := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ") + ")"
+	rows := make([]string, len(items))
+	args := make([]any, 0, len(items)*len(columns))
+	for i, item := range items {
+		itemArgs, err := s.args(item)
+		if err != nil {
+			return err
+		}
+		rows[i] = row
+		args = append(args, itemArgs...)
+	}
+
+	_, err := s.db.Exec(
+		"INSERT INTO "+s.table+" ("+strings.Join(columns, ", ")+") VALUES "+strings.Join(rows, ", "),
+		args...,
+	)
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY {
+		return ErrDuplicateID
+	}
+	return err
+}
+
+// Replace overwrites the record with the same ID. It reports whether one existed.
+func (s *sqlStore[T]) Replace(item *T) (bool, error) {
+	args, err := s.args(item)
+	if err != nil {
+		return false, err
+	}
+
+	assignments := make([]string, 0, len(s.columns)+1)
+	for _, column := range s.columns {
+		assignments = append(assignments, column+" = ?")
+	}
+	assignments = append(assignments, "data = ?")
+
+	// Arguments are ordered id, columns..., data; the id moves to the WHERE clause
+	result, err := s.db.Exec(
+		"UPDATE "+s.table+" SET "+strings.Join(assignments, ", ")+" WHERE id = ?",
+		append(args[1:], args[0])...,
+	)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// Remove deletes the record with the given ID. It reports whether one existed.
+func (s *sqlStore[T]) Remove(id string) (bool, error) {
+	result, err := s.db.Exec("DELETE FROM "+s.table+" WHERE id = ?", id)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// Count returns the number of records in the table.
+func (s *sqlStore[T]) Count() (int, error) {
+	var count int
+	err := s.db.QueryRow("SELECT COUNT(*) FROM " + s.table).Scan(&count)
+	return count, err
+}
@@ -0,0 +1,46 @@
+// internal/repository/sqlite_supplier_repository.go
+package repository
+
+import "hot-coffee/models"
+
+type sqliteSupplierRepository struct {
+	store *sqlStore[models.Supplier]
+}
+
+func NewSQLiteSupplierRepository(db DBTX) SupplierRepository {
+	return &sqliteSupplierRepository{
+		store: newSQLStore(db, "suppliers",
+			supplierKey,
+			[]string{"name"},
+			func(supplier *models.Supplier) []any { return []any{supplier.Name} },
+		),
+	}
+}
+
+func (r *sqliteSupplierRepository) Create(supplier *models.Supplier) error {
+	return r.store.Insert(supplier)
+}
+
+func (r *sqliteSupplierRepository) GetByID(id string) (*models.Supplier, error) {
+	return r.store.Get(id)
+}
+
+func (r *sqliteSupplierRepository) GetAll() ([]*models.Supplier, error) {
+	return r.store.All()
+}
+
+func (r *sqliteSupplierRepository) Update(supplier *models.Supplier) error {
+	found, err := r.store.Replace(supplier)
+	if err == nil && !found {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (r *sqliteSupplierRepository) Delete(id string) error {
+	found, err := r.store.Remove(id)
+	if err == nil && !found {
+		return ErrNotFound
+	}
+	return err
+}
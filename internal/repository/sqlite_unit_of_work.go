@@ -0,0 +1,86 @@
+// internal/repository/sqlite_unit_of_work.go
+package repository
+
+import "database/sql"
+
+// NewSQLiteRepositories returns the SQLite repositories that run their
+// statements on db.
+func NewSQLiteRepositories(db DBTX) Repositories {
+	return Repositories{
+		Orders:         NewSQLiteOrderRepository(db),
+		Menu:           NewSQLiteMenuRepository(db),
+		Inventory:      NewSQLiteInventoryRepository(db),
+		Suppliers:      NewSQLiteSupplierRepository(db),
+		PurchaseOrders: NewSQLitePurchaseOrderRepository(db),
+		StockCounts:    NewSQLiteStockCountRepository(db),
+		Locations:      NewSQLiteLocationRepository(db),
+		Categories:     NewSQLiteCategoryRepository(db),
+		Movements:      NewSQLiteMovementRepository(db),
+	}
+}
+
+// sqliteUnitOfWork stages writes like unitOfWork but commits them in a single
+// database transaction, so a failed commit is rolled back by SQLite rather
+// than by compensating writes.
+type sqliteUnitOfWork struct {
+	db    *sql.DB
+	locks *keyLocker
+}
+
+func NewSQLiteUnitOfWork(db *sql.DB) UnitOfWork {
+	return &sqliteUnitOfWork{
+		db:    db,
+		locks: newKeyLocker(),
+	}
+}
+
+func (u *sqliteUnitOfWork) Execute(lockKeys []string, fn func(repos Repositories) error) error {
+	unlock := u.locks.Lock(lockKeys)
+	defer unlock()
+
+	// fn reads outside the transaction; only the commit runs inside it
+	conn := &txConn{db: u.db}
+	staged, committers := stageRepositories(NewSQLiteRepositories(conn))
+	if err := fn(staged); err != nil {
+		return err
+	}
+
+	tx, err := u.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	conn.tx = tx
+	for _, c := range committers {
+		if err := c.commit(); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// txConn runs statements in tx once one is set and on db before that.
+type txConn struct {
+	db *sql.DB
+	tx *sql.Tx
+}
+
+func (c *txConn) conn() DBTX {
+	if c.tx != nil {
+		return c.tx
+	}
+	return c.db
+}
+
+func (c *txConn) Exec(query string, args ...any) (sql.Result, error) {
+	return c.conn().Exec(query, args...)
+}
+
+func (c *txConn) Query(query string, args ...any) (*sql.Rows, error) {
+	return c.conn().Query(query, args...)
+}
+
+func (c *txConn) QueryRow(query string, args ...any) *sql.Row {
+	return c.conn().QueryRow(query, args...)
+}
@@ -0,0 +1,97 @@
+// internal/repository/sqlite_unit_of_work_test.go
+package repository
+
+import (
+	"path/filepath"
+	"testing"
+
+	"hot-coffee/models"
+)
+
+func TestSQLiteUnitOfWorkExecute(t *testing.T) {
+	tests := []struct {
+		name       string
+		movementID string
+		wantErr    bool
+	}{
+		{name: "commits every repository", movementID: "m1"},
+		// The ledger is committed last, after the other writes have been made
+		{name: "ledger insert fails last", movementID: "m0", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := OpenSQLite(filepath.Join(t.TempDir(), "hot-coffee.db"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+			repos := NewSQLiteRepositories(db)
+			if err := repos.Inventory.Create(&models.InventoryItem{IngredientID: "milk", Name: "Milk", Quantity: 100, Unit: "ml"}); err != nil {
+				t.Fatal(err)
+			}
+			if err := repos.Orders.Create(&models.Order{ID: "o0", Status: "open"}); err != nil {
+				t.Fatal(err)
+			}
+			if err := repos.Movements.Append(&models.InventoryMovement{ID: "m0", IngredientID: "milk", Delta: 100}); err != nil {
+				t.Fatal(err)
+			}
+
+			err = NewSQLiteUnitOfWork(db).Execute([]string{"inventory:milk"}, func(staged Repositories) error {
+				milk, err := staged.Inventory.GetByID("milk")
+				if err != nil {
+					return err
+				}
+				milk.Quantity = 90
+				if err := staged.Inventory.Update(milk); err != nil {
+					return err
+				}
+				if err := staged.Orders.Update(&models.Order{ID: "o0", Status: "completed"}); err != nil {
+					return err
+				}
+				if err := staged.Orders.Create(&models.Order{ID: "o1", Status: "open"}); err != nil {
+					return err
+				}
+				return staged.Movements.Append(&models.InventoryMovement{ID: tt.movementID, IngredientID: "milk", Delta: -10})
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			// Either every write is kept or the database is as seeded
+			wantQuantity, wantStatus, wantMovements := 100.0, "open", 1
+			if !tt.wantErr {
+				wantQuantity, wantStatus, wantMovements = 90, "completed", 2
+			}
+
+			milk, err := repos.Inventory.GetByID("milk")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if milk.Quantity != wantQuantity {
+				t.Errorf("milk quantity = %g, want %g", milk.Quantity, wantQuantity)
+			}
+			o0, err := repos.Orders.GetByID("o0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if o0.Status != wantStatus {
+				t.Errorf("order o0 status = %q, want %q", o0.Status, wantStatus)
+			}
+			o1, err := repos.Orders.GetByID("o1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (o1 != nil) == tt.wantErr {
+				t.Errorf("order o1 exists = %t, want %t", o1 != nil, !tt.wantErr)
+			}
+			movements, err := repos.Movements.GetAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(movements) != wantMovements {
+				t.Errorf("got %d movements, want %d", len(movements), wantMovements)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+// internal/repository/staged_movements.go
+package repository
+
+import "hot-coffee/models"
+
+// stagedMovements buffers the movements appended inside a unit of work. The
+// unit of work commits them last and in one atomic append, so there is never
+// a partial append to roll back.
+type stagedMovements struct {
+	base    MovementRepository
+	pending []*models.InventoryMovement
+}
+
+func newStagedMovements(base MovementRepository) *stagedMovements {
+	return &stagedMovements{base: base}
+}
+
+func (r *stagedMovements) Append(movements ...*models.InventoryMovement) error {
+	r.pending = append(r.pending, movements...)
+	return nil
+}
+
+func (r *stagedMovements) GetByIngredient(ingredientID string) ([]*models.InventoryMovement, error) {
+	movements, err := r.base.GetByIngredient(ingredientID)
+	if err != nil {
+		return nil, err
+	}
+	for _, movement := range r.pending {
+		if movement.IngredientID == ingredientID {
+			movements = append(movements, movement)
+		}
+	}
+	return movements, nil
+}
+
+func (r *stagedMovements) GetAll() ([]*models.InventoryMovement, error) {
+	movements, err := r.base.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	return append(movements, r.pending...), nil
+}
+
+func (r *stagedMovements) commit() error {
+	if len(r.pending) == 0 {
+		return nil
+	}
+	return r.base.Append(r.pending...)
+}
+
+func (r *stagedMovements) rollback() error {
+	return nil
+}
@@ -0,0 +1,188 @@
+// internal/repository/staged_repository.go
+package repository
+
+import "errors"
+
+// crudRepository is the method set shared by the entity repositories.
+type crudRepository[T any] interface {
+	Create(item *T) error
+	GetByID(id string) (*T, error)
+	GetAll() ([]*T, error)
+	Update(item *T) error
+	Delete(id string) error
+}
+
+type committer interface {
+	commit() error
+	rollback() error
+}
+
+// stagedChange tracks a record touched inside a unit of work: its state before
+// the unit of work began and its staged state (nil when deleted).
+type stagedChange[T any] struct {
+	original *T
+	current  *T
+	applied  bool
+}
+
+// stagedRepository buffers writes in memory on top of a base repository.
+// Reads see the staged writes; nothing reaches the base until commit.
+type stagedRepository[T any] struct {
+	base    crudRepository[T]
+	keyOf   func(*T) string
+	changes map[string]*stagedChange[T]
+	order   []string
+}
+
+func newStagedRepository[T any](base crudRepository[T], keyOf func(*T) string) *stagedRepository[T] {
+	return &stagedRepository[T]{
+		base:    base,
+		keyOf:   keyOf,
+		changes: make(map[string]*stagedChange[T]),
+	}
+}
+
+// track returns the change record for key, capturing the base state on first touch.
+func (r *stagedRepository[T]) track(key string) (*stagedChange[T], error) {
+	if change, ok := r.changes[key]; ok {
+		return change, nil
+	}
+
+	original, err := r.base.GetByID(key)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read again so the staged copy shares no memory with the original
+	current, err := r.base.GetByID(key)
+	if err != nil {
+		return nil, err
+	}
+
+	change := &stagedChange[T]{original: original, current: current}
+	r.changes[key] = change
+	r.order = append(r.order, key)
+	return change, nil
+}
+
+func (r *stagedRepository[T]) Create(item *T) error {
+	change, err := r.track(r.keyOf(item))
+	if err != nil {
+		return err
+	}
+	if change.current != nil {
+		return ErrDuplicateID
+	}
+	change.current = item
+	return nil
+}
+
+func (r *stagedRepository[T]) GetByID(id string) (*T, error) {
+	if change, ok := r.changes[id]; ok {
+		return change.current, nil
+	}
+	return r.base.GetByID(id)
+}
+
+func (r *stagedRepository[T]) GetAll() ([]*T, error) {
+	items, err := r.base.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*T, 0, len(items))
+	seen := make(map[string]bool, len(r.changes))
+	for _, item := range items {
+		key := r.keyOf(item)
+		if change, ok := r.changes[key]; ok {
+			seen[key] = true
+			if change.current != nil {
+				result = append(result, change.current)
+			}
+			continue
+		}
+		result = append(result, item)
+	}
+
+	// Records created inside the unit of work
+	for _, key := range r.order {
+		if change := r.changes[key]; !seen[key] && change.current != nil {
+			result = append(result, change.current)
+		}
+	}
+
+	return result, nil
+}
+
+func (r *stagedRepository[T]) Update(item *T) error {
+	change, err := r.track(r.keyOf(item))
+	if err != nil {
+		return err
+	}
+	if change.current == nil {
+		return ErrNotFound
+	}
+	change.current = item
+	return nil
+}
+
+func (r *stagedRepository[T]) Delete(id string) error {
+	change, err := r.track(id)
+	if err != nil {
+		return err
+	}
+	if change.current == nil {
+		return ErrNotFound
+	}
+	change.current = nil
+	return nil
+}
+
+func (r *stagedRepository[T]) commit() error {
+	for _, key := range r.order {
+		change := r.changes[key]
+
+		var err error
+		switch {
+		case change.original == nil && change.current != nil:
+			err = r.base.Create(change.current)
+		case change.original != nil && change.current != nil:
+			err = r.base.Update(change.current)
+		case change.original != nil && change.current == nil:
+			err = r.base.Delete(key)
+		}
+		if err != nil {
+			return err
+		}
+		change.applied = true
+	}
+	return nil
+}
+
+// rollback restores the original state of every change applied by commit.
+func (r *stagedRepository[T]) rollback() error {
+	var errs []error
+	for i := len(r.order) - 1; i >= 0; i-- {
+		key := r.order[i]
+		change := r.changes[key]
+		if !change.applied {
+			continue
+		}
+
+		var err error
+		switch {
+		case change.original == nil:
+			err = r.base.Delete(key)
+		case change.current == nil:
+			err = r.base.Create(change.original)
+		default:
+			err = r.base.Update(change.original)
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		change.applied = false
+	}
+	return errors.Join(errs...)
+}
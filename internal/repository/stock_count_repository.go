@@ -0,0 +1,52 @@
+// internal/repository/stock_count_repository.go
+package repository
+
+import (
+	"path/filepath"
+
+	"hot-coffee/models"
+)
+
+const stockCountsFileName = "stock_counts.json"
+
+type stockCountRepository struct {
+	store *jsonStore[models.StockCount]
+}
+
+func NewStockCountRepository(dataDir string) StockCountRepository {
+	return &stockCountRepository{
+		store: newJSONStore(filepath.Join(dataDir, stockCountsFileName), stockCountKey),
+	}
+}
+
+func stockCountKey(count *models.StockCount) string {
+	return count.ID
+}
+
+func (r *stockCountRepository) Create(count *models.StockCount) error {
+	return r.store.Insert(count)
+}
+
+func (r *stockCountRepository) GetByID(id string) (*models.StockCount, error) {
+	return r.store.Get(id)
+}
+
+func (r *stockCountRepository) GetAll() ([]*models.StockCount, error) {
+	return r.store.All()
+}
+
+func (r *stockCountRepository) Update(count *models.StockCount) error {
+	found, err := r.store.Replace(count)
+	if err == nil && !found {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (r *stockCountRepository) Delete(id string) error {
+	found, err := r.store.Remove(id)
+	if err == nil && !found {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,92 @@
+// internal/repository/storage.go
+package repository
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+const tempFilePattern = ".*.tmp"
+
+// writeFileAtomic writes data to a temporary file next to path, syncs it and
+// renames it over path, so a crash never leaves a partially written file behind.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir, name := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+name+tempFilePattern)
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	// Remove the temp file on any failure before the rename
+	committed := false
+	defer func() {
+		if !committed {
+			os.Remove(tmpPath)
+		}
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return err
+	}
+	committed = true
+
+	return syncDir(dir)
+}
+
+// syncDir flushes directory metadata so a completed rename survives a crash.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	// Some filesystems do not support syncing directories; the rename itself has still happened
+	d.Sync()
+	return nil
+}
+
+// saveJSONFile journals the marshalled value and then atomically replaces the data file.
+func saveJSONFile(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	journal := newJournal(path)
+	if err := journal.Append(data); err != nil {
+		return err
+	}
+	if err := writeFileAtomic(path, data, 0o644); err != nil {
+		return err
+	}
+
+	return journal.CheckpointIfNeeded(data)
+}
+
+// isValidJSONArray reports whether data holds a complete JSON array, which is
+// the top-level shape of every data file.
+func isValidJSONArray(data []byte) bool {
+	var records []json.RawMessage
+	return json.Unmarshal(data, &records) == nil
+}
@@ -0,0 +1,52 @@
+// internal/repository/supplier_repository.go
+package repository
+
+import (
+	"path/filepath"
+
+	"hot-coffee/models"
+)
+
+const suppliersFileName = "suppliers.json"
+
+type supplierRepository struct {
+	store *jsonStore[models.Supplier]
+}
+
+func NewSupplierRepository(dataDir string) SupplierRepository {
+	return &supplierRepository{
+		store: newJSONStore(filepath.Join(dataDir, suppliersFileName), supplierKey),
+	}
+}
+
+func supplierKey(supplier *models.Supplier) string {
+	return supplier.ID
+}
+
+func (r *supplierRepository) Create(supplier *models.Supplier) error {
+	return r.store.Insert(supplier)
+}
+
+func (r *supplierRepository) GetByID(id string) (*models.Supplier, error) {
+	return r.store.Get(id)
+}
+
+func (r *supplierRepository) GetAll() ([]*models.Supplier, error) {
+	return r.store.All()
+}
+
+func (r *supplierRepository) Update(supplier *models.Supplier) error {
+	found, err := r.store.Replace(supplier)
+	if err == nil && !found {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (r *supplierRepository) Delete(id string) error {
+	found, err := r.store.Remove(id)
+	if err == nil && !found {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,149 @@
+// internal/repository/unit_of_work.go
+package repository
+
+import (
+	"errors"
+	"log/slog"
+	"sort"
+	"sync"
+
+	"hot-coffee/models"
+)
+
+// Repositories groups the repositories that take part in a unit of work.
+type Repositories struct {
+	Orders         OrderRepository
+	Menu           MenuRepository
+	Inventory      InventoryRepository
+	Suppliers      SupplierRepository
+	PurchaseOrders PurchaseOrderRepository
+	StockCounts    StockCountRepository
+	Locations      LocationRepository
+	Categories     CategoryRepository
+	Movements      MovementRepository
+}
+
+// UnitOfWork runs business operations that span several repositories so that
+// their writes either all commit or all roll back.
+type UnitOfWork interface {
+	// Execute locks lockKeys, runs fn against staged repositories and commits the
+	// staged writes if fn returns nil. If fn or the commit fails, nothing is kept.
+	// Operations touching the same keys are serialized.
+	Execute(lockKeys []string, fn func(repos Repositories) error) error
+}
+
+type unitOfWork struct {
+	repos Repositories
+	locks *keyLocker
+}
+
+func NewUnitOfWork(repos Repositories) UnitOfWork {
+	return &unitOfWork{
+		repos: repos,
+		locks: newKeyLocker(),
+	}
+}
+
+func (u *unitOfWork) Execute(lockKeys []string, fn func(repos Repositories) error) error {
+	unlock := u.locks.Lock(lockKeys)
+	defer unlock()
+
+	staged, committers := stageRepositories(u.repos)
+	if err := fn(staged); err != nil {
+		return err
+	}
+
+	var applied []committer
+	for _, c := range committers {
+		if err := c.commit(); err != nil {
+			// Undo this repository's partial commit and every earlier one
+			applied = append(applied, c)
+			for i := len(applied) - 1; i >= 0; i-- {
+				if rbErr := applied[i].rollback(); rbErr != nil {
+					slog.Error("Failed to roll back unit of work", "error", rbErr)
+					err = errors.Join(err, rbErr)
+				}
+			}
+			return err
+		}
+		applied = append(applied, c)
+	}
+
+	return nil
+}
+
+// stageRepositories wraps repos in repositories that buffer their writes. It
+// returns the committers in the order their writes are committed.
+func stageRepositories(repos Repositories) (Repositories, []committer) {
+	orders := newStagedRepository[models.Order](repos.Orders, orderKey)
+	menu := newStagedRepository[models.MenuItem](repos.Menu, menuItemKey)
+	inventory := newStagedRepository[models.InventoryItem](repos.Inventory, inventoryItemKey)
+	suppliers := newStagedRepository[models.Supplier](repos.Suppliers, supplierKey)
+	purchaseOrders := newStagedRepository[models.PurchaseOrder](repos.PurchaseOrders, purchaseOrderKey)
+	stockCounts := newStagedRepository[models.StockCount](repos.StockCounts, stockCountKey)
+	locations := newStagedRepository[models.Location](repos.Locations, locationKey)
+	categories := newStagedRepository[models.Category](repos.Categories, categoryKey)
+	movements := newStagedMovements(repos.Movements)
+
+	staged := Repositories{
+		Orders:         orders,
+		Menu:           menu,
+		Inventory:      inventory,
+		Suppliers:      suppliers,
+		PurchaseOrders: purchaseOrders,
+		StockCounts:    stockCounts,
+		Locations:      locations,
+		Categories:     categories,
+		Movements:      movements,
+	}
+
+	// Movements go last: their append is atomic, so a failure there only has
+	// to undo the repositories before them
+	return staged, []committer{inventory, menu, orders, suppliers, purchaseOrders, stockCounts, locations, categories, movements}
+}
+
+// keyLocker hands out one mutex per key. Keys are always locked in sorted
+// order so that overlapping key sets cannot deadlock.
+type keyLocker struct {
+	mutex sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+func newKeyLocker() *keyLocker {
+	return &keyLocker{locks: make(map[string]*sync.Mutex)}
+}
+
+func (l *keyLocker) Lock(keys []string) func() {
+	unique := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		unique[key] = struct{}{}
+	}
+
+	sorted := make([]string, 0, len(unique))
+	for key := range unique {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+
+	l.mutex.Lock()
+	mutexes := make([]*sync.Mutex, len(sorted))
+	for i, key := range sorted {
+		m, ok := l.locks[key]
+		if !ok {
+			m = &sync.Mutex{}
+			l.locks[key] = m
+		}
+		mutexes[i] = m
+	}
+	l.mutex.Unlock()
+
+	for _, m := range mutexes {
+		m.Lock()
+	}
+
+	return func() {
+		for i := len(mutexes) - 1; i >= 0; i-- {
+			mutexes[i].Unlock()
+		}
+	}
+}
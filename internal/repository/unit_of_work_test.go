@@ -0,0 +1,155 @@
+// internal/repository/unit_of_work_test.go
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"hot-coffee/models"
+)
+
+var errInjected = errors.New("injected failure")
+
+// failingOrders fails to write the order with ID failOn.
+type failingOrders struct {
+	OrderRepository
+	failOn string
+}
+
+func (r *failingOrders) Create(order *models.Order) error {
+	if order.ID == r.failOn {
+		return errInjected
+	}
+	return r.OrderRepository.Create(order)
+}
+
+func (r *failingOrders) Update(order *models.Order) error {
+	if order.ID == r.failOn {
+		return errInjected
+	}
+	return r.OrderRepository.Update(order)
+}
+
+// failingMovements fails every append when fail is set.
+type failingMovements struct {
+	MovementRepository
+	fail bool
+}
+
+func (r *failingMovements) Append(movements ...*models.InventoryMovement) error {
+	if r.fail {
+		return errInjected
+	}
+	return r.MovementRepository.Append(movements...)
+}
+
+func TestUnitOfWorkExecute(t *testing.T) {
+	tests := []struct {
+		name          string
+		fnErr         error
+		failOrder     string
+		failMovements bool
+		wantErr       bool
+	}{
+		{name: "commits every repository"},
+		{name: "function fails", fnErr: errInjected, wantErr: true},
+		{name: "first order fails after inventory and menu", failOrder: "o1", wantErr: true},
+		{name: "second order fails after the first", failOrder: "o2", wantErr: true},
+		{name: "ledger append fails last", failMovements: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			repos := Repositories{
+				Inventory: NewInventoryRepository(dir),
+				Menu:      NewMenuRepository(dir),
+				Orders:    &failingOrders{OrderRepository: NewOrderRepository(dir), failOn: tt.failOrder},
+				Movements: &failingMovements{MovementRepository: NewMovementRepository(dir), fail: tt.failMovements},
+			}
+			if err := repos.Inventory.Create(&models.InventoryItem{IngredientID: "milk", Name: "Milk", Quantity: 100, Unit: "ml"}); err != nil {
+				t.Fatal(err)
+			}
+			if err := repos.Menu.Create(&models.MenuItem{ID: "latte", Name: "Latte", Price: 3}); err != nil {
+				t.Fatal(err)
+			}
+			if err := repos.Orders.Create(&models.Order{ID: "o0", Status: "open"}); err != nil {
+				t.Fatal(err)
+			}
+
+			err := NewUnitOfWork(repos).Execute([]string{"inventory:milk"}, func(staged Repositories) error {
+				milk, err := staged.Inventory.GetByID("milk")
+				if err != nil {
+					return err
+				}
+				milk.Quantity = 90
+				if err := staged.Inventory.Update(milk); err != nil {
+					return err
+				}
+				if err := staged.Menu.Update(&models.MenuItem{ID: "latte", Name: "Latte", Price: 4}); err != nil {
+					return err
+				}
+				if err := staged.Orders.Update(&models.Order{ID: "o0", Status: "completed"}); err != nil {
+					return err
+				}
+				if err := staged.Orders.Create(&models.Order{ID: "o1", Status: "open"}); err != nil {
+					return err
+				}
+				if err := staged.Orders.Create(&models.Order{ID: "o2", Status: "open"}); err != nil {
+					return err
+				}
+				if err := staged.Movements.Append(&models.InventoryMovement{ID: "m1", IngredientID: "milk", Delta: -10}); err != nil {
+					return err
+				}
+				return tt.fnErr
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			// Either every write is kept or the repositories are as seeded
+			wantQuantity, wantPrice, wantStatus, wantMovements := 100.0, 3.0, "open", 0
+			if !tt.wantErr {
+				wantQuantity, wantPrice, wantStatus, wantMovements = 90, 4, "completed", 1
+			}
+
+			milk, err := repos.Inventory.GetByID("milk")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if milk.Quantity != wantQuantity {
+				t.Errorf("milk quantity = %g, want %g", milk.Quantity, wantQuantity)
+			}
+			latte, err := repos.Menu.GetByID("latte")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if latte.Price != wantPrice {
+				t.Errorf("latte price = %g, want %g", latte.Price, wantPrice)
+			}
+			o0, err := repos.Orders.GetByID("o0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if o0.Status != wantStatus {
+				t.Errorf("order o0 status = %q, want %q", o0.Status, wantStatus)
+			}
+			for _, id := range []string{"o1", "o2"} {
+				order, err := repos.Orders.GetByID(id)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if (order != nil) == tt.wantErr {
+					t.Errorf("order %s exists = %t, want %t", id, order != nil, !tt.wantErr)
+				}
+			}
+			movements, err := repos.Movements.GetAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(movements) != wantMovements {
+				t.Errorf("got %d movements, want %d", len(movements), wantMovements)
+			}
+		})
+	}
+}
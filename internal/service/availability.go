@@ -0,0 +1,127 @@
+// internal/service/availability.go
+package service
+
+import (
+	"math"
+	"time"
+
+	"hot-coffee/models"
+)
+
+// recipePortions returns how many units of a recipe the usable stock at a
+// location can make, limited by the scarcest ingredient, or nil when the
+// recipe uses no stock. Ingredients missing from the inventory make none.
+func recipePortions(stock *stockUnits, recipe []models.MenuItemIngredient, locationID string, now time.Time) (*int, error) {
+	required := make(map[string]float64, len(recipe))
+	for _, ingredient := range recipe {
+		quantity, err := stock.toStock(ingredient.IngredientID, ingredient.Quantity, ingredient.Unit)
+		if err != nil {
+			return nil, err
+		}
+		required[ingredient.IngredientID] += quantity
+	}
+
+	var portions *int
+	for _, ingredientID := range sortedIngredientIDs(required) {
+		if required[ingredientID] <= 0 {
+			continue
+		}
+		item, err := stock.item(ingredientID)
+		if err != nil {
+			return nil, err
+		}
+
+		makes := 0
+		if item != nil && item.DeletedAt == "" {
+			makes = int(math.Floor(usableQuantity(item, locationID, now)/required[ingredientID] + ledgerTolerance))
+		}
+		if portions == nil || makes < *portions {
+			portions = &makes
+		}
+	}
+	return portions, nil
+}
+
+// stampAvailability sets whether a menu item and each of its variants can be
+// ordered at a location at now, given in the shop's time zone, and how many
+// portions the stock there can make. An item with variants can make as many
+// portions as its best stocked variant.
+func stampAvailability(stock *stockUnits, menuItem *models.MenuItem, locationID string, now time.Time) error {
+	scheduled := onSchedule(menuItem, now)
+	menuItem.OnSchedule = nil
+	if len(menuItem.Schedules) > 0 {
+		menuItem.OnSchedule = &scheduled
+	}
+	stampSoldOut(menuItem, locationID)
+	blocked := menuItem.SoldOut || !scheduled
+
+	if len(menuItem.Variants) == 0 {
+		portions, err := recipePortions(stock, menuItem.Ingredients, locationID, now)
+		if err != nil {
+			return err
+		}
+		menuItem.Portions = portions
+		menuItem.Available = availability(blocked, portions)
+		return nil
+	}
+
+	var best *int
+	unlimited := false
+	for i := range menuItem.Variants {
+		variant := &menuItem.Variants[i]
+		portions, err := recipePortions(stock, variant.Ingredients, locationID, now)
+		if err != nil {
+			return err
+		}
+		variant.Portions = portions
+		variant.Available = availability(blocked, portions)
+
+		if portions == nil {
+			unlimited = true
+		} else if best == nil || *portions > *best {
+			best = portions
+		}
+	}
+	if unlimited {
+		best = nil
+	}
+	menuItem.Portions = best
+	menuItem.Available = availability(blocked, best)
+	return nil
+}
+
+func availability(blocked bool, portions *int) *bool {
+	available := !blocked && (portions == nil || *portions > 0)
+	return &available
+}
+
+// soldOutLocations returns where a menu item is marked sold out. An item
+// marked sold out before the mark was kept per location is sold out at the
+// default location.
+func soldOutLocations(menuItem *models.MenuItem) []models.SoldOutLocation {
+	if len(menuItem.SoldOutLocations) == 0 && menuItem.SoldOut {
+		return []models.SoldOutLocation{{LocationID: models.DefaultLocationID, SoldOutAt: menuItem.SoldOutAt}}
+	}
+	return menuItem.SoldOutLocations
+}
+
+// soldOutAt returns the mark that makes a menu item sold out at a location,
+// or nil if there is none.
+func soldOutAt(menuItem *models.MenuItem, locationID string) *models.SoldOutLocation {
+	locations := soldOutLocations(menuItem)
+	for i := range locations {
+		if locations[i].LocationID == locationID {
+			return &locations[i]
+		}
+	}
+	return nil
+}
+
+// stampSoldOut sets whether a menu item is marked sold out at a location.
+func stampSoldOut(menuItem *models.MenuItem, locationID string) {
+	menuItem.SoldOutLocations = soldOutLocations(menuItem)
+	menuItem.SoldOut, menuItem.SoldOutAt = false, ""
+	if mark := soldOutAt(menuItem, locationID); mark != nil {
+		menuItem.SoldOut, menuItem.SoldOutAt = true, mark.SoldOutAt
+	}
+}
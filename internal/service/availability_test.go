@@ -0,0 +1,162 @@
+// internal/service/availability_test.go
+package service
+
+import (
+	"testing"
+	"time"
+
+	"hot-coffee/models"
+)
+
+func TestStampAvailability(t *testing.T) {
+	latte := []models.MenuItemIngredient{{IngredientID: "milk", Quantity: 200}, {IngredientID: "coffee", Quantity: 18}}
+	tests := []struct {
+		name          string
+		item          models.MenuItem
+		locationID    string
+		wantAvailable bool
+		wantPortions  *int
+	}{
+		{
+			name:          "the scarcest ingredient limits the portions",
+			item:          models.MenuItem{Ingredients: latte},
+			wantAvailable: true,
+			wantPortions:  intPtr(5),
+		},
+		{
+			name:          "expired stock is not counted",
+			item:          models.MenuItem{Ingredients: []models.MenuItemIngredient{{IngredientID: "cream", Quantity: 50}}},
+			wantAvailable: true,
+			wantPortions:  intPtr(2),
+		},
+		{
+			name:         "stock at another location is not counted",
+			item:         models.MenuItem{Ingredients: latte},
+			locationID:   "bar",
+			wantPortions: intPtr(0),
+		},
+		{
+			name:         "an ingredient missing from the inventory makes none",
+			item:         models.MenuItem{Ingredients: []models.MenuItemIngredient{{IngredientID: "saffron", Quantity: 1}}},
+			wantPortions: intPtr(0),
+		},
+		{
+			name:          "a recipe without stock has no portions",
+			item:          models.MenuItem{Ingredients: []models.MenuItemIngredient{}},
+			wantAvailable: true,
+		},
+		{
+			name:         "sold out whatever the stock",
+			item:         models.MenuItem{Ingredients: latte, SoldOutLocations: []models.SoldOutLocation{{LocationID: "main"}}},
+			wantPortions: intPtr(5),
+		},
+		{
+			name:          "sold out at another location only",
+			item:          models.MenuItem{Ingredients: latte, SoldOutLocations: []models.SoldOutLocation{{LocationID: "bar"}}},
+			wantAvailable: true,
+			wantPortions:  intPtr(5),
+		},
+		{
+			name: "variants report the best stocked one",
+			item: models.MenuItem{Variants: []models.MenuItemVariant{
+				{ID: "small", Ingredients: []models.MenuItemIngredient{{IngredientID: "milk", Quantity: 150}}},
+				{ID: "large", Ingredients: []models.MenuItemIngredient{{IngredientID: "milk", Quantity: 300}}},
+			}},
+			wantAvailable: true,
+			wantPortions:  intPtr(6),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := newTestRepositories(t)
+			addStock(t, repos, "milk", "ml", 1000, 0)
+			addStock(t, repos, "coffee", "g", 100, 0)
+			addStock(t, repos, "cream", "ml", 300, 0)
+			cream, err := repos.Inventory.GetByID("cream")
+			if err != nil {
+				t.Fatal(err)
+			}
+			cream.Lots = []models.StockLot{
+				{ID: "old", Quantity: 200, ExpiresAt: "2000-01-01", LocationID: models.DefaultLocationID},
+				{ID: "new", Quantity: 100, ExpiresAt: "2999-12-31", LocationID: models.DefaultLocationID},
+			}
+			if err := repos.Inventory.Update(cream); err != nil {
+				t.Fatal(err)
+			}
+
+			item := tt.item
+			err = stampAvailability(newStockUnits(repos.Inventory), &item, locationOrDefault(tt.locationID), time.Now())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if item.Available == nil || *item.Available != tt.wantAvailable {
+				t.Errorf("available = %v, want %t", item.Available, tt.wantAvailable)
+			}
+			if (item.Portions == nil) != (tt.wantPortions == nil) || item.Portions != nil && *item.Portions != *tt.wantPortions {
+				t.Errorf("portions = %v, want %v", describePortions(item.Portions), describePortions(tt.wantPortions))
+			}
+		})
+	}
+}
+
+func TestSetSoldOut(t *testing.T) {
+	repos := newTestRepositories(t)
+	if err := repos.Locations.Create(&models.Location{ID: "bar", Name: "Bar"}); err != nil {
+		t.Fatal(err)
+	}
+	addStock(t, repos, "milk", "ml", 1000, 0)
+	milk, err := repos.Inventory.GetByID("milk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	milk.Stock = []models.LocationStock{{LocationID: models.DefaultLocationID, Quantity: 500}, {LocationID: "bar", Quantity: 500}}
+	if err := repos.Inventory.Update(milk); err != nil {
+		t.Fatal(err)
+	}
+	addMenuItem(t, repos, &models.MenuItem{ID: "latte", Name: "Latte", Price: 4, Ingredients: []models.MenuItemIngredient{{IngredientID: "milk", Quantity: 200}}})
+	s := newTestMenuService(repos)
+
+	item, err := s.SetSoldOut("latte", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !item.SoldOut || *item.Available {
+		t.Errorf("latte is sold out %t and available %t at main, want sold out and unavailable", item.SoldOut, *item.Available)
+	}
+	availableAt := func(locationID string) int {
+		t.Helper()
+		items, err := s.GetAllMenuItems(locationID, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(items)
+	}
+	if availableAt("") != 0 || availableAt("bar") != 1 {
+		t.Errorf("available items are %d at main and %d at the bar, want 0 and 1", availableAt(""), availableAt("bar"))
+	}
+
+	// New orders at main cannot include it
+	order := &models.Order{CustomerName: "Ann", Items: []models.OrderItem{{ProductID: "latte", Quantity: 1}}}
+	if err := newTestOrderService(repos).CreateOrder(order); errorKind(err) != KindConflict {
+		t.Fatalf("CreateOrder() error = %v, want a conflict", err)
+	}
+
+	if _, err := s.SetSoldOut("latte", "", false); err != nil {
+		t.Fatal(err)
+	}
+	if availableAt("") != 1 {
+		t.Errorf("latte is not available at main once no longer sold out")
+	}
+}
+
+func intPtr(n int) *int {
+	return &n
+}
+
+func describePortions(portions *int) any {
+	if portions == nil {
+		return "none"
+	}
+	return *portions
+}
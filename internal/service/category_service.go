@@ -0,0 +1,160 @@
+// internal/service/category_service.go
+package service
+
+import (
+	"cmp"
+	"errors"
+	"log/slog"
+	"slices"
+	"time"
+
+	"hot-coffee/internal/repository"
+	"hot-coffee/models"
+)
+
+type categoryService struct {
+	categoryRepo repository.CategoryRepository
+	menuRepo     repository.MenuRepository
+}
+
+func NewCategoryService(categoryRepo repository.CategoryRepository, menuRepo repository.MenuRepository) CategoryService {
+	return &categoryService{
+		categoryRepo: categoryRepo,
+		menuRepo:     menuRepo,
+	}
+}
+
+// CreateCategory adds a category, deriving its ID from the name when none is given.
+func (s *categoryService) CreateCategory(category *models.Category) error {
+	category.DeletedAt = ""
+	if category.ID == "" {
+		id, err := uniqueSlug(category.Name, func(id string) (bool, error) {
+			existing, err := s.categoryRepo.GetByID(id)
+			return existing != nil, err
+		})
+		if err != nil {
+			return err
+		}
+		category.ID = id
+	}
+
+	if err := s.categoryRepo.Create(category); err != nil {
+		slog.Error("Failed to create category", "error", err)
+		if errors.Is(err, repository.ErrDuplicateID) {
+			return ConflictError("category %s already exists", category.ID)
+		}
+		return err
+	}
+
+	slog.Info("Category created", "categoryID", category.ID, "name", category.Name)
+	return nil
+}
+
+func (s *categoryService) GetCategoryByID(id string) (*models.Category, error) {
+	category, err := s.categoryRepo.GetByID(id)
+	if err != nil {
+		slog.Error("Failed to get category", "categoryID", id, "error", err)
+		return nil, err
+	}
+	if category == nil {
+		return nil, NotFoundError("category not found")
+	}
+	return category, nil
+}
+
+// GetAllCategories returns the categories that are not deleted in display
+// order.
+func (s *categoryService) GetAllCategories() ([]*models.Category, error) {
+	categories, err := s.categoryRepo.GetAll()
+	if err != nil {
+		slog.Error("Failed to get all categories", "error", err)
+		return nil, err
+	}
+
+	active := categories[:0]
+	for _, category := range categories {
+		if category.DeletedAt == "" {
+			active = append(active, category)
+		}
+	}
+	slices.SortStableFunc(active, compareCategories)
+	return active, nil
+}
+
+func (s *categoryService) UpdateCategory(category *models.Category) error {
+	existing, err := s.categoryRepo.GetByID(category.ID)
+	if err != nil {
+		return err
+	}
+	if existing == nil || existing.DeletedAt != "" {
+		return NotFoundError("category not found")
+	}
+
+	category.DeletedAt = ""
+	if err := s.categoryRepo.Update(category); err != nil {
+		slog.Error("Failed to update category", "categoryID", category.ID, "error", err)
+		if errors.Is(err, repository.ErrNotFound) {
+			return NotFoundError("category not found")
+		}
+		return err
+	}
+
+	slog.Info("Category updated", "categoryID", category.ID, "name", category.Name)
+	return nil
+}
+
+// DeleteCategory refuses to delete a category that menu items still belong to.
+// Categories are soft-deleted so that sales reports keep naming the category
+// past orders were sold in.
+func (s *categoryService) DeleteCategory(id string) error {
+	category, err := s.categoryRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if category == nil || category.DeletedAt != "" {
+		return NotFoundError("category not found")
+	}
+
+	menuItems, err := s.menuRepo.GetAll()
+	if err != nil {
+		return err
+	}
+	var members []models.MenuItemReference
+	for _, item := range menuItems {
+		if item.CategoryID == id && item.DeletedAt == "" {
+			members = append(members, models.MenuItemReference{ProductID: item.ID, Name: item.Name})
+		}
+	}
+	if len(members) > 0 {
+		return referenceConflict("category "+id+" still has menu items", members, nil)
+	}
+
+	category.DeletedAt = time.Now().Format(time.RFC3339)
+	if err := s.categoryRepo.Update(category); err != nil {
+		slog.Error("Failed to delete category", "categoryID", id, "error", err)
+		return err
+	}
+
+	slog.Info("Category deleted", "categoryID", id)
+	return nil
+}
+
+// checkCategoryExists reports a validation error on field unless id names a
+// category that is not deleted. An empty id is no category.
+func checkCategoryExists(categoryRepo repository.CategoryRepository, field, id string) error {
+	if id == "" {
+		return nil
+	}
+	category, err := categoryRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if category == nil || category.DeletedAt != "" {
+		return FieldError(field, "unknown category: %s", id)
+	}
+	return nil
+}
+
+func compareCategories(a, b *models.Category) int {
+	return cmp.Or(cmp.Compare(a.DisplayOrder, b.DisplayOrder), cmp.Compare(a.Name, b.Name))
+}
@@ -0,0 +1,129 @@
+// internal/service/costing.go
+package service
+
+import (
+	"slices"
+
+	"hot-coffee/models"
+)
+
+// costOf returns what quantities of ingredients, in the units they are stocked
+// in, cost at the ingredients' current unit costs. Ingredients missing from
+// the inventory or without a unit cost count as free.
+func (s *stockUnits) costOf(ingredients map[string]float64) (float64, error) {
+	var cost float64
+	for ingredientID, quantity := range ingredients {
+		item, err := s.item(ingredientID)
+		if err != nil {
+			return 0, err
+		}
+		if item != nil {
+			cost += quantity * item.UnitCost
+		}
+	}
+	return cost, nil
+}
+
+// costRecipe values one unit of a recipe at the ingredients' current unit
+// costs and returns the cost of each ingredient and the unrounded total.
+func costRecipe(stock *stockUnits, recipe []models.MenuItemIngredient) ([]models.IngredientCost, float64, error) {
+	costs := make([]models.IngredientCost, 0, len(recipe))
+	var total float64
+	for _, ingredient := range recipe {
+		quantity, err := stock.toStock(ingredient.IngredientID, ingredient.Quantity, ingredient.Unit)
+		if err != nil {
+			return nil, 0, err
+		}
+		item, err := stock.item(ingredient.IngredientID)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		line := models.IngredientCost{
+			IngredientID: ingredient.IngredientID,
+			Name:         ingredient.IngredientID,
+			Quantity:     quantity,
+			Unit:         ingredient.Unit,
+		}
+		if item != nil {
+			line.Name = item.Name
+			line.Unit = item.Unit
+			line.UnitCost = item.UnitCost
+			line.Cost = roundMoney(quantity * item.UnitCost)
+			total += quantity * item.UnitCost
+		}
+		costs = append(costs, line)
+	}
+	return costs, total, nil
+}
+
+// costMenuItem breaks down the cost and margin of a menu item and each of its
+// variants.
+func costMenuItem(stock *stockUnits, menuItem *models.MenuItem) (*models.MenuItemCosting, error) {
+	ingredients, cost, err := costRecipe(stock, menuItem.Ingredients)
+	if err != nil {
+		return nil, err
+	}
+	costing := &models.MenuItemCosting{
+		ProductID:   menuItem.ID,
+		Name:        menuItem.Name,
+		Price:       menuItem.Price,
+		Cost:        roundMoney(cost),
+		Ingredients: ingredients,
+	}
+	costing.Margin, costing.MarginPercent = margin(menuItem.Price, cost)
+
+	uncosted := uncostedIngredients(nil, ingredients)
+	for _, variant := range menuItem.Variants {
+		ingredients, cost, err := costRecipe(stock, variant.Ingredients)
+		if err != nil {
+			return nil, err
+		}
+		variantCosting := models.VariantCosting{
+			VariantID:   variant.ID,
+			Name:        variant.Name,
+			Price:       variant.Price,
+			Cost:        roundMoney(cost),
+			Ingredients: ingredients,
+		}
+		variantCosting.Margin, variantCosting.MarginPercent = margin(variant.Price, cost)
+		costing.Variants = append(costing.Variants, variantCosting)
+		uncosted = uncostedIngredients(uncosted, ingredients)
+	}
+
+	slices.Sort(uncosted)
+	costing.Uncosted = uncosted
+	return costing, nil
+}
+
+// stampMenuItemCost sets the computed cost and margin of a menu item and its
+// variants.
+func stampMenuItemCost(stock *stockUnits, menuItem *models.MenuItem) error {
+	costing, err := costMenuItem(stock, menuItem)
+	if err != nil {
+		return err
+	}
+	menuItem.Cost, menuItem.Margin = costing.Cost, costing.Margin
+	for i, variant := range costing.Variants {
+		menuItem.Variants[i].Cost, menuItem.Variants[i].Margin = variant.Cost, variant.Margin
+	}
+	return nil
+}
+
+// margin returns what is left of price after cost and the percentage of price
+// that is, rounded for display.
+func margin(price, cost float64) (float64, float64) {
+	if price <= 0 {
+		return roundMoney(price - cost), 0
+	}
+	return roundMoney(price - cost), roundMoney((price - cost) / price * 100)
+}
+
+func uncostedIngredients(uncosted []string, ingredients []models.IngredientCost) []string {
+	for _, ingredient := range ingredients {
+		if ingredient.UnitCost == 0 && !slices.Contains(uncosted, ingredient.IngredientID) {
+			uncosted = append(uncosted, ingredient.IngredientID)
+		}
+	}
+	return uncosted
+}
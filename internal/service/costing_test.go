@@ -0,0 +1,124 @@
+// internal/service/costing_test.go
+package service
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"hot-coffee/models"
+)
+
+func TestAverageInCost(t *testing.T) {
+	tests := []struct {
+		name         string
+		onHand       float64
+		unitCost     float64
+		quantity     float64
+		cost         float64
+		wantUnitCost float64
+	}{
+		{name: "weighted by quantity", onHand: 1000, unitCost: 0.002, quantity: 3000, cost: 0.003, wantUnitCost: 0.00275},
+		{name: "first cost is taken as it is", onHand: 1000, quantity: 500, cost: 0.004, wantUnitCost: 0.004},
+		{name: "empty stock takes the new cost", onHand: 0, unitCost: 0.002, quantity: 500, cost: 0.004, wantUnitCost: 0.004},
+		{name: "stock below zero counts as empty", onHand: -200, unitCost: 0.002, quantity: 500, cost: 0.004, wantUnitCost: 0.004},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := &models.InventoryItem{Quantity: tt.onHand, UnitCost: tt.unitCost}
+			averageInCost(item, tt.quantity, tt.cost)
+			if math.Abs(item.UnitCost-tt.wantUnitCost) > 1e-12 {
+				t.Errorf("unit cost = %g, want %g", item.UnitCost, tt.wantUnitCost)
+			}
+		})
+	}
+}
+
+func TestCostMenuItem(t *testing.T) {
+	repos := newTestRepositories(t)
+	addStock(t, repos, "milk", "ml", 10000, 0.002)
+	addStock(t, repos, "coffee", "g", 1000, 0.02)
+	addStock(t, repos, "syrup", "ml", 1000, 0)
+	latte := &models.MenuItem{
+		ID:    "latte",
+		Name:  "Latte",
+		Price: 4,
+		Ingredients: []models.MenuItemIngredient{
+			{IngredientID: "milk", Quantity: 0.2, Unit: "l"},
+			{IngredientID: "coffee", Quantity: 18},
+		},
+		Variants: []models.MenuItemVariant{{
+			ID:    "vanilla",
+			Name:  "Vanilla latte",
+			Price: 4.5,
+			Ingredients: []models.MenuItemIngredient{
+				{IngredientID: "milk", Quantity: 200},
+				{IngredientID: "coffee", Quantity: 18},
+				{IngredientID: "syrup", Quantity: 10},
+			},
+		}},
+	}
+
+	costing, err := costMenuItem(newStockUnits(repos.Inventory), latte)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 200 ml of milk at 0.002 and 18 g of coffee at 0.02
+	if costing.Cost != 0.76 || costing.Margin != 3.24 || costing.MarginPercent != 81 {
+		t.Errorf("latte costs %g with a margin of %g (%g%%), want 0.76, 3.24 and 81%%", costing.Cost, costing.Margin, costing.MarginPercent)
+	}
+	if milk := costing.Ingredients[0]; milk.Quantity != 200 || milk.Unit != "ml" || milk.Cost != 0.4 {
+		t.Errorf("milk line = %g %s costing %g, want 200 ml costing 0.4", milk.Quantity, milk.Unit, milk.Cost)
+	}
+	if len(costing.Variants) != 1 {
+		t.Fatalf("got %d variant costings, want 1", len(costing.Variants))
+	}
+	if vanilla := costing.Variants[0]; vanilla.Cost != 0.76 || vanilla.Margin != 3.74 {
+		t.Errorf("vanilla latte costs %g with a margin of %g, want 0.76 and 3.74", vanilla.Cost, vanilla.Margin)
+	}
+	if !slices.Equal(costing.Uncosted, []string{"syrup"}) {
+		t.Errorf("uncosted = %v, want [syrup]", costing.Uncosted)
+	}
+}
+
+func TestOrderKeepsCostOfGoods(t *testing.T) {
+	repos := newTestRepositories(t)
+	addStock(t, repos, "milk", "ml", 1000, 0.002)
+	addStock(t, repos, "coffee", "g", 1000, 0.02)
+	addMenuItem(t, repos, &models.MenuItem{
+		ID:    "latte",
+		Name:  "Latte",
+		Price: 4,
+		Ingredients: []models.MenuItemIngredient{
+			{IngredientID: "milk", Quantity: 200},
+			{IngredientID: "coffee", Quantity: 18},
+		},
+	})
+	s := newTestOrderService(repos)
+
+	order := &models.Order{CustomerName: "Ann", Items: []models.OrderItem{{ProductID: "latte", Quantity: 2}}}
+	if err := s.CreateOrder(order); err != nil {
+		t.Fatal(err)
+	}
+	if line := order.Items[0]; line.UnitCost != 0.76 || line.LineCost != 1.52 || order.CostOfGoods != 1.52 {
+		t.Errorf("order costs %g per unit, %g per line and %g in all, want 0.76, 1.52 and 1.52", line.UnitCost, line.LineCost, order.CostOfGoods)
+	}
+
+	// A later price rise does not change what was sold
+	milk, err := repos.Inventory.GetByID("milk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	milk.UnitCost = 0.004
+	if err := repos.Inventory.Update(milk); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := s.GetOrderByID(order.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.CostOfGoods != 1.52 {
+		t.Errorf("stored cost of goods = %g, want 1.52", stored.CostOfGoods)
+	}
+}
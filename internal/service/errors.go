@@ -0,0 +1,94 @@
+// internal/service/errors.go
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"hot-coffee/models"
+)
+
+// ErrorKind classifies service errors so that callers can react to them
+// without inspecting messages.
+type ErrorKind string
+
+const (
+	KindNotFound          ErrorKind = "not_found"
+	KindValidation        ErrorKind = "validation_error"
+	KindConflict          ErrorKind = "conflict"
+	KindInsufficientStock ErrorKind = "insufficient_stock"
+	KindInternal          ErrorKind = "internal_error"
+)
+
+// Error is the typed error returned by services. Errors of any other type
+// coming out of a service are internal failures such as disk I/O errors.
+type Error struct {
+	Kind    ErrorKind
+	Message string
+	Details []models.ErrorDetail
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func NotFoundError(format string, args ...any) *Error {
+	return &Error{Kind: KindNotFound, Message: fmt.Sprintf(format, args...)}
+}
+
+func ConflictError(format string, args ...any) *Error {
+	return &Error{Kind: KindConflict, Message: fmt.Sprintf(format, args...)}
+}
+
+func ValidationError(format string, args ...any) *Error {
+	return &Error{Kind: KindValidation, Message: fmt.Sprintf(format, args...)}
+}
+
+// FieldError is a validation error for a single request field.
+func FieldError(field, format string, args ...any) *Error {
+	message := fmt.Sprintf(format, args...)
+	return &Error{
+		Kind:    KindValidation,
+		Message: message,
+		Details: []models.ErrorDetail{{Field: field, Message: message}},
+	}
+}
+
+// InsufficientStockError reports every ingredient that is short.
+func InsufficientStockError(shortages []models.ErrorDetail) *Error {
+	message := "insufficient inventory"
+	if len(shortages) == 1 {
+		message = fmt.Sprintf("insufficient inventory for ingredient '%s'", shortages[0].IngredientID)
+	}
+	return &Error{Kind: KindInsufficientStock, Message: message, Details: shortages}
+}
+
+// shortage describes an ingredient that has available of the required
+// quantity, in the unit it is stocked in.
+func shortage(ingredientID, unit string, required, available float64) models.ErrorDetail {
+	shortfall := required - available
+	return models.ErrorDetail{
+		IngredientID: ingredientID,
+		Unit:         unit,
+		Required:     &required,
+		Available:    &available,
+		Shortfall:    &shortfall,
+	}
+}
+
+// KindOf returns the kind of err, treating untyped errors as internal.
+func KindOf(err error) ErrorKind {
+	var serviceErr *Error
+	if errors.As(err, &serviceErr) {
+		return serviceErr.Kind
+	}
+	return KindInternal
+}
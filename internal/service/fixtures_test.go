@@ -0,0 +1,104 @@
+// internal/service/fixtures_test.go
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"hot-coffee/internal/notify"
+	"hot-coffee/internal/repository"
+	"hot-coffee/models"
+)
+
+// newTestRepositories returns JSON repositories in a temporary data directory
+// that holds the default location.
+func newTestRepositories(t *testing.T) repository.Repositories {
+	t.Helper()
+	dir := t.TempDir()
+	repos := repository.Repositories{
+		Orders:         repository.NewOrderRepository(dir),
+		Menu:           repository.NewMenuRepository(dir),
+		Inventory:      repository.NewInventoryRepository(dir),
+		Suppliers:      repository.NewSupplierRepository(dir),
+		PurchaseOrders: repository.NewPurchaseOrderRepository(dir),
+		StockCounts:    repository.NewStockCountRepository(dir),
+		Locations:      repository.NewLocationRepository(dir),
+		Categories:     repository.NewCategoryRepository(dir),
+		Movements:      repository.NewMovementRepository(dir),
+	}
+	if err := repos.Locations.Create(&models.Location{ID: models.DefaultLocationID, Name: "Main"}); err != nil {
+		t.Fatal(err)
+	}
+	return repos
+}
+
+// addStock stores an ingredient holding quantity at the default location, with
+// its opening balance in the ledger.
+func addStock(t *testing.T, repos repository.Repositories, id, unit string, quantity, unitCost float64) {
+	t.Helper()
+	item := &models.InventoryItem{
+		IngredientID: id,
+		Name:         id,
+		Quantity:     quantity,
+		Unit:         unit,
+		UnitCost:     unitCost,
+		Stock:        []models.LocationStock{{LocationID: models.DefaultLocationID, Quantity: quantity}},
+	}
+	if err := repos.Inventory.Create(item); err != nil {
+		t.Fatal(err)
+	}
+	err := repos.Movements.Append(&models.InventoryMovement{
+		ID:            generateID(),
+		IngredientID:  id,
+		Delta:         quantity,
+		QuantityAfter: quantity,
+		Reason:        models.MovementReasonOpening,
+		LocationID:    models.DefaultLocationID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// addMenuItem stores a menu item.
+func addMenuItem(t *testing.T, repos repository.Repositories, item *models.MenuItem) {
+	t.Helper()
+	if err := repos.Menu.Create(item); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// quantityOf returns the stored quantity of an ingredient.
+func quantityOf(t *testing.T, repos repository.Repositories, id string) float64 {
+	t.Helper()
+	item, err := repos.Inventory.GetByID(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item == nil {
+		t.Fatalf("ingredient %s not found", id)
+	}
+	return item.Quantity
+}
+
+func newTestOrderService(repos repository.Repositories) OrderService {
+	return NewOrderService(repos.Orders, repos.Menu, repos.Inventory, repository.NewUnitOfWork(repos), notify.Multi(), time.UTC)
+}
+
+// errorKind returns the kind of a service error, or "" for other errors.
+func errorKind(err error) ErrorKind {
+	var serviceErr *Error
+	if errors.As(err, &serviceErr) {
+		return serviceErr.Kind
+	}
+	return ""
+}
+
+func newTestMenuService(repos repository.Repositories) MenuService {
+	return NewMenuService(repos.Menu, repos.Inventory, repos.Orders, repos.Locations, repos.Categories, repository.NewUnitOfWork(repos), time.UTC)
+}
+
+func newTestInventoryService(repos repository.Repositories) InventoryService {
+	return NewInventoryService(repos.Inventory, repos.Menu, repos.Orders, repos.Movements, repos.Locations, repository.NewUnitOfWork(repos), time.UTC)
+}
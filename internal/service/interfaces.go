@@ -1,7 +1,11 @@
 // internal/service/interfaces.go
 package service
 
-import "hot-coffee/models"
+import (
+	"time"
+
+	"hot-coffee/models"
+)
 
 type OrderService interface {
 	CreateOrder(order *models.Order) error
@@ -10,25 +14,88 @@ type OrderService interface {
 	UpdateOrder(order *models.Order) error
 	DeleteOrder(id string) error
 	CloseOrder(id string) error
+	CancelOrder(id, reason string) (*models.Order, error)
+	ChangeOrderStatus(id, status, reason string) (*models.Order, error)
 }
 
 type MenuService interface {
 	CreateMenuItem(item *models.MenuItem) error
-	GetMenuItemByID(id string) (*models.MenuItem, error)
-	GetAllMenuItems() ([]*models.MenuItem, error)
+	GetMenuItemByID(id, locationID string) (*models.MenuItem, error)
+	GetAllMenuItems(locationID string, availableOnly bool) ([]*models.MenuItem, error)
 	UpdateMenuItem(item *models.MenuItem) error
-	DeleteMenuItem(id string) error
+	DeleteMenuItem(id string, cascade bool) error
+	GetMenuItemUsage(id string) (*models.MenuItemUsage, error)
+	GetMenuItemCosting(id string) (*models.MenuItemCosting, error)
+	SetSoldOut(id, locationID string, soldOut bool) (*models.MenuItem, error)
+	GetStructuredMenu(locationID string, availableOnly bool) (*models.StructuredMenu, error)
+	PreviewMenu(at time.Time, locationID string, availableOnly bool) (*models.StructuredMenu, error)
+}
+
+type CategoryService interface {
+	CreateCategory(category *models.Category) error
+	GetCategoryByID(id string) (*models.Category, error)
+	GetAllCategories() ([]*models.Category, error)
+	UpdateCategory(category *models.Category) error
+	DeleteCategory(id string) error
 }
 
 type InventoryService interface {
-	CreateInventoryItem(item *models.InventoryItem) error
+	CreateInventoryItem(item *models.InventoryItem, actor string) error
 	GetInventoryItemByID(id string) (*models.InventoryItem, error)
 	GetAllInventoryItems() ([]*models.InventoryItem, error)
-	UpdateInventoryItem(item *models.InventoryItem) error
-	DeleteInventoryItem(id string) error
+	UpdateInventoryItem(item *models.InventoryItem, actor string) error
+	DeleteInventoryItem(id string, cascade bool, actor string) error
+	GetInventoryItemUsage(id string) (*models.InventoryUsage, error)
+	GetLowStockItems(locationID string) ([]models.LowStockItem, error)
+	RecordMovement(id string, movement *models.InventoryMovement) error
+	GetMovements(id string, from, to time.Time) (*models.InventoryMovementsResponse, error)
+	ReconcileLedger() error
+	RecordWaste(waste *models.WasteRecord) error
+	TransferStock(transfer *models.StockTransfer) error
+	GetExpiringLots(days int) ([]models.ExpiringLot, error)
+	ExpireLots() ([]models.ExpiringLot, error)
+}
+
+type SupplierService interface {
+	CreateSupplier(supplier *models.Supplier) error
+	GetSupplierByID(id string) (*models.Supplier, error)
+	GetAllSuppliers() ([]*models.Supplier, error)
+	UpdateSupplier(supplier *models.Supplier) error
+	DeleteSupplier(id string) error
+}
+
+type LocationService interface {
+	EnsureDefaultLocation() error
+	CreateLocation(location *models.Location) error
+	GetLocationByID(id string) (*models.Location, error)
+	GetAllLocations() ([]*models.Location, error)
+	UpdateLocation(location *models.Location) error
+	DeleteLocation(id string) error
+}
+
+type PurchaseOrderService interface {
+	CreatePurchaseOrder(order *models.PurchaseOrder) error
+	GetPurchaseOrderByID(id string) (*models.PurchaseOrder, error)
+	GetAllPurchaseOrders(status string) ([]*models.PurchaseOrder, error)
+	ReceivePurchaseOrder(id string, receipt *models.PurchaseOrderReceipt) (*models.PurchaseOrder, error)
+	CancelPurchaseOrder(id string) (*models.PurchaseOrder, error)
+	SuggestPurchaseOrders(locationID string) ([]*models.SuggestedPurchaseOrder, error)
+}
+
+type StockCountService interface {
+	StartStockCount(count *models.StockCount) error
+	GetStockCountByID(id string) (*models.StockCount, error)
+	GetAllStockCounts(status string) ([]*models.StockCount, error)
+	RecordCounts(id string, submission *models.CountSubmission) (*models.StockCount, error)
+	CommitStockCount(id, actor string) (*models.StockCount, error)
+	CancelStockCount(id string) (*models.StockCount, error)
 }
 
 type ReportsService interface {
-	GetTotalSales() (*models.TotalSalesResponse, error)
-	GetPopularItems() (*models.PopularItemsResponse, error)
+	GetTotalSales(locationID string) (*models.TotalSalesResponse, error)
+	GetPopularItems(byVariant bool, locationID string) (*models.PopularItemsResponse, error)
+	GetWasteReport(from, to time.Time, locationID string) (*models.WasteReport, error)
+	GetShrinkageReport(from, to time.Time, locationID string) (*models.ShrinkageReport, error)
+	GetMarginReport(from, to time.Time, byVariant bool, locationID string) (*models.MarginReport, error)
+	GetCategorySales(from, to time.Time, locationID string) (*models.CategorySalesReport, error)
 }
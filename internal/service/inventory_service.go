@@ -3,25 +3,79 @@ package service
 
 import (
 	"errors"
+	"fmt"
 	"log/slog"
+	"math"
+	"slices"
+	"time"
 
 	"hot-coffee/internal/repository"
 	"hot-coffee/models"
 )
 
+// ledgerTolerance absorbs floating point drift when comparing a quantity with
+// its replayed ledger.
+const ledgerTolerance = 1e-6
+
 type inventoryService struct {
 	inventoryRepo repository.InventoryRepository
+	menuRepo      repository.MenuRepository
+	orderRepo     repository.OrderRepository
+	movementRepo  repository.MovementRepository
+	locationRepo  repository.LocationRepository
+	uow           repository.UnitOfWork
+	zone          *time.Location
 }
 
-func NewInventoryService(inventoryRepo repository.InventoryRepository) InventoryService {
+// NewInventoryService returns an inventory service that reads lot expiry dates
+// in the shop's time zone.
+func NewInventoryService(inventoryRepo repository.InventoryRepository, menuRepo repository.MenuRepository, orderRepo repository.OrderRepository, movementRepo repository.MovementRepository, locationRepo repository.LocationRepository, uow repository.UnitOfWork, zone *time.Location) InventoryService {
 	return &inventoryService{
 		inventoryRepo: inventoryRepo,
+		menuRepo:      menuRepo,
+		orderRepo:     orderRepo,
+		movementRepo:  movementRepo,
+		locationRepo:  locationRepo,
+		uow:           uow,
+		zone:          zone,
 	}
 }
 
-func (s *inventoryService) CreateInventoryItem(item *models.InventoryItem) error {
-	if err := s.inventoryRepo.Create(item); err != nil {
+// CreateInventoryItem adds an ingredient, deriving its ID from the name when
+// none is given. Its quantity is recorded as the opening balance at the default
+// location.
+func (s *inventoryService) CreateInventoryItem(item *models.InventoryItem, actor string) error {
+	item.DeletedAt = ""
+	item.Stock = nil
+	item.Lots = nil
+	if item.IngredientID == "" {
+		id, err := uniqueSlug(item.Name, func(id string) (bool, error) {
+			existing, err := s.inventoryRepo.GetByID(id)
+			return existing != nil, err
+		})
+		if err != nil {
+			return err
+		}
+		item.IngredientID = id
+	}
+
+	err := s.uow.Execute([]string{inventoryLockKey(item.IngredientID)}, func(repos repository.Repositories) error {
+		if err := checkSupplierExists(repos.Suppliers, "supplier_id", item.SupplierID); err != nil {
+			return err
+		}
+		if err := repos.Inventory.Create(item); err != nil {
+			return err
+		}
+		return recordMovement(repos, item, item.Quantity, &models.InventoryMovement{
+			Reason: models.MovementReasonOpening,
+			Actor:  actor,
+		})
+	})
+	if err != nil {
 		slog.Error("Failed to create inventory item", "error", err)
+		if errors.Is(err, repository.ErrDuplicateID) {
+			return ConflictError("inventory item %s already exists", item.IngredientID)
+		}
 		return err
 	}
 
@@ -35,28 +89,96 @@ func (s *inventoryService) GetInventoryItemByID(id string) (*models.InventoryIte
 		slog.Error("Failed to get inventory item", "itemID", id, "error", err)
 		return nil, err
 	}
+	if item == nil {
+		return nil, NotFoundError("inventory item not found")
+	}
 	return item, nil
 }
 
+// GetAllInventoryItems returns the inventory without deleted ingredients.
 func (s *inventoryService) GetAllInventoryItems() ([]*models.InventoryItem, error) {
 	items, err := s.inventoryRepo.GetAll()
 	if err != nil {
 		slog.Error("Failed to get all inventory items", "error", err)
 		return nil, err
 	}
-	return items, nil
+
+	active := items[:0]
+	for _, item := range items {
+		if item.DeletedAt == "" {
+			active = append(active, item)
+		}
+	}
+	return active, nil
 }
 
-func (s *inventoryService) UpdateInventoryItem(item *models.InventoryItem) error {
-	existing, err := s.inventoryRepo.GetByID(item.IngredientID)
+// GetLowStockItems returns the ingredients at or below their reorder point at
+// a location, or at each location when locationID is empty.
+func (s *inventoryService) GetLowStockItems(locationID string) ([]models.LowStockItem, error) {
+	locations, err := locationIDs(s.locationRepo, "location", locationID)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	items, err := s.GetAllInventoryItems()
+	if err != nil {
+		return nil, err
 	}
-	if existing == nil {
-		return errors.New("inventory item not found")
+
+	lowStock := []models.LowStockItem{}
+	for _, item := range items {
+		for _, location := range locations {
+			if isLowStock(item, location) {
+				lowStock = append(lowStock, toLowStockItem(item, location))
+			}
+		}
 	}
+	return lowStock, nil
+}
+
+// UpdateInventoryItem replaces an ingredient's details. A changed quantity is
+// recorded as an adjustment at the default location. The unit cost is kept:
+// it only changes through deliveries and cost adjustment movements, which the
+// ledger records.
+func (s *inventoryService) UpdateInventoryItem(item *models.InventoryItem, actor string) error {
+	// Serialize with orders deducting the same ingredient
+	err := s.uow.Execute([]string{inventoryLockKey(item.IngredientID)}, func(repos repository.Repositories) error {
+		existing, err := repos.Inventory.GetByID(item.IngredientID)
+		if err != nil {
+			return err
+		}
+		if existing == nil || existing.DeletedAt != "" {
+			return NotFoundError("inventory item not found")
+		}
+		if err := checkUnitChange(repos.Menu, existing, item); err != nil {
+			return err
+		}
+		if err := checkSupplierExists(repos.Suppliers, "supplier_id", item.SupplierID); err != nil {
+			return err
+		}
+
+		if item.UnitCost != 0 && item.UnitCost != existing.UnitCost {
+			return FieldError("unit_cost", "the unit cost of %s changes through restocks and cost_adjustment movements", existing.IngredientID)
+		}
 
-	if err := s.inventoryRepo.Update(item); err != nil {
+		item.DeletedAt = ""
+		item.UnitCost = existing.UnitCost
+		item.Stock = existing.Stock
+		item.Lots = existing.Lots
+		delta := item.Quantity - existing.Quantity
+		if delta == 0 {
+			return repos.Inventory.Update(item)
+		}
+		if held := existing.Quantity - stockAt(existing, models.DefaultLocationID); item.Quantity < held {
+			return FieldError("quantity", "%g %s of %s is held at other locations", held, existing.Unit, existing.IngredientID)
+		}
+
+		item.Quantity = existing.Quantity
+		return adjustStock(repos, item, delta, &models.InventoryMovement{
+			Reason: models.MovementReasonAdjustment,
+			Actor:  actor,
+		}, s.zone)
+	})
+	if err != nil {
 		slog.Error("Failed to update inventory item", "itemID", item.IngredientID, "error", err)
 		return err
 	}
@@ -65,12 +187,527 @@ func (s *inventoryService) UpdateInventoryItem(item *models.InventoryItem) error
 	return nil
 }
 
-func (s *inventoryService) DeleteInventoryItem(id string) error {
-	if err := s.inventoryRepo.Delete(id); err != nil {
+// DeleteInventoryItem refuses to delete an ingredient that menu items or open
+// orders still use unless cascade is set. Cascading soft-deletes the menu items
+// whose recipes need the ingredient and removes the modifiers and substitution
+// options that offer it from the others, holding their locks. An ingredient
+// that any order used is soft-deleted so that the order history keeps
+// resolving; others are removed.
+func (s *inventoryService) DeleteInventoryItem(id string, cascade bool, actor string) error {
+	soft := false
+	var locked []string
+	err := executeRetrying(s.uow, "the menu items using "+id+" changed while it was deleted, please retry", func() ([]string, error) {
+		// Cascading updates the menu items, so their locks are held too
+		var err error
+		if locked, err = menuItemsReferringTo(s.menuRepo, id); err != nil {
+			return nil, err
+		}
+		keys := []string{inventoryLockKey(id)}
+		for _, productID := range locked {
+			keys = append(keys, menuItemLockKey(productID))
+		}
+		return keys, nil
+	}, func(repos repository.Repositories) error {
+		item, err := repos.Inventory.GetByID(id)
+		if err != nil {
+			return err
+		}
+		if item == nil || item.DeletedAt != "" {
+			return NotFoundError("inventory item not found")
+		}
+
+		menuItems, err := repos.Menu.GetAll()
+		if err != nil {
+			return err
+		}
+		orders, err := repos.Orders.GetAll()
+		if err != nil {
+			return err
+		}
+
+		usage, err := inventoryUsage(repos.Menu, repos.Inventory, id, menuItems, orders)
+		if err != nil {
+			return err
+		}
+		for _, ref := range usage.MenuItems {
+			if !slices.Contains(locked, ref.ProductID) {
+				return errLocksChanged
+			}
+		}
+		if (len(usage.MenuItems) > 0 || len(usage.OpenOrders) > 0) && !cascade {
+			return referenceConflict("ingredient "+id+" is still in use", usage.MenuItems, usage.OpenOrders)
+		}
+
+		deletedAt := time.Now().Format(time.RFC3339)
+		for _, ref := range usage.MenuItems {
+			menuItem, err := repos.Menu.GetByID(ref.ProductID)
+			if err != nil {
+				return err
+			}
+			if !recipeUses(menuItem, id) {
+				removeIngredientOptions(menuItem, id)
+				if err := repos.Menu.Update(menuItem); err != nil {
+					return err
+				}
+				slog.Info("Menu item options removed with their ingredient", "itemID", menuItem.ID, "ingredientID", id)
+				continue
+			}
+
+			menuItem.DeletedAt = deletedAt
+			if err := repos.Menu.Update(menuItem); err != nil {
+				return err
+			}
+			slog.Info("Menu item deleted with its ingredient", "itemID", menuItem.ID, "ingredientID", id)
+		}
+
+		soft = len(usage.OpenOrders) > 0 || usedByAnyOrder(orders, id)
+		if !soft {
+			// Close the ledger so a new ingredient with this ID starts from zero
+			for _, locationID := range stockLocations(item) {
+				err := adjustStock(repos, item, -stockAt(item, locationID), &models.InventoryMovement{
+					Reason:     models.MovementReasonAdjustment,
+					LocationID: locationID,
+					Actor:      actor,
+					Note:       "ingredient deleted",
+				}, s.zone)
+				if err != nil {
+					return err
+				}
+			}
+			return repos.Inventory.Delete(id)
+		}
+		item.DeletedAt = deletedAt
+		return repos.Inventory.Update(item)
+	})
+	if err != nil {
 		slog.Error("Failed to delete inventory item", "itemID", id, "error", err)
 		return err
 	}
 
-	slog.Info("Inventory item deleted", "itemID", id)
+	slog.Info("Inventory item deleted", "itemID", id, "soft", soft)
+	return nil
+}
+
+// RecordMovement applies a manual stock change such as a delivery or waste to
+// an ingredient at the movement's location. A restock forms a new lot, and
+// the price it gives as unit_cost is averaged into the ingredient's unit cost;
+// stock taken out comes from the lot the movement names, or oldest first. A
+// cost adjustment replaces the unit cost and leaves the stock alone. On
+// success movement holds the recorded movement.
+func (s *inventoryService) RecordMovement(id string, movement *models.InventoryMovement) error {
+	movement.LocationID = locationOrDefault(movement.LocationID)
+	err := s.uow.Execute([]string{inventoryLockKey(id)}, func(repos repository.Repositories) error {
+		item, err := repos.Inventory.GetByID(id)
+		if err != nil {
+			return err
+		}
+		if item == nil || item.DeletedAt != "" {
+			return NotFoundError("inventory item not found")
+		}
+		if err := checkLocationExists(repos.Locations, "location_id", movement.LocationID); err != nil {
+			return err
+		}
+
+		if available := stockAt(item, movement.LocationID); available+movement.Delta < 0 {
+			return InsufficientStockError([]models.ErrorDetail{shortage(id, item.Unit, -movement.Delta, available)})
+		}
+
+		switch movement.Reason {
+		case models.MovementReasonRestock:
+			if movement.UnitCost > 0 {
+				averageInCost(item, movement.Delta, movement.UnitCost)
+			}
+			return receiveStock(repos, item, movement.Delta, movement.ExpiresAt, movement)
+		case models.MovementReasonCostAdjustment:
+			if movement.Note == "" {
+				movement.Note = fmt.Sprintf("unit cost was %g", item.UnitCost)
+			}
+			item.UnitCost = movement.UnitCost
+			if err := repos.Inventory.Update(item); err != nil {
+				return err
+			}
+			return recordMovement(repos, item, 0, movement)
+		}
+		return adjustStock(repos, item, movement.Delta, movement, s.zone)
+	})
+	if err != nil {
+		slog.Error("Failed to record inventory movement", "itemID", id, "error", err)
+		return err
+	}
+
+	slog.Info("Inventory movement recorded", "itemID", id, "reason", movement.Reason, "delta", movement.Delta)
+	return nil
+}
+
+// RecordWaste deducts stock thrown away at a location, either an ingredient or
+// the recipe ingredients of finished menu items, as waste movements. On success
+// waste holds the movements and their cost.
+func (s *inventoryService) RecordWaste(waste *models.WasteRecord) error {
+	waste.LocationID = locationOrDefault(waste.LocationID)
+	var locked map[string]float64
+	var movements []models.InventoryMovement
+	err := executeRetrying(s.uow, "the products wasted changed while the waste was recorded, please retry", func() ([]string, error) {
+		var err error
+		if locked, err = wastedIngredients(s.menuRepo, s.inventoryRepo, waste); err != nil {
+			return nil, err
+		}
+		return append(inventoryLockKeys(locked), menuItemLockKeys(waste.Items)...), nil
+	}, func(repos repository.Repositories) error {
+		movements = nil
+		if err := checkLocationExists(repos.Locations, "location_id", waste.LocationID); err != nil {
+			return err
+		}
+		wasted, err := wastedIngredients(repos.Menu, repos.Inventory, waste)
+		if err != nil {
+			return err
+		}
+		if !coversIngredients(locked, wasted) {
+			return errLocksChanged
+		}
+
+		ingredientIDs := sortedIngredientIDs(wasted)
+		items := make([]*models.InventoryItem, len(ingredientIDs))
+		var shortages []models.ErrorDetail
+		for i, id := range ingredientIDs {
+			item, err := repos.Inventory.GetByID(id)
+			if err != nil {
+				return err
+			}
+			if item == nil || item.DeletedAt != "" {
+				return ConflictError("ingredient not found in inventory: %s", id)
+			}
+			if available := stockAt(item, waste.LocationID); available < wasted[id] {
+				shortages = append(shortages, shortage(id, item.Unit, wasted[id], available))
+			}
+			items[i] = item
+		}
+		if len(shortages) > 0 {
+			return InsufficientStockError(shortages)
+		}
+
+		for _, item := range items {
+			movement := &models.InventoryMovement{
+				Reason:      models.MovementReasonWaste,
+				WasteReason: waste.Reason,
+				LotID:       waste.LotID,
+				LocationID:  waste.LocationID,
+				Actor:       waste.Actor,
+				Note:        waste.Note,
+			}
+			if err := adjustStock(repos, item, -wasted[item.IngredientID], movement, s.zone); err != nil {
+				return err
+			}
+			movements = append(movements, *movement)
+		}
+		return nil
+	})
+	if err != nil {
+		slog.Error("Failed to record waste", "error", err)
+		return err
+	}
+
+	waste.Movements = movements
+	waste.Cost = 0
+	for _, movement := range movements {
+		waste.Cost += -movement.Delta * movement.UnitCost
+	}
+	waste.Cost = roundMoney(waste.Cost)
+
+	slog.Info("Waste recorded", "reason", waste.Reason, "ingredients", len(movements), "cost", waste.Cost)
+	return nil
+}
+
+// wastedIngredients returns the stock a waste record takes out: the recipe
+// ingredients of its menu items, or its ingredient in the unit it is stocked
+// in.
+func wastedIngredients(menuRepo repository.MenuRepository, inventoryRepo repository.InventoryRepository, waste *models.WasteRecord) (map[string]float64, error) {
+	if len(waste.Items) > 0 {
+		return calculateRequiredIngredients(menuRepo, inventoryRepo, waste.Items, false)
+	}
+
+	item, err := inventoryRepo.GetByID(waste.IngredientID)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil || item.DeletedAt != "" {
+		return nil, NotFoundError("inventory item not found")
+	}
+	quantity := waste.Quantity
+	if waste.Unit != "" {
+		if quantity, err = unitConverter(item).Convert(waste.Quantity, waste.Unit, item.Unit); err != nil {
+			return nil, FieldError("unit", "unit %s does not convert to %s, the unit %s is stocked in", waste.Unit, item.Unit, item.IngredientID)
+		}
+	}
+	return map[string]float64{item.IngredientID: quantity}, nil
+}
+
+// TransferStock moves stock of an ingredient from one location to another as
+// one unit, recording a transfer movement at each. On success transfer holds
+// both movements.
+func (s *inventoryService) TransferStock(transfer *models.StockTransfer) error {
+	transfer.ID = generateID()
+	transfer.Movements = nil
+	err := s.uow.Execute([]string{inventoryLockKey(transfer.IngredientID)}, func(repos repository.Repositories) error {
+		transfer.Movements = nil
+		item, err := repos.Inventory.GetByID(transfer.IngredientID)
+		if err != nil {
+			return err
+		}
+		if item == nil || item.DeletedAt != "" {
+			return NotFoundError("inventory item not found")
+		}
+		if err := checkLocationExists(repos.Locations, "from_location_id", transfer.FromLocationID); err != nil {
+			return err
+		}
+		if err := checkLocationExists(repos.Locations, "to_location_id", transfer.ToLocationID); err != nil {
+			return err
+		}
+
+		quantity := transfer.Quantity
+		if transfer.Unit != "" {
+			if quantity, err = unitConverter(item).Convert(transfer.Quantity, transfer.Unit, item.Unit); err != nil {
+				return FieldError("unit", "unit %s does not convert to %s, the unit %s is stocked in", transfer.Unit, item.Unit, item.IngredientID)
+			}
+		}
+		if available := stockAt(item, transfer.FromLocationID); available < quantity {
+			return InsufficientStockError([]models.ErrorDetail{shortage(item.IngredientID, item.Unit, quantity, available)})
+		}
+		return transferStock(repos, item, quantity, transfer, s.zone)
+	})
+	if err != nil {
+		slog.Error("Failed to transfer stock", "itemID", transfer.IngredientID, "error", err)
+		return err
+	}
+
+	slog.Info("Stock transferred", "itemID", transfer.IngredientID, "from", transfer.FromLocationID, "to", transfer.ToLocationID, "quantity", transfer.Quantity)
+	return nil
+}
+
+// GetExpiringLots returns the lots that expire within the given number of days
+// or have expired already, soonest first.
+func (s *inventoryService) GetExpiringLots(days int) ([]models.ExpiringLot, error) {
+	items, err := s.GetAllInventoryItems()
+	if err != nil {
+		return nil, err
+	}
+
+	horizon := time.Now().AddDate(0, 0, days)
+	type expiringLot struct {
+		lot    models.ExpiringLot
+		expiry time.Time
+	}
+	var expiring []expiringLot
+	for _, item := range items {
+		for _, lot := range item.Lots {
+			if expiry, ok := lotExpiry(&lot, s.zone); ok && expiry.Before(horizon) {
+				expiring = append(expiring, expiringLot{lot: toExpiringLot(item, lot), expiry: expiry})
+			}
+		}
+	}
+	slices.SortStableFunc(expiring, func(a, b expiringLot) int {
+		return a.expiry.Compare(b.expiry)
+	})
+
+	result := make([]models.ExpiringLot, len(expiring))
+	for i, lot := range expiring {
+		result[i] = lot.lot
+	}
+	return result, nil
+}
+
+// ExpireLots moves the remaining stock of every expired lot to waste, so that
+// quantities only count usable stock. It returns the lots it wasted.
+func (s *inventoryService) ExpireLots() ([]models.ExpiringLot, error) {
+	items, err := s.GetAllInventoryItems()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().In(s.zone)
+	expired := []models.ExpiringLot{}
+	for _, item := range items {
+		if !slices.ContainsFunc(item.Lots, func(lot models.StockLot) bool { return isLotExpired(&lot, now) }) {
+			continue
+		}
+
+		var wasted []models.ExpiringLot
+		err := s.uow.Execute([]string{inventoryLockKey(item.IngredientID)}, func(repos repository.Repositories) error {
+			wasted = nil
+			current, err := repos.Inventory.GetByID(item.IngredientID)
+			if err != nil || current == nil {
+				return err
+			}
+			for _, lot := range slices.Clone(current.Lots) {
+				if !isLotExpired(&lot, now) {
+					continue
+				}
+				err := adjustStock(repos, current, -lot.Quantity, &models.InventoryMovement{
+					Reason:      models.MovementReasonWaste,
+					WasteReason: models.WasteReasonExpired,
+					LotID:       lot.ID,
+					LocationID:  lotLocation(&lot),
+					ExpiresAt:   lot.ExpiresAt,
+					Note:        "lot expired",
+				}, s.zone)
+				if err != nil {
+					return err
+				}
+				wasted = append(wasted, toExpiringLot(current, lot))
+			}
+			return nil
+		})
+		if err != nil {
+			slog.Error("Failed to expire lots", "itemID", item.IngredientID, "error", err)
+			return nil, err
+		}
+
+		for _, lot := range wasted {
+			slog.Info("Expired lot moved to waste", "itemID", lot.IngredientID, "lotID", lot.ID, "quantity", lot.Quantity)
+		}
+		expired = append(expired, wasted...)
+	}
+	return expired, nil
+}
+
+// GetMovements returns an ingredient's movements created within [from, to].
+// A zero from or to leaves that end of the range open.
+func (s *inventoryService) GetMovements(id string, from, to time.Time) (*models.InventoryMovementsResponse, error) {
+	item, err := s.GetInventoryItemByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	movements, err := s.movementRepo.GetByIngredient(id)
+	if err != nil {
+		slog.Error("Failed to get inventory movements", "itemID", id, "error", err)
+		return nil, err
+	}
+
+	response := &models.InventoryMovementsResponse{
+		IngredientID:   id,
+		Quantity:       item.Quantity,
+		LedgerQuantity: replayMovements(movements),
+		Movements:      []models.InventoryMovement{},
+	}
+	for _, movement := range movements {
+		createdAt, err := time.Parse(time.RFC3339, movement.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if (!from.IsZero() && createdAt.Before(from)) || (!to.IsZero() && createdAt.After(to)) {
+			continue
+		}
+		response.Movements = append(response.Movements, *movement)
+	}
+	return response, nil
+}
+
+// ReconcileLedger makes the ledger account for every ingredient's current
+// quantity. Ingredients without movements, as in data from before the ledger
+// existed, get an opening balance. A quantity that differs from the replayed
+// ledger, as after editing a data file by hand, is only logged, so that a
+// person decides which of the two is right.
+func (s *inventoryService) ReconcileLedger() error {
+	items, err := s.inventoryRepo.GetAll()
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		err := s.uow.Execute([]string{inventoryLockKey(item.IngredientID)}, func(repos repository.Repositories) error {
+			current, err := repos.Inventory.GetByID(item.IngredientID)
+			if err != nil || current == nil {
+				return err
+			}
+			movements, err := repos.Movements.GetByIngredient(current.IngredientID)
+			if err != nil {
+				return err
+			}
+
+			if len(movements) == 0 {
+				return recordMovement(repos, current, current.Quantity, &models.InventoryMovement{
+					Reason: models.MovementReasonOpening,
+				})
+			}
+
+			ledgerQuantity := replayMovements(movements)
+			if math.Abs(current.Quantity-ledgerQuantity) > ledgerTolerance {
+				slog.Warn("Inventory quantity differs from its ledger",
+					"itemID", current.IngredientID, "quantity", current.Quantity, "ledgerQuantity", ledgerQuantity)
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("reconcile %s: %w", item.IngredientID, err)
+		}
+	}
 	return nil
 }
+
+// GetInventoryItemUsage lists the menu items and open orders that depend on an
+// ingredient.
+func (s *inventoryService) GetInventoryItemUsage(id string) (*models.InventoryUsage, error) {
+	if _, err := s.GetInventoryItemByID(id); err != nil {
+		return nil, err
+	}
+
+	menuItems, err := s.menuRepo.GetAll()
+	if err != nil {
+		slog.Error("Failed to get menu items for inventory usage", "itemID", id, "error", err)
+		return nil, err
+	}
+	orders, err := s.orderRepo.GetAll()
+	if err != nil {
+		slog.Error("Failed to get orders for inventory usage", "itemID", id, "error", err)
+		return nil, err
+	}
+
+	return inventoryUsage(s.menuRepo, s.inventoryRepo, id, menuItems, orders)
+}
+
+// inventoryUsage finds the menu items that are not deleted and the open orders
+// that use an ingredient.
+func inventoryUsage(menuRepo repository.MenuRepository, inventoryRepo repository.InventoryRepository, id string, menuItems []*models.MenuItem, orders []*models.Order) (*models.InventoryUsage, error) {
+	usage := &models.InventoryUsage{
+		IngredientID: id,
+		MenuItems:    []models.MenuItemReference{},
+		OpenOrders:   []models.OrderReference{},
+	}
+
+	for _, item := range menuItems {
+		if item.DeletedAt != "" {
+			continue
+		}
+		if ref := menuItemReferenceTo(item, id); ref != nil {
+			usage.MenuItems = append(usage.MenuItems, *ref)
+		}
+	}
+
+	for _, order := range orders {
+		if !isActiveOrder(order) {
+			continue
+		}
+		used, err := ingredientsUsedBy(menuRepo, inventoryRepo, order)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := used[id]; ok {
+			usage.OpenOrders = append(usage.OpenOrders, toOrderReference(order))
+		}
+	}
+
+	return usage, nil
+}
+
+// usedByAnyOrder reports whether an ingredient was recorded as deducted by any
+// order.
+func usedByAnyOrder(orders []*models.Order, id string) bool {
+	for _, order := range orders {
+		for _, ingredient := range order.IngredientsUsed {
+			if ingredient.IngredientID == id {
+				return true
+			}
+		}
+	}
+	return false
+}
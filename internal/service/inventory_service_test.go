@@ -0,0 +1,107 @@
+// internal/service/inventory_service_test.go
+package service
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"hot-coffee/internal/repository"
+	"hot-coffee/models"
+)
+
+func TestReconcileLedger(t *testing.T) {
+	tests := []struct {
+		name      string
+		quantity  float64
+		ledger    []float64
+		wantAdded []models.InventoryMovement
+		// drifted is set when the quantity is left not matching the ledger
+		drifted bool
+	}{
+		{
+			name:      "stock from before the ledger gets an opening balance",
+			quantity:  500,
+			wantAdded: []models.InventoryMovement{{Reason: models.MovementReasonOpening, Delta: 500, QuantityAfter: 500}},
+		},
+		{
+			name:     "a ledger that adds up is left alone",
+			quantity: 420,
+			ledger:   []float64{500, -30, -50, 0},
+		},
+		{
+			name:     "rounding drift is tolerated",
+			quantity: 0.3,
+			ledger:   []float64{0.1, 0.2},
+		},
+		{
+			name:     "a quantity edited by hand is only reported",
+			quantity: 380,
+			ledger:   []float64{500, -100},
+			drifted:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			repos := repository.Repositories{
+				Inventory: repository.NewInventoryRepository(dir),
+				Movements: repository.NewMovementRepository(dir),
+			}
+			err := repos.Inventory.Create(&models.InventoryItem{
+				IngredientID: "milk",
+				Name:         "Milk",
+				Quantity:     tt.quantity,
+				Unit:         "ml",
+				Stock:        []models.LocationStock{{LocationID: models.DefaultLocationID, Quantity: tt.quantity}},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, delta := range tt.ledger {
+				err := repos.Movements.Append(&models.InventoryMovement{
+					ID:           fmt.Sprintf("m%d", i),
+					IngredientID: "milk",
+					Delta:        delta,
+					Reason:       models.MovementReasonRestock,
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			s := NewInventoryService(repos.Inventory, nil, nil, repos.Movements, nil, repository.NewUnitOfWork(repos), time.UTC)
+			// Reconciling twice must not add anything the second time
+			for range 2 {
+				if err := s.ReconcileLedger(); err != nil {
+					t.Fatalf("ReconcileLedger() error = %v", err)
+				}
+			}
+
+			movements, err := repos.Movements.GetByIngredient("milk")
+			if err != nil {
+				t.Fatal(err)
+			}
+			added := movements[len(tt.ledger):]
+			if len(added) != len(tt.wantAdded) {
+				t.Fatalf("ReconcileLedger() added %d movements, want %d", len(added), len(tt.wantAdded))
+			}
+			for i, want := range tt.wantAdded {
+				got := added[i]
+				if got.Reason != want.Reason || got.Delta != want.Delta || got.QuantityAfter != want.QuantityAfter {
+					t.Errorf("movement %d = %s %g to %g, want %s %g to %g",
+						i, got.Reason, got.Delta, got.QuantityAfter, want.Reason, want.Delta, want.QuantityAfter)
+				}
+			}
+			if matches := math.Abs(replayMovements(movements)-tt.quantity) <= ledgerTolerance; matches == tt.drifted {
+				t.Errorf("ledger replays to %g with a stored quantity of %g, want them to match: %t",
+					replayMovements(movements), tt.quantity, !tt.drifted)
+			}
+			if got := quantityOf(t, repos, "milk"); got != tt.quantity {
+				t.Errorf("quantity = %g, want %g left alone", got, tt.quantity)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+// internal/service/location_service.go
+package service
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+
+	"hot-coffee/internal/repository"
+	"hot-coffee/models"
+)
+
+type locationService struct {
+	locationRepo      repository.LocationRepository
+	inventoryRepo     repository.InventoryRepository
+	purchaseOrderRepo repository.PurchaseOrderRepository
+	stockCountRepo    repository.StockCountRepository
+}
+
+func NewLocationService(locationRepo repository.LocationRepository, inventoryRepo repository.InventoryRepository, purchaseOrderRepo repository.PurchaseOrderRepository, stockCountRepo repository.StockCountRepository) LocationService {
+	return &locationService{
+		locationRepo:      locationRepo,
+		inventoryRepo:     inventoryRepo,
+		purchaseOrderRepo: purchaseOrderRepo,
+		stockCountRepo:    stockCountRepo,
+	}
+}
+
+// EnsureDefaultLocation creates the default location if it does not exist, as
+// in data from before locations existed.
+func (s *locationService) EnsureDefaultLocation() error {
+	existing, err := s.locationRepo.GetByID(models.DefaultLocationID)
+	if err != nil || existing != nil {
+		return err
+	}
+
+	location := &models.Location{ID: models.DefaultLocationID, Name: "Main"}
+	if err := s.locationRepo.Create(location); err != nil {
+		return err
+	}
+	slog.Info("Default location created", "locationID", location.ID)
+	return nil
+}
+
+// CreateLocation adds a location, deriving its ID from the name when none is given.
+func (s *locationService) CreateLocation(location *models.Location) error {
+	location.DeletedAt = ""
+	if location.ID == "" {
+		id, err := uniqueSlug(location.Name, func(id string) (bool, error) {
+			existing, err := s.locationRepo.GetByID(id)
+			return existing != nil, err
+		})
+		if err != nil {
+			return err
+		}
+		location.ID = id
+	}
+
+	if err := s.locationRepo.Create(location); err != nil {
+		slog.Error("Failed to create location", "error", err)
+		if errors.Is(err, repository.ErrDuplicateID) {
+			return ConflictError("location %s already exists", location.ID)
+		}
+		return err
+	}
+
+	slog.Info("Location created", "locationID", location.ID, "name", location.Name)
+	return nil
+}
+
+func (s *locationService) GetLocationByID(id string) (*models.Location, error) {
+	location, err := s.locationRepo.GetByID(id)
+	if err != nil {
+		slog.Error("Failed to get location", "locationID", id, "error", err)
+		return nil, err
+	}
+	if location == nil {
+		return nil, NotFoundError("location not found")
+	}
+	return location, nil
+}
+
+// GetAllLocations returns the locations that are not deleted.
+func (s *locationService) GetAllLocations() ([]*models.Location, error) {
+	locations, err := s.locationRepo.GetAll()
+	if err != nil {
+		slog.Error("Failed to get all locations", "error", err)
+		return nil, err
+	}
+
+	active := locations[:0]
+	for _, location := range locations {
+		if location.DeletedAt == "" {
+			active = append(active, location)
+		}
+	}
+	return active, nil
+}
+
+func (s *locationService) UpdateLocation(location *models.Location) error {
+	existing, err := s.locationRepo.GetByID(location.ID)
+	if err != nil {
+		return err
+	}
+	if existing == nil || existing.DeletedAt != "" {
+		return NotFoundError("location not found")
+	}
+
+	location.DeletedAt = ""
+	if err := s.locationRepo.Update(location); err != nil {
+		slog.Error("Failed to update location", "locationID", location.ID, "error", err)
+		if errors.Is(err, repository.ErrNotFound) {
+			return NotFoundError("location not found")
+		}
+		return err
+	}
+
+	slog.Info("Location updated", "locationID", location.ID, "name", location.Name)
+	return nil
+}
+
+// DeleteLocation refuses to delete the default location or a location that
+// still holds stock, awaits deliveries or is being counted. Locations are
+// soft-deleted so that the orders and movements there keep resolving.
+func (s *locationService) DeleteLocation(id string) error {
+	location, err := s.locationRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if location == nil || location.DeletedAt != "" {
+		return NotFoundError("location not found")
+	}
+	if id == models.DefaultLocationID {
+		return ConflictError("the default location cannot be deleted")
+	}
+
+	items, err := s.inventoryRepo.GetAll()
+	if err != nil {
+		return err
+	}
+	purchaseOrders, err := s.purchaseOrderRepo.GetAll()
+	if err != nil {
+		return err
+	}
+	counts, err := s.stockCountRepo.GetAll()
+	if err != nil {
+		return err
+	}
+
+	var details []models.ErrorDetail
+	for _, item := range items {
+		if quantity := stockAt(item, id); quantity > ledgerTolerance && item.DeletedAt == "" {
+			details = append(details, models.ErrorDetail{
+				Field:        "inventory_item",
+				Message:      "holds stock of " + item.IngredientID,
+				IngredientID: item.IngredientID,
+				Available:    &quantity,
+				Unit:         item.Unit,
+			})
+		}
+	}
+	for _, order := range purchaseOrders {
+		if locationOrDefault(order.LocationID) == id && isOutstandingPurchaseOrder(order) {
+			details = append(details, models.ErrorDetail{
+				Field:   "purchase_order",
+				Message: "awaits delivery of purchase order " + order.ID,
+			})
+		}
+	}
+	for _, count := range counts {
+		if count.LocationID == id && count.Status == models.StockCountStatusOpen {
+			details = append(details, models.ErrorDetail{
+				Field:   "stock_count",
+				Message: "is being counted in stock count " + count.ID,
+			})
+		}
+	}
+	if len(details) > 0 {
+		return &Error{Kind: KindConflict, Message: "location " + id + " is still in use", Details: details}
+	}
+
+	location.DeletedAt = time.Now().Format(time.RFC3339)
+	if err := s.locationRepo.Update(location); err != nil {
+		slog.Error("Failed to delete location", "locationID", id, "error", err)
+		return err
+	}
+
+	slog.Info("Location deleted", "locationID", id)
+	return nil
+}
@@ -0,0 +1,166 @@
+// internal/service/locations.go
+package service
+
+import (
+	"math"
+	"time"
+
+	"hot-coffee/internal/repository"
+	"hot-coffee/models"
+)
+
+// locationOrDefault returns the location a record refers to, the default
+// location for records without one.
+func locationOrDefault(locationID string) string {
+	if locationID == "" {
+		return models.DefaultLocationID
+	}
+	return locationID
+}
+
+// stockAt returns the quantity of an ingredient held at a location.
+func stockAt(item *models.InventoryItem, locationID string) float64 {
+	if len(item.Stock) == 0 {
+		if locationID == models.DefaultLocationID {
+			return item.Quantity
+		}
+		return 0
+	}
+	for _, stock := range item.Stock {
+		if stock.LocationID == locationID {
+			return stock.Quantity
+		}
+	}
+	return 0
+}
+
+// addStockAt changes the quantity held at a location and the total with it.
+// The breakdown by location is only kept while stock is held away from the
+// default location.
+func addStockAt(item *models.InventoryItem, locationID string, delta float64) {
+	item.Quantity += delta
+	if len(item.Stock) == 0 {
+		if locationID == models.DefaultLocationID {
+			return
+		}
+		item.Stock = []models.LocationStock{{LocationID: models.DefaultLocationID, Quantity: item.Quantity - delta}}
+	}
+
+	found := false
+	for i := range item.Stock {
+		if item.Stock[i].LocationID == locationID {
+			item.Stock[i].Quantity += delta
+			found = true
+		}
+	}
+	if !found {
+		item.Stock = append(item.Stock, models.LocationStock{LocationID: locationID, Quantity: delta})
+	}
+
+	stock := item.Stock[:0]
+	for _, s := range item.Stock {
+		if math.Abs(s.Quantity) > ledgerTolerance {
+			stock = append(stock, s)
+		}
+	}
+	if len(stock) == 0 || (len(stock) == 1 && stock[0].LocationID == models.DefaultLocationID) {
+		stock = nil
+	}
+	item.Stock = stock
+}
+
+// stockLocations returns the locations holding stock of an ingredient.
+func stockLocations(item *models.InventoryItem) []string {
+	if len(item.Stock) == 0 {
+		if item.Quantity == 0 {
+			return nil
+		}
+		return []string{models.DefaultLocationID}
+	}
+	locations := make([]string, 0, len(item.Stock))
+	for _, stock := range item.Stock {
+		locations = append(locations, stock.LocationID)
+	}
+	return locations
+}
+
+// transferStock moves quantity of an ingredient between locations and records
+// a transfer movement at each. The lots drawn at the source move along with
+// their expiry. It must run inside a unit of work holding the ingredient's lock.
+func transferStock(repos repository.Repositories, item *models.InventoryItem, quantity float64, transfer *models.StockTransfer, zone *time.Location) error {
+	drawn, err := drawFromLots(item, quantity, "", transfer.FromLocationID, zone)
+	if err != nil {
+		return err
+	}
+	for _, lot := range drawn {
+		// A lot moved whole keeps its ID
+		if findLot(item, lot.ID) != nil {
+			lot.ID = generateID()
+		}
+		lot.LocationID = transfer.ToLocationID
+		item.Lots = append(item.Lots, lot)
+	}
+	addStockAt(item, transfer.FromLocationID, -quantity)
+	addStockAt(item, transfer.ToLocationID, quantity)
+	if err := repos.Inventory.Update(item); err != nil {
+		return err
+	}
+
+	for _, side := range []struct {
+		locationID string
+		delta      float64
+	}{
+		{transfer.FromLocationID, -quantity},
+		{transfer.ToLocationID, quantity},
+	} {
+		movement := &models.InventoryMovement{
+			Reason:     models.MovementReasonTransfer,
+			LocationID: side.locationID,
+			TransferID: transfer.ID,
+			Actor:      transfer.Actor,
+			Note:       transfer.Note,
+		}
+		if err := recordMovement(repos, item, side.delta, movement); err != nil {
+			return err
+		}
+		transfer.Movements = append(transfer.Movements, *movement)
+	}
+	return nil
+}
+
+// locationIDs returns the IDs of the locations that are not deleted, or only
+// locationID once it is known to exist. field names the request field the
+// location was given in.
+func locationIDs(locationRepo repository.LocationRepository, field, locationID string) ([]string, error) {
+	if locationID != "" {
+		if err := checkLocationExists(locationRepo, field, locationID); err != nil {
+			return nil, err
+		}
+		return []string{locationID}, nil
+	}
+
+	locations, err := locationRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(locations))
+	for _, location := range locations {
+		if location.DeletedAt == "" {
+			ids = append(ids, location.ID)
+		}
+	}
+	return ids, nil
+}
+
+// checkLocationExists rejects a reference to a location that does not exist
+// or is deleted.
+func checkLocationExists(locationRepo repository.LocationRepository, field, id string) error {
+	location, err := locationRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if location == nil || location.DeletedAt != "" {
+		return FieldError(field, "unknown location: %s", id)
+	}
+	return nil
+}
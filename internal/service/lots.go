@@ -0,0 +1,181 @@
+// internal/service/lots.go
+package service
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"hot-coffee/internal/repository"
+	"hot-coffee/models"
+)
+
+// receiveStock adds a delivery to an ingredient as a new lot at the movement's
+// location and records it as a movement. expiresAt may be empty for stock that
+// does not expire.
+func receiveStock(repos repository.Repositories, item *models.InventoryItem, quantity float64, expiresAt string, movement *models.InventoryMovement) error {
+	movement.LocationID = locationOrDefault(movement.LocationID)
+	lot := models.StockLot{
+		ID:              generateID(),
+		Quantity:        quantity,
+		ReceivedAt:      time.Now().Format(time.RFC3339),
+		ExpiresAt:       expiresAt,
+		PurchaseOrderID: movement.PurchaseOrderID,
+		LocationID:      movement.LocationID,
+	}
+	item.Lots = append(item.Lots, lot)
+	movement.LotID = lot.ID
+	movement.ExpiresAt = expiresAt
+	// Adding stock draws on no lot, so no expiry is read
+	return adjustStock(repos, item, quantity, movement, nil)
+}
+
+// drawFromLots takes quantity out of an ingredient's lots at a location, from
+// the lot named by lotID if given. Otherwise stock held before lots were
+// tracked goes first, then unexpired lots oldest first, and expired lots last.
+// It returns the part of each lot taken. Expiry dates are read in zone, the
+// shop's time zone.
+func drawFromLots(item *models.InventoryItem, quantity float64, lotID, locationID string, zone *time.Location) ([]models.StockLot, error) {
+	if lotID != "" {
+		lot := findLot(item, lotID)
+		if lot == nil {
+			return nil, FieldError("lot_id", "%s has no lot %s", item.IngredientID, lotID)
+		}
+		if lotLocation(lot) != locationID {
+			return nil, FieldError("lot_id", "lot %s is held at %s", lotID, lotLocation(lot))
+		}
+		if quantity > lot.Quantity+ledgerTolerance {
+			return nil, FieldError("delta", "lot %s only holds %g %s", lotID, lot.Quantity, item.Unit)
+		}
+		drawn := *lot
+		drawn.Quantity = min(quantity, lot.Quantity)
+		lot.Quantity -= quantity
+		removeEmptyLots(item)
+		return []models.StockLot{drawn}, nil
+	}
+
+	var drawn []models.StockLot
+	quantity -= untrackedQuantity(item, locationID)
+	now := time.Now().In(zone)
+	oldestFirst := lotsOldestFirst(item)
+	for _, expired := range []bool{false, true} {
+		for _, i := range oldestFirst {
+			lot := &item.Lots[i]
+			if quantity <= 0 {
+				break
+			}
+			if lotLocation(lot) != locationID || isLotExpired(lot, now) != expired {
+				continue
+			}
+			taken := min(quantity, lot.Quantity)
+			lot.Quantity -= taken
+			quantity -= taken
+			part := *lot
+			part.Quantity = taken
+			drawn = append(drawn, part)
+		}
+	}
+	removeEmptyLots(item)
+	return drawn, nil
+}
+
+// lotsOldestFirst returns the indexes of an ingredient's lots ordered by when
+// they were received, then by ID. The order of item.Lots cannot be relied on,
+// as a transfer moves a lot to the end of it.
+func lotsOldestFirst(item *models.InventoryItem) []int {
+	indexes := make([]int, len(item.Lots))
+	receivedAt := make([]time.Time, len(item.Lots))
+	for i := range item.Lots {
+		indexes[i] = i
+		// Lots from before receipts were dated come first
+		receivedAt[i], _ = time.Parse(time.RFC3339, item.Lots[i].ReceivedAt)
+	}
+	slices.SortFunc(indexes, func(a, b int) int {
+		if c := receivedAt[a].Compare(receivedAt[b]); c != 0 {
+			return c
+		}
+		return cmp.Compare(item.Lots[a].ID, item.Lots[b].ID)
+	})
+	return indexes
+}
+
+// untrackedQuantity returns the stock at a location that belongs to no lot.
+func untrackedQuantity(item *models.InventoryItem, locationID string) float64 {
+	untracked := stockAt(item, locationID)
+	for i := range item.Lots {
+		if lotLocation(&item.Lots[i]) == locationID {
+			untracked -= item.Lots[i].Quantity
+		}
+	}
+	return max(untracked, 0)
+}
+
+// usableQuantity returns the stock of an ingredient at a location outside the
+// lots expired at now, given in the shop's time zone.
+func usableQuantity(item *models.InventoryItem, locationID string, now time.Time) float64 {
+	usable := stockAt(item, locationID)
+	for i := range item.Lots {
+		if lotLocation(&item.Lots[i]) == locationID && isLotExpired(&item.Lots[i], now) {
+			usable -= item.Lots[i].Quantity
+		}
+	}
+	return max(usable, 0)
+}
+
+func lotLocation(lot *models.StockLot) string {
+	return locationOrDefault(lot.LocationID)
+}
+
+// lotExpiry returns when a lot stops being usable: the given instant, or the
+// end of the given day in zone. ok is false for lots that do not expire.
+func lotExpiry(lot *models.StockLot, zone *time.Location) (expiry time.Time, ok bool) {
+	if lot.ExpiresAt == "" {
+		return time.Time{}, false
+	}
+	if expiry, err := time.Parse(time.RFC3339, lot.ExpiresAt); err == nil {
+		return expiry, true
+	}
+	day, err := time.ParseInLocation(time.DateOnly, lot.ExpiresAt, zone)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return day.AddDate(0, 0, 1), true
+}
+
+// isLotExpired reports whether a lot has expired at now, whose time zone
+// expiry dates are read in.
+func isLotExpired(lot *models.StockLot, now time.Time) bool {
+	expiry, ok := lotExpiry(lot, now.Location())
+	return ok && !now.Before(expiry)
+}
+
+func findLot(item *models.InventoryItem, lotID string) *models.StockLot {
+	for i := range item.Lots {
+		if item.Lots[i].ID == lotID {
+			return &item.Lots[i]
+		}
+	}
+	return nil
+}
+
+func removeEmptyLots(item *models.InventoryItem) {
+	lots := item.Lots[:0]
+	for _, lot := range item.Lots {
+		if lot.Quantity > ledgerTolerance {
+			lots = append(lots, lot)
+		}
+	}
+	if len(lots) == 0 {
+		lots = nil
+	}
+	item.Lots = lots
+}
+
+func toExpiringLot(item *models.InventoryItem, lot models.StockLot) models.ExpiringLot {
+	return models.ExpiringLot{
+		IngredientID: item.IngredientID,
+		Name:         item.Name,
+		Unit:         item.Unit,
+		StockLot:     lot,
+	}
+}
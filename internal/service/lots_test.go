@@ -0,0 +1,159 @@
+// internal/service/lots_test.go
+package service
+
+import (
+	"errors"
+	"maps"
+	"testing"
+	"time"
+	// The time zones tested need not be installed
+	_ "time/tzdata"
+
+	"hot-coffee/models"
+)
+
+func TestDrawFromLots(t *testing.T) {
+	tests := []struct {
+		name        string
+		quantity    float64
+		lotID       string
+		locationID  string
+		transfer    bool
+		wantDrawn   []models.StockLot
+		wantLots    map[string]float64
+		wantErrorOn string
+	}{
+		{
+			name:       "lots in the order they were received, wherever they are listed",
+			quantity:   75,
+			locationID: "main",
+			transfer:   true,
+			wantDrawn:  []models.StockLot{{ID: "moved", Quantity: 10}, {ID: "fresh", Quantity: 30}, {ID: "undated", Quantity: 25}},
+			wantLots:   map[string]float64{"expired": 20, "undated": 15, "bar": 50},
+		},
+		{
+			name:       "untracked stock first, then the oldest unexpired lots",
+			quantity:   45,
+			locationID: "main",
+			wantDrawn:  []models.StockLot{{ID: "fresh", Quantity: 30}, {ID: "undated", Quantity: 5}},
+			wantLots:   map[string]float64{"expired": 20, "undated": 35, "bar": 50},
+		},
+		{
+			name:       "expired lots last",
+			quantity:   95,
+			locationID: "main",
+			wantDrawn:  []models.StockLot{{ID: "fresh", Quantity: 30}, {ID: "undated", Quantity: 40}, {ID: "expired", Quantity: 15}},
+			wantLots:   map[string]float64{"expired": 5, "bar": 50},
+		},
+		{
+			name:       "only lots at the location",
+			quantity:   20,
+			locationID: "bar",
+			wantDrawn:  []models.StockLot{{ID: "bar", Quantity: 20}},
+			wantLots:   map[string]float64{"fresh": 30, "expired": 20, "undated": 40, "bar": 30},
+		},
+		{
+			name:       "a named lot",
+			quantity:   15,
+			lotID:      "expired",
+			locationID: "main",
+			wantDrawn:  []models.StockLot{{ID: "expired", Quantity: 15}},
+			wantLots:   map[string]float64{"fresh": 30, "expired": 5, "undated": 40, "bar": 50},
+		},
+		{
+			name:        "a named lot that does not exist",
+			quantity:    1,
+			lotID:       "missing",
+			locationID:  "main",
+			wantErrorOn: "lot_id",
+		},
+		{
+			name:        "a named lot at another location",
+			quantity:    1,
+			lotID:       "bar",
+			locationID:  "main",
+			wantErrorOn: "lot_id",
+		},
+		{
+			name:        "more than a named lot holds",
+			quantity:    31,
+			lotID:       "fresh",
+			locationID:  "main",
+			wantErrorOn: "delta",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// main holds 10 units from before lots were tracked
+			item := &models.InventoryItem{
+				IngredientID: "milk",
+				Unit:         "ml",
+				Stock: []models.LocationStock{
+					{LocationID: "main", Quantity: 100},
+					{LocationID: "bar", Quantity: 50},
+				},
+				Lots: []models.StockLot{
+					{ID: "fresh", Quantity: 30, ReceivedAt: "2024-03-02T08:00:00Z", ExpiresAt: "2999-12-31", LocationID: "main"},
+					{ID: "expired", Quantity: 20, ReceivedAt: "2024-03-01T08:00:00Z", ExpiresAt: "2000-01-01", LocationID: "main"},
+					{ID: "undated", Quantity: 40, ReceivedAt: "2024-03-03T08:00:00Z"},
+					{ID: "bar", Quantity: 50, ReceivedAt: "2024-03-02T08:00:00Z", ExpiresAt: "2999-12-31", LocationID: "bar"},
+				},
+			}
+			if tt.transfer {
+				// A lot received first, moved here from the bar, goes to the end
+				item.Stock[0].Quantity += 10
+				item.Lots = append(item.Lots, models.StockLot{ID: "moved", Quantity: 10, ReceivedAt: "2024-02-28T08:00:00Z", ExpiresAt: "2999-12-31", LocationID: "main"})
+			}
+
+			drawn, err := drawFromLots(item, tt.quantity, tt.lotID, tt.locationID, time.UTC)
+			if tt.wantErrorOn != "" {
+				var serviceErr *Error
+				if !errors.As(err, &serviceErr) || serviceErr.Kind != KindValidation || serviceErr.Details[0].Field != tt.wantErrorOn {
+					t.Fatalf("drawFromLots() error = %v, want a validation error on %s", err, tt.wantErrorOn)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("drawFromLots() error = %v", err)
+			}
+
+			if len(drawn) != len(tt.wantDrawn) {
+				t.Fatalf("drew from %d lots, want %d: %+v", len(drawn), len(tt.wantDrawn), drawn)
+			}
+			for i, want := range tt.wantDrawn {
+				if drawn[i].ID != want.ID || drawn[i].Quantity != want.Quantity {
+					t.Errorf("draw %d = %g from %s, want %g from %s", i, drawn[i].Quantity, drawn[i].ID, want.Quantity, want.ID)
+				}
+			}
+			lots := make(map[string]float64, len(item.Lots))
+			for _, lot := range item.Lots {
+				lots[lot.ID] = lot.Quantity
+			}
+			if !maps.Equal(lots, tt.wantLots) {
+				t.Errorf("lots left = %v, want %v", lots, tt.wantLots)
+			}
+		})
+	}
+}
+
+func TestIsLotExpiredReadsDatesInZone(t *testing.T) {
+	// Kiritimati is 25 hours ahead of Pago Pago, so its date is always a day later
+	east, err := time.LoadLocation("Pacific/Kiritimati")
+	if err != nil {
+		t.Fatal(err)
+	}
+	west, err := time.LoadLocation("Pacific/Pago_Pago")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	lot := &models.StockLot{ExpiresAt: now.In(west).Format(time.DateOnly)}
+
+	if isLotExpired(lot, now.In(west)) {
+		t.Errorf("lot expiring on %s has expired in Pago Pago, where it is that day", lot.ExpiresAt)
+	}
+	if !isLotExpired(lot, now.In(east)) {
+		t.Errorf("lot expiring on %s has not expired in Kiritimati, where it is the day after", lot.ExpiresAt)
+	}
+}
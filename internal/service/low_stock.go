@@ -0,0 +1,63 @@
+// internal/service/low_stock.go
+package service
+
+import (
+	"log/slog"
+	"time"
+
+	"hot-coffee/internal/notify"
+	"hot-coffee/models"
+)
+
+// isLowStock reports whether an ingredient with a reorder point is at or below
+// it at a location. Every location holding the ingredient reorders on its own.
+func isLowStock(item *models.InventoryItem, locationID string) bool {
+	return item.ReorderPoint > 0 && stockAt(item, locationID) <= item.ReorderPoint
+}
+
+func toLowStockItem(item *models.InventoryItem, locationID string) models.LowStockItem {
+	quantity := stockAt(item, locationID)
+	lowStock := models.LowStockItem{
+		IngredientID: item.IngredientID,
+		Name:         item.Name,
+		LocationID:   locationID,
+		Quantity:     quantity,
+		Unit:         item.Unit,
+		ReorderPoint: item.ReorderPoint,
+		ParLevel:     item.ParLevel,
+	}
+	if item.ParLevel > quantity {
+		lowStock.ReorderQuantity = item.ParLevel - quantity
+	}
+	return lowStock
+}
+
+// sendLowStockAlerts logs each alert and hands it to notifier in the
+// background, so a slow webhook never holds up the request. It must only be
+// called once the deductions behind the alerts are committed.
+func sendLowStockAlerts(notifier notify.Notifier, alerts []models.LowStockAlert) {
+	for _, alert := range alerts {
+		slog.Warn("Inventory at reorder point",
+			"ingredientID", alert.IngredientID, "locationID", alert.LocationID, "quantity", alert.Quantity,
+			"reorderPoint", alert.ReorderPoint, "orderID", alert.OrderID)
+	}
+	if notifier == nil || len(alerts) == 0 {
+		return
+	}
+
+	go func() {
+		for _, alert := range alerts {
+			if err := notifier.NotifyLowStock(alert); err != nil {
+				slog.Error("Failed to send low stock alert", "ingredientID", alert.IngredientID, "error", err)
+			}
+		}
+	}()
+}
+
+func newLowStockAlert(item *models.InventoryItem, locationID, orderID string) models.LowStockAlert {
+	return models.LowStockAlert{
+		LowStockItem: toLowStockItem(item, locationID),
+		OrderID:      orderID,
+		TriggeredAt:  time.Now().Format(time.RFC3339),
+	}
+}
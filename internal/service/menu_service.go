@@ -2,75 +2,406 @@
 package service
 
 import (
+	"cmp"
 	"errors"
 	"log/slog"
+	"slices"
+	"time"
 
 	"hot-coffee/internal/repository"
 	"hot-coffee/models"
 )
 
 type menuService struct {
-	menuRepo repository.MenuRepository
+	menuRepo      repository.MenuRepository
+	inventoryRepo repository.InventoryRepository
+	orderRepo     repository.OrderRepository
+	locationRepo  repository.LocationRepository
+	categoryRepo  repository.CategoryRepository
+	uow           repository.UnitOfWork
+	zone          *time.Location
 }
 
-func NewMenuService(menuRepo repository.MenuRepository) MenuService {
+// NewMenuService returns a menu service that checks menu schedules in the
+// shop's time zone.
+func NewMenuService(menuRepo repository.MenuRepository, inventoryRepo repository.InventoryRepository, orderRepo repository.OrderRepository, locationRepo repository.LocationRepository, categoryRepo repository.CategoryRepository, uow repository.UnitOfWork, zone *time.Location) MenuService {
 	return &menuService{
-		menuRepo: menuRepo,
+		menuRepo:      menuRepo,
+		inventoryRepo: inventoryRepo,
+		orderRepo:     orderRepo,
+		locationRepo:  locationRepo,
+		categoryRepo:  categoryRepo,
+		uow:           uow,
+		zone:          zone,
 	}
 }
 
+// CreateMenuItem adds a product, deriving its ID from the name when none is
+// given. It holds the locks of the ingredients the product refers to, so none
+// of them is deleted while it is added.
 func (s *menuService) CreateMenuItem(item *models.MenuItem) error {
-	if err := s.menuRepo.Create(item); err != nil {
+	item.DeletedAt = ""
+	item.SoldOutLocations = nil
+	clearComputedFields(item)
+
+	if item.ID == "" {
+		id, err := uniqueSlug(item.Name, func(id string) (bool, error) {
+			existing, err := s.menuRepo.GetByID(id)
+			return existing != nil, err
+		})
+		if err != nil {
+			return err
+		}
+		item.ID = id
+	}
+
+	err := s.uow.Execute(append(ingredientLockKeys(item), menuItemLockKey(item.ID)), func(repos repository.Repositories) error {
+		if err := checkCategoryExists(repos.Categories, "category_id", item.CategoryID); err != nil {
+			return err
+		}
+		if err := checkIngredientReferences(repos.Inventory, item); err != nil {
+			return err
+		}
+		return repos.Menu.Create(item)
+	})
+	if err != nil {
 		slog.Error("Failed to create menu item", "error", err)
+		if errors.Is(err, repository.ErrDuplicateID) {
+			return ConflictError("menu item %s already exists", item.ID)
+		}
 		return err
 	}
 
 	slog.Info("Menu item created", "itemID", item.ID, "name", item.Name)
+	s.describeSaved(item, models.DefaultLocationID)
 	return nil
 }
 
-func (s *menuService) GetMenuItemByID(id string) (*models.MenuItem, error) {
+// GetMenuItemByID returns a menu item with its cost and its availability at a
+// location, the default location when locationID is empty, at this time.
+func (s *menuService) GetMenuItemByID(id, locationID string) (*models.MenuItem, error) {
+	locationID = locationOrDefault(locationID)
+	if err := checkLocationExists(s.locationRepo, "location", locationID); err != nil {
+		return nil, err
+	}
+
 	item, err := s.menuRepo.GetByID(id)
 	if err != nil {
 		slog.Error("Failed to get menu item", "itemID", id, "error", err)
 		return nil, err
 	}
+	if item == nil {
+		return nil, NotFoundError("menu item not found")
+	}
+	if err := describeMenuItem(newStockUnits(s.inventoryRepo), item, locationID, s.now()); err != nil {
+		return nil, err
+	}
 	return item, nil
 }
 
-func (s *menuService) GetAllMenuItems() ([]*models.MenuItem, error) {
+// GetAllMenuItems returns the menu without deleted items, each with its cost
+// and its availability at a location, the default location when locationID is
+// empty, at this time. availableOnly leaves out the items that cannot be
+// ordered there.
+func (s *menuService) GetAllMenuItems(locationID string, availableOnly bool) ([]*models.MenuItem, error) {
+	return s.menuAt(locationID, availableOnly, s.now())
+}
+
+// menuAt describes the menu as it is at a location at time now, given in the
+// shop's time zone.
+func (s *menuService) menuAt(locationID string, availableOnly bool, now time.Time) ([]*models.MenuItem, error) {
+	locationID = locationOrDefault(locationID)
+	if err := checkLocationExists(s.locationRepo, "location", locationID); err != nil {
+		return nil, err
+	}
+
 	items, err := s.menuRepo.GetAll()
 	if err != nil {
 		slog.Error("Failed to get all menu items", "error", err)
 		return nil, err
 	}
-	return items, nil
+
+	stock := newStockUnits(s.inventoryRepo)
+	active := items[:0]
+	for _, item := range items {
+		if item.DeletedAt != "" {
+			continue
+		}
+		if err := describeMenuItem(stock, item, locationID, now); err != nil {
+			return nil, err
+		}
+		if availableOnly && !*item.Available {
+			continue
+		}
+		active = append(active, item)
+	}
+	return active, nil
 }
 
+// UpdateMenuItem replaces a product's details. It holds the menu item's lock,
+// so it cannot undo a sold out change made at the same time, and the locks of
+// the ingredients the product refers to, so none of them is deleted meanwhile.
 func (s *menuService) UpdateMenuItem(item *models.MenuItem) error {
-	existing, err := s.menuRepo.GetByID(item.ID)
+	err := s.uow.Execute(append(ingredientLockKeys(item), menuItemLockKey(item.ID)), func(repos repository.Repositories) error {
+		existing, err := repos.Menu.GetByID(item.ID)
+		if err != nil {
+			return err
+		}
+		if existing == nil || existing.DeletedAt != "" {
+			return NotFoundError("menu item not found")
+		}
+
+		// Sold out is only changed by SetSoldOut
+		item.DeletedAt = ""
+		item.SoldOutLocations = soldOutLocations(existing)
+		clearComputedFields(item)
+		if err := checkCategoryExists(repos.Categories, "category_id", item.CategoryID); err != nil {
+			return err
+		}
+		if err := checkIngredientReferences(repos.Inventory, item); err != nil {
+			return err
+		}
+		return repos.Menu.Update(item)
+	})
 	if err != nil {
+		slog.Error("Failed to update menu item", "itemID", item.ID, "error", err)
+		if errors.Is(err, repository.ErrNotFound) {
+			return NotFoundError("menu item not found")
+		}
 		return err
 	}
-	if existing == nil {
-		return errors.New("menu item not found")
+
+	slog.Info("Menu item updated", "itemID", item.ID, "name", item.Name)
+	s.describeSaved(item, models.DefaultLocationID)
+	return nil
+}
+
+// GetStructuredMenu returns the menu grouped by category in display order, as
+// GetAllMenuItems describes it. Categories without items are left out.
+func (s *menuService) GetStructuredMenu(locationID string, availableOnly bool) (*models.StructuredMenu, error) {
+	return s.structuredMenuAt(locationID, availableOnly, s.now())
+}
+
+// PreviewMenu returns the structured menu as its schedules make it at time at,
+// with the stock there is now.
+func (s *menuService) PreviewMenu(at time.Time, locationID string, availableOnly bool) (*models.StructuredMenu, error) {
+	return s.structuredMenuAt(locationID, availableOnly, at.In(s.zone))
+}
+
+func (s *menuService) structuredMenuAt(locationID string, availableOnly bool, now time.Time) (*models.StructuredMenu, error) {
+	items, err := s.menuAt(locationID, availableOnly, now)
+	if err != nil {
+		return nil, err
+	}
+	categories, err := s.categoryRepo.GetAll()
+	if err != nil {
+		slog.Error("Failed to get categories for structured menu", "error", err)
+		return nil, err
 	}
 
-	if err := s.menuRepo.Update(item); err != nil {
-		slog.Error("Failed to update menu item", "itemID", item.ID, "error", err)
+	slices.SortStableFunc(items, func(a, b *models.MenuItem) int {
+		return cmp.Or(cmp.Compare(a.DisplayOrder, b.DisplayOrder), cmp.Compare(a.Name, b.Name))
+	})
+	byCategory := make(map[string][]models.MenuItem)
+	for _, item := range items {
+		byCategory[item.CategoryID] = append(byCategory[item.CategoryID], *item)
+	}
+
+	menu := &models.StructuredMenu{
+		LocationID: locationOrDefault(locationID),
+		At:         now.Format(time.RFC3339),
+		Categories: []models.MenuSection{},
+	}
+	slices.SortStableFunc(categories, compareCategories)
+	for _, category := range categories {
+		if category.DeletedAt != "" || len(byCategory[category.ID]) == 0 {
+			continue
+		}
+		menu.Categories = append(menu.Categories, models.MenuSection{
+			CategoryID:   category.ID,
+			Name:         category.Name,
+			Description:  category.Description,
+			DisplayOrder: category.DisplayOrder,
+			Items:        byCategory[category.ID],
+		})
+	}
+	if uncategorized := byCategory[""]; len(uncategorized) > 0 {
+		menu.Categories = append(menu.Categories, models.MenuSection{Name: "Other", Items: uncategorized})
+	}
+	return menu, nil
+}
+
+// SetSoldOut marks a menu item sold out at a location, the default location
+// when locationID is empty, or available there again, regardless of stock.
+// Orders at that location cannot add an item while it is sold out there. The
+// item is returned as it is at that location.
+func (s *menuService) SetSoldOut(id, locationID string, soldOut bool) (*models.MenuItem, error) {
+	locationID = locationOrDefault(locationID)
+	var item *models.MenuItem
+	changed := false
+	err := s.uow.Execute([]string{menuItemLockKey(id)}, func(repos repository.Repositories) error {
+		if err := checkLocationExists(repos.Locations, "location", locationID); err != nil {
+			return err
+		}
+		var err error
+		item, err = repos.Menu.GetByID(id)
+		if err != nil {
+			return err
+		}
+		if item == nil || item.DeletedAt != "" {
+			return NotFoundError("menu item not found")
+		}
+
+		locations := soldOutLocations(item)
+		if (soldOutAt(item, locationID) != nil) == soldOut {
+			return nil
+		}
+		if soldOut {
+			locations = append(locations, models.SoldOutLocation{LocationID: locationID, SoldOutAt: time.Now().Format(time.RFC3339)})
+		} else {
+			locations = slices.DeleteFunc(locations, func(mark models.SoldOutLocation) bool {
+				return mark.LocationID == locationID
+			})
+		}
+		item.SoldOutLocations = locations
+		item.SoldOut, item.SoldOutAt = false, ""
+		changed = true
+		return repos.Menu.Update(item)
+	})
+	if err != nil {
+		slog.Error("Failed to change menu item sold out", "itemID", id, "error", err)
+		return nil, err
+	}
+
+	if changed {
+		slog.Info("Menu item sold out changed", "itemID", id, "locationID", locationID, "soldOut", soldOut)
+	}
+	s.describeSaved(item, locationID)
+	return item, nil
+}
+
+// GetMenuItemCosting breaks down what a menu item costs to make at the
+// ingredients' current unit costs.
+func (s *menuService) GetMenuItemCosting(id string) (*models.MenuItemCosting, error) {
+	item, err := s.menuRepo.GetByID(id)
+	if err != nil {
+		slog.Error("Failed to get menu item", "itemID", id, "error", err)
+		return nil, err
+	}
+	if item == nil || item.DeletedAt != "" {
+		return nil, NotFoundError("menu item not found")
+	}
+	return costMenuItem(newStockUnits(s.inventoryRepo), item)
+}
+
+// describeSaved fills in the computed fields of a menu item just saved for the
+// response, with its availability at a location. The item is already stored,
+// so a failure is only logged.
+func (s *menuService) describeSaved(item *models.MenuItem, locationID string) {
+	if err := describeMenuItem(newStockUnits(s.inventoryRepo), item, locationID, s.now()); err != nil {
+		slog.Warn("Failed to describe menu item", "itemID", item.ID, "error", err)
+	}
+}
+
+// describeMenuItem fills in the cost of a menu item and its availability at a
+// location.
+func describeMenuItem(stock *stockUnits, item *models.MenuItem, locationID string, now time.Time) error {
+	if err := stampMenuItemCost(stock, item); err != nil {
 		return err
 	}
+	return stampAvailability(stock, item, locationID, now)
+}
 
-	slog.Info("Menu item updated", "itemID", item.ID, "name", item.Name)
-	return nil
+// now returns the current time in the shop's time zone.
+func (s *menuService) now() time.Time {
+	return time.Now().In(s.zone)
 }
 
-func (s *menuService) DeleteMenuItem(id string) error {
-	if err := s.menuRepo.Delete(id); err != nil {
+// clearComputedFields drops the computed fields sent with a menu item so they
+// are not stored.
+func clearComputedFields(item *models.MenuItem) {
+	item.Cost, item.Margin = 0, 0
+	item.Available, item.Portions, item.OnSchedule = nil, nil, nil
+	item.SoldOut, item.SoldOutAt = false, ""
+	for i := range item.Variants {
+		variant := &item.Variants[i]
+		variant.Cost, variant.Margin = 0, 0
+		variant.Available, variant.Portions = nil, nil
+	}
+}
+
+// DeleteMenuItem refuses to delete a product that open orders still use
+// unless cascade is set. A product that any order refers to is soft-deleted
+// so that the order history keeps resolving; others are removed. The check and
+// the delete hold the menu item's lock, so no order for it is placed in
+// between.
+func (s *menuService) DeleteMenuItem(id string, cascade bool) error {
+	referenced := false
+	err := s.uow.Execute([]string{menuItemLockKey(id)}, func(repos repository.Repositories) error {
+		item, err := repos.Menu.GetByID(id)
+		if err != nil {
+			return err
+		}
+		if item == nil || item.DeletedAt != "" {
+			return NotFoundError("menu item not found")
+		}
+
+		orders, err := repos.Orders.GetAll()
+		if err != nil {
+			return err
+		}
+
+		usage := menuItemUsage(id, orders)
+		if len(usage.OpenOrders) > 0 && !cascade {
+			return referenceConflict("menu item "+id+" is used by open orders", nil, usage.OpenOrders)
+		}
+
+		referenced = slices.ContainsFunc(orders, func(order *models.Order) bool {
+			return orderUsesProduct(order, id)
+		})
+		if referenced {
+			item.DeletedAt = time.Now().Format(time.RFC3339)
+			return repos.Menu.Update(item)
+		}
+		return repos.Menu.Delete(id)
+	})
+	if err != nil {
 		slog.Error("Failed to delete menu item", "itemID", id, "error", err)
+		if errors.Is(err, repository.ErrNotFound) {
+			return NotFoundError("menu item not found")
+		}
 		return err
 	}
 
-	slog.Info("Menu item deleted", "itemID", id)
+	slog.Info("Menu item deleted", "itemID", id, "soft", referenced)
 	return nil
 }
+
+// GetMenuItemUsage lists the open orders that depend on a product.
+func (s *menuService) GetMenuItemUsage(id string) (*models.MenuItemUsage, error) {
+	item, err := s.menuRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return nil, NotFoundError("menu item not found")
+	}
+
+	orders, err := s.orderRepo.GetAll()
+	if err != nil {
+		slog.Error("Failed to get orders for menu item usage", "itemID", id, "error", err)
+		return nil, err
+	}
+
+	return menuItemUsage(id, orders), nil
+}
+
+func menuItemUsage(id string, orders []*models.Order) *models.MenuItemUsage {
+	usage := &models.MenuItemUsage{ProductID: id, OpenOrders: []models.OrderReference{}}
+	for _, order := range orders {
+		if isActiveOrder(order) && orderUsesProduct(order, id) {
+			usage.OpenOrders = append(usage.OpenOrders, toOrderReference(order))
+		}
+	}
+	return usage
+}
@@ -0,0 +1,92 @@
+// internal/service/menu_service_test.go
+package service
+
+import (
+	"slices"
+	"testing"
+
+	"hot-coffee/models"
+)
+
+func TestGetStructuredMenu(t *testing.T) {
+	repos := newTestRepositories(t)
+	for _, category := range []*models.Category{
+		{ID: "bakery", Name: "Bakery", DisplayOrder: 2},
+		{ID: "hot", Name: "Hot drinks", DisplayOrder: 1},
+		{ID: "cold", Name: "Cold drinks", DisplayOrder: 1},
+		{ID: "retired", Name: "Retired", DisplayOrder: 0, DeletedAt: "2024-01-01T00:00:00Z"},
+	} {
+		if err := repos.Categories.Create(category); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, item := range []*models.MenuItem{
+		{ID: "muffin", Name: "Muffin", CategoryID: "bakery"},
+		{ID: "latte", Name: "Latte", CategoryID: "hot", DisplayOrder: 2},
+		{ID: "tea", Name: "Tea", CategoryID: "hot", DisplayOrder: 1},
+		{ID: "espresso", Name: "Espresso", CategoryID: "hot", DisplayOrder: 2},
+		{ID: "gift_card", Name: "Gift card"},
+		{ID: "old_scone", Name: "Old scone", CategoryID: "bakery", DeletedAt: "2024-01-01T00:00:00Z"},
+	} {
+		item.Ingredients = []models.MenuItemIngredient{}
+		addMenuItem(t, repos, item)
+	}
+
+	menu, err := newTestMenuService(repos).GetStructuredMenu("", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Categories without items are left out, and items without one come last
+	type section struct {
+		category string
+		items    []string
+	}
+	want := []section{
+		{"hot", []string{"tea", "espresso", "latte"}},
+		{"bakery", []string{"muffin"}},
+		{"", []string{"gift_card"}},
+	}
+	var got []section
+	for _, s := range menu.Categories {
+		var items []string
+		for _, item := range s.Items {
+			items = append(items, item.ID)
+		}
+		got = append(got, section{s.CategoryID, items})
+	}
+	if !slices.EqualFunc(got, want, func(a, b section) bool {
+		return a.category == b.category && slices.Equal(a.items, b.items)
+	}) {
+		t.Errorf("structured menu = %v, want %v", got, want)
+	}
+	if other := menu.Categories[len(menu.Categories)-1]; other.Name != "Other" {
+		t.Errorf("last section is named %q, want Other", other.Name)
+	}
+	if menu.LocationID != models.DefaultLocationID {
+		t.Errorf("location = %q, want %q", menu.LocationID, models.DefaultLocationID)
+	}
+}
+
+func TestCreateMenuItemChecksCategory(t *testing.T) {
+	repos := newTestRepositories(t)
+	if err := repos.Categories.Create(&models.Category{ID: "retired", Name: "Retired", DeletedAt: "2024-01-01T00:00:00Z"}); err != nil {
+		t.Fatal(err)
+	}
+	s := newTestMenuService(repos)
+
+	for _, categoryID := range []string{"missing", "retired"} {
+		item := &models.MenuItem{Name: "Tea " + categoryID, Price: 2, CategoryID: categoryID, Ingredients: []models.MenuItemIngredient{}}
+		err := s.CreateMenuItem(item)
+		if errorKind(err) != KindValidation {
+			t.Errorf("CreateMenuItem() in %s error = %v, want a validation error", categoryID, err)
+		}
+	}
+	items, err := repos.Menu.GetAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 0 {
+		t.Errorf("stored %d menu items, want none", len(items))
+	}
+}
@@ -0,0 +1,165 @@
+// internal/service/order_lines.go
+package service
+
+import (
+	"slices"
+
+	"hot-coffee/models"
+)
+
+// priceOrderLine applies the variant, substitutions and modifiers chosen on an
+// order line to the product's recipe. It stamps the line with a snapshot of the
+// product name and category, prices and the cost of its ingredients at their
+// current unit costs, and returns the ingredients one unit of the line uses, in
+// the units they are stocked in. field names the line in validation errors.
+func priceOrderLine(menuItem *models.MenuItem, orderItem *models.OrderItem, field string, stock *stockUnits) (map[string]float64, error) {
+	recipe, unitPrice := menuItem.Ingredients, menuItem.Price
+	orderItem.VariantName = ""
+	if len(menuItem.Variants) > 0 || orderItem.VariantID != "" {
+		variant := findVariant(menuItem, orderItem.VariantID)
+		if variant == nil {
+			if orderItem.VariantID == "" {
+				return nil, FieldError(field+".variant_id", "a variant is required for %s", menuItem.ID)
+			}
+			return nil, FieldError(field+".variant_id", "variant %s is not available for %s", orderItem.VariantID, menuItem.ID)
+		}
+		recipe, unitPrice = variant.Ingredients, variant.Price
+		orderItem.VariantName = variant.Name
+	}
+
+	// Quantities stay in recipe units until every line is known
+	lines := slices.Clone(recipe)
+
+	seenGroups := make(map[string]bool, len(orderItem.Substitutions))
+	for i := range orderItem.Substitutions {
+		substitution := &orderItem.Substitutions[i]
+		if seenGroups[substitution.GroupID] {
+			return nil, FieldError(field+".substitutions", "substitution group %s is chosen more than once for %s", substitution.GroupID, menuItem.ID)
+		}
+		seenGroups[substitution.GroupID] = true
+
+		group := findSubstitutionGroup(menuItem, substitution.GroupID)
+		if group == nil {
+			return nil, FieldError(field+".substitutions", "substitution group %s is not available for %s", substitution.GroupID, menuItem.ID)
+		}
+		option := findSubstitutionOption(group, substitution.IngredientID)
+		if option == nil {
+			return nil, FieldError(field+".substitutions", "ingredient %s is not an option of substitution group %s", substitution.IngredientID, group.ID)
+		}
+
+		// The option replaces the recipe ingredient in the same quantity
+		for j := range lines {
+			if lines[j].IngredientID != group.IngredientID {
+				continue
+			}
+			if lines[j].Unit == "" {
+				unit, err := stock.unitOf(group.IngredientID)
+				if err != nil {
+					return nil, err
+				}
+				lines[j].Unit = unit
+			}
+			lines[j].IngredientID = option.IngredientID
+		}
+
+		substitution.Name = option.Name
+		substitution.Price = option.Price
+		unitPrice += option.Price
+	}
+
+	seenModifiers := make(map[string]bool, len(orderItem.Modifiers))
+	for i := range orderItem.Modifiers {
+		chosen := &orderItem.Modifiers[i]
+		if seenModifiers[chosen.ModifierID] {
+			return nil, FieldError(field+".modifiers", "modifier %s is listed more than once for %s", chosen.ModifierID, menuItem.ID)
+		}
+		seenModifiers[chosen.ModifierID] = true
+
+		modifier := findModifier(menuItem, chosen.ModifierID)
+		if modifier == nil {
+			return nil, FieldError(field+".modifiers", "modifier %s is not available for %s", chosen.ModifierID, menuItem.ID)
+		}
+		if chosen.Quantity == 0 {
+			chosen.Quantity = 1
+		}
+		if modifier.MaxQuantity > 0 && chosen.Quantity > modifier.MaxQuantity {
+			return nil, FieldError(field+".modifiers", "modifier %s allows at most %d per item", modifier.ID, modifier.MaxQuantity)
+		}
+
+		for _, ingredient := range modifier.Ingredients {
+			ingredient.Quantity *= float64(chosen.Quantity)
+			lines = append(lines, ingredient)
+		}
+
+		chosen.Name = modifier.Name
+		chosen.UnitPrice = modifier.Price
+		unitPrice += modifier.Price * float64(chosen.Quantity)
+	}
+
+	ingredients := make(map[string]float64, len(lines))
+	for _, line := range lines {
+		quantity, err := stock.toStock(line.IngredientID, line.Quantity, line.Unit)
+		if err != nil {
+			return nil, err
+		}
+		ingredients[line.IngredientID] += quantity
+	}
+
+	for ingredientID, quantity := range ingredients {
+		if quantity < 0 {
+			return nil, FieldError(field+".modifiers", "modifiers remove more %s than %s uses", ingredientID, menuItem.ID)
+		}
+		if quantity == 0 {
+			delete(ingredients, ingredientID)
+		}
+	}
+
+	unitCost, err := stock.costOf(ingredients)
+	if err != nil {
+		return nil, err
+	}
+
+	orderItem.ProductName = menuItem.Name
+	orderItem.CategoryID = menuItem.CategoryID
+	orderItem.UnitPrice = roundMoney(unitPrice)
+	orderItem.LineTotal = roundMoney(unitPrice * float64(orderItem.Quantity))
+	orderItem.UnitCost = roundMoney(unitCost)
+	orderItem.LineCost = roundMoney(unitCost * float64(orderItem.Quantity))
+	return ingredients, nil
+}
+
+func findVariant(menuItem *models.MenuItem, id string) *models.MenuItemVariant {
+	for i := range menuItem.Variants {
+		if menuItem.Variants[i].ID == id {
+			return &menuItem.Variants[i]
+		}
+	}
+	return nil
+}
+
+func findModifier(menuItem *models.MenuItem, id string) *models.MenuModifier {
+	for i := range menuItem.Modifiers {
+		if menuItem.Modifiers[i].ID == id {
+			return &menuItem.Modifiers[i]
+		}
+	}
+	return nil
+}
+
+func findSubstitutionGroup(menuItem *models.MenuItem, id string) *models.SubstitutionGroup {
+	for i := range menuItem.SubstitutionGroups {
+		if menuItem.SubstitutionGroups[i].ID == id {
+			return &menuItem.SubstitutionGroups[i]
+		}
+	}
+	return nil
+}
+
+func findSubstitutionOption(group *models.SubstitutionGroup, ingredientID string) *models.SubstitutionOption {
+	for i := range group.Options {
+		if group.Options[i].IngredientID == ingredientID {
+			return &group.Options[i]
+		}
+	}
+	return nil
+}
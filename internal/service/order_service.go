@@ -2,46 +2,114 @@
 package service
 
 import (
-	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
+	"strings"
 	"time"
 
+	"hot-coffee/internal/notify"
 	"hot-coffee/internal/repository"
 	"hot-coffee/models"
 )
 
+// orderTransitions lists the statuses each order status may move to: the next
+// step of the lifecycle, cancelling before completion and refunding after it.
+var orderTransitions = map[string][]string{
+	models.OrderStatusOpen:          {models.OrderStatusInPreparation, models.OrderStatusCancelled},
+	models.OrderStatusInPreparation: {models.OrderStatusReady, models.OrderStatusCancelled},
+	models.OrderStatusReady:         {models.OrderStatusCompleted, models.OrderStatusCancelled},
+	models.OrderStatusCompleted:     {models.OrderStatusRefunded},
+	models.OrderStatusClosed:        {models.OrderStatusRefunded},
+}
+
+// orderLifecycle is the order statuses move through, one step at a time, from
+// being placed to being completed.
+var orderLifecycle = []string{
+	models.OrderStatusOpen,
+	models.OrderStatusInPreparation,
+	models.OrderStatusReady,
+	models.OrderStatusCompleted,
+}
+
 type orderService struct {
 	orderRepo     repository.OrderRepository
 	menuRepo      repository.MenuRepository
 	inventoryRepo repository.InventoryRepository
+	uow           repository.UnitOfWork
+	notifier      notify.Notifier
+	zone          *time.Location
 }
 
-func NewOrderService(orderRepo repository.OrderRepository, menuRepo repository.MenuRepository, inventoryRepo repository.InventoryRepository) OrderService {
+// NewOrderService returns an order service that checks menu schedules in the
+// shop's time zone.
+func NewOrderService(orderRepo repository.OrderRepository, menuRepo repository.MenuRepository, inventoryRepo repository.InventoryRepository, uow repository.UnitOfWork, notifier notify.Notifier, zone *time.Location) OrderService {
 	return &orderService{
 		orderRepo:     orderRepo,
 		menuRepo:      menuRepo,
 		inventoryRepo: inventoryRepo,
+		uow:           uow,
+		notifier:      notifier,
+		zone:          zone,
 	}
 }
 
+// CreateOrder places an order at its location, the default location when none
+// is given, and deducts the ingredients from the stock held there.
 func (s *orderService) CreateOrder(order *models.Order) error {
 	// Generate order ID
 	order.ID = generateID()
-	order.Status = "open"
+	order.LocationID = locationOrDefault(order.LocationID)
+	order.Status = models.OrderStatusOpen
 	order.CreatedAt = time.Now().Format(time.RFC3339)
-
-	// Check if all products exist and validate inventory
-	if err := s.validateAndDeductInventory(order); err != nil {
-		return err
+	order.StatusHistory = []models.OrderStatusChange{
+		{Status: order.Status, ChangedAt: order.CreatedAt},
 	}
 
-	if err := s.orderRepo.Create(order); err != nil {
+	// Deduct inventory and persist the order as one unit, serialized against
+	// other orders that use the same ingredients and against changes to the
+	// products ordered
+	var alerts []models.LowStockAlert
+	var locked map[string]float64
+	err := executeRetrying(s.uow, "the products ordered changed while the order was placed, please retry", func() ([]string, error) {
+		// Check if all products exist and calculate the ingredients they need
+		var err error
+		if locked, err = calculateRequiredIngredients(s.menuRepo, s.inventoryRepo, order.Items, false); err != nil {
+			return nil, err
+		}
+		return append(inventoryLockKeys(locked), menuItemLockKeys(order.Items)...), nil
+	}, func(repos repository.Repositories) error {
+		if err := checkOrderable(repos.Menu, order.Items, nil, order.LocationID, time.Now().In(s.zone)); err != nil {
+			return err
+		}
+		if err := checkLocationExists(repos.Locations, "location_id", order.LocationID); err != nil {
+			return err
+		}
+
+		// Price the lines from the recipes as they are under the locks
+		requiredIngredients, err := calculateRequiredIngredients(repos.Menu, repos.Inventory, order.Items, false)
+		if err != nil {
+			return err
+		}
+		if !coversIngredients(locked, requiredIngredients) {
+			return errLocksChanged
+		}
+		calculateOrderTotals(order)
+
+		alerts, err = s.validateAndDeductInventory(repos, requiredIngredients, order.ID, order.LocationID)
+		if err != nil {
+			return err
+		}
+		order.IngredientsUsed = toOrderIngredients(requiredIngredients)
+		return repos.Orders.Create(order)
+	})
+	if err != nil {
 		slog.Error("Failed to create order", "error", err)
 		return err
 	}
 
 	slog.Info("Order created", "orderID", order.ID, "customer", order.CustomerName)
+	sendLowStockAlerts(s.notifier, alerts)
 	return nil
 }
 
@@ -51,6 +119,9 @@ func (s *orderService) GetOrderByID(id string) (*models.Order, error) {
 		slog.Error("Failed to get order", "orderID", id, "error", err)
 		return nil, err
 	}
+	if order == nil {
+		return nil, NotFoundError("order not found")
+	}
 	return order, nil
 }
 
@@ -63,92 +134,437 @@ func (s *orderService) GetAllOrders() ([]*models.Order, error) {
 	return orders, nil
 }
 
+// UpdateOrder replaces the customer name and items of an open order. Only the
+// difference in ingredients between the old and new items is deducted from or
+// returned to inventory at the order's location, which does not change. On
+// success order holds the stored order.
 func (s *orderService) UpdateOrder(order *models.Order) error {
-	existing, err := s.orderRepo.GetByID(order.ID)
-	if err != nil {
-		return err
-	}
-	if existing == nil {
-		return errors.New("order not found")
-	}
+	var alerts []models.LowStockAlert
+	err := s.executeOnOrder(order.ID, order.Items, func(repos repository.Repositories, existing *models.Order, requiredIngredients map[string]float64) error {
+		if existing.Status != models.OrderStatusOpen {
+			return ConflictError("only open orders can be modified: order is %s", existing.Status)
+		}
+		if err := checkOrderable(repos.Menu, order.Items, existing.Items, locationOrDefault(existing.LocationID), time.Now().In(s.zone)); err != nil {
+			return err
+		}
 
-	if err := s.orderRepo.Update(order); err != nil {
+		used, err := ingredientsUsedBy(repos.Menu, repos.Inventory, existing)
+		if err != nil {
+			return err
+		}
+		alerts, err = s.applyInventoryDelta(repos, used, requiredIngredients, existing.ID, locationOrDefault(existing.LocationID))
+		if err != nil {
+			return err
+		}
+
+		// Server-owned fields are kept from the stored order
+		existing.CustomerName = order.CustomerName
+		existing.Items = order.Items
+		existing.IngredientsUsed = toOrderIngredients(requiredIngredients)
+		calculateOrderTotals(existing)
+		if err := repos.Orders.Update(existing); err != nil {
+			return err
+		}
+
+		*order = *existing
+		return nil
+	})
+	if err != nil {
 		slog.Error("Failed to update order", "orderID", order.ID, "error", err)
 		return err
 	}
 
 	slog.Info("Order updated", "orderID", order.ID)
+	sendLowStockAlerts(s.notifier, alerts)
 	return nil
 }
 
+// DeleteOrder cancels the order rather than removing it, so it stays in the
+// order history and its ingredients are returned to inventory.
 func (s *orderService) DeleteOrder(id string) error {
-	if err := s.orderRepo.Delete(id); err != nil {
-		slog.Error("Failed to delete order", "orderID", id, "error", err)
-		return err
+	_, err := s.CancelOrder(id, "")
+	return err
+}
+
+func (s *orderService) CancelOrder(id, reason string) (*models.Order, error) {
+	var order *models.Order
+	err := s.executeOnOrder(id, nil, func(repos repository.Repositories, current *models.Order, _ map[string]float64) error {
+		order = current
+		if err := transitionOrder(order, models.OrderStatusCancelled, reason); err != nil {
+			return err
+		}
+
+		used, err := ingredientsUsedBy(repos.Menu, repos.Inventory, order)
+		if err != nil {
+			return err
+		}
+		if err := s.restoreInventory(repos, used, order.ID, locationOrDefault(order.LocationID)); err != nil {
+			return err
+		}
+		return repos.Orders.Update(order)
+	})
+	if err != nil {
+		slog.Error("Failed to cancel order", "orderID", id, "error", err)
+		return nil, err
 	}
 
-	slog.Info("Order deleted", "orderID", id)
-	return nil
+	slog.Info("Order cancelled", "orderID", id, "reason", reason)
+	return order, nil
 }
 
+// CloseOrder completes an order at any step before completion by taking the
+// steps of the lifecycle it has left, each recorded in its status history.
 func (s *orderService) CloseOrder(id string) error {
-	order, err := s.orderRepo.GetByID(id)
+	err := s.uow.Execute([]string{orderLockKey(id)}, func(repos repository.Repositories) error {
+		order, err := repos.Orders.GetByID(id)
+		if err != nil {
+			return err
+		}
+		if order == nil {
+			return NotFoundError("order not found")
+		}
+
+		step := slices.Index(orderLifecycle, order.Status)
+		if step < 0 || step == len(orderLifecycle)-1 {
+			return ConflictError("invalid order status transition: cannot close order that is %s", order.Status)
+		}
+		for _, status := range orderLifecycle[step+1:] {
+			if err := transitionOrder(order, status, ""); err != nil {
+				return err
+			}
+		}
+		return repos.Orders.Update(order)
+	})
 	if err != nil {
+		slog.Error("Failed to close order", "orderID", id, "error", err)
 		return err
 	}
-	if order == nil {
-		return errors.New("order not found")
+
+	slog.Info("Order closed", "orderID", id)
+	return nil
+}
+
+func (s *orderService) ChangeOrderStatus(id, status, reason string) (*models.Order, error) {
+	if status == models.OrderStatusCancelled {
+		return s.CancelOrder(id, reason)
 	}
 
-	order.Status = "closed"
-	if err := s.orderRepo.Update(order); err != nil {
-		slog.Error("Failed to close order", "orderID", id, "error", err)
-		return err
+	var order *models.Order
+	err := s.uow.Execute([]string{orderLockKey(id)}, func(repos repository.Repositories) error {
+		var err error
+		order, err = repos.Orders.GetByID(id)
+		if err != nil {
+			return err
+		}
+		if order == nil {
+			return NotFoundError("order not found")
+		}
+
+		if err := transitionOrder(order, status, reason); err != nil {
+			return err
+		}
+		return repos.Orders.Update(order)
+	})
+	if err != nil {
+		slog.Error("Failed to change order status", "orderID", id, "status", status, "error", err)
+		return nil, err
 	}
 
-	slog.Info("Order closed", "orderID", id)
+	slog.Info("Order status changed", "orderID", id, "status", status)
+	return order, nil
+}
+
+// transitionOrder moves order to status and records the change in its history.
+func transitionOrder(order *models.Order, status, reason string) error {
+	if !slices.Contains(orderTransitions[order.Status], status) {
+		return ConflictError("invalid order status transition: cannot move order from %s to %s", order.Status, status)
+	}
+
+	order.Status = status
+	order.StatusHistory = append(order.StatusHistory, models.OrderStatusChange{
+		Status:    status,
+		ChangedAt: time.Now().Format(time.RFC3339),
+		Reason:    reason,
+	})
 	return nil
 }
 
-func (s *orderService) validateAndDeductInventory(order *models.Order) error {
+// calculateRequiredIngredients looks up the product of every line, prices the
+// line with its modifiers and substitutions, and returns the ingredients all
+// lines need. Deleted products are only accepted when allowDeleted is set, for
+// orders placed before they were deleted.
+func calculateRequiredIngredients(menuRepo repository.MenuRepository, inventoryRepo repository.InventoryRepository, items []models.OrderItem, allowDeleted bool) (map[string]float64, error) {
 	requiredIngredients := make(map[string]float64)
+	stock := newStockUnits(inventoryRepo)
+
+	for i := range items {
+		orderItem := &items[i]
+		menuItem, err := menuRepo.GetByID(orderItem.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		if menuItem == nil || (menuItem.DeletedAt != "" && !allowDeleted) {
+			return nil, FieldError(fmt.Sprintf("items[%d].product_id", i), "product not found: %s", orderItem.ProductID)
+		}
+
+		lineIngredients, err := priceOrderLine(menuItem, orderItem, fmt.Sprintf("items[%d]", i), stock)
+		if err != nil {
+			return nil, err
+		}
+
+		for ingredientID, quantity := range lineIngredients {
+			requiredIngredients[ingredientID] += quantity * float64(orderItem.Quantity)
+		}
+	}
+
+	return requiredIngredients, nil
+}
 
-	// Calculate total ingredients needed
-	for _, orderItem := range order.Items {
-		menuItem, err := s.menuRepo.GetByID(orderItem.ProductID)
+// checkOrderable refuses lines of products that are deleted, marked sold out
+// at locationID or outside their schedules at now, given in the shop's time
+// zone. Products already on the order, given as existing, are accepted so that
+// an order placed while an item could be ordered can still be changed. Inside
+// a unit of work it must hold the menu item locks of items.
+func checkOrderable(menuRepo repository.MenuRepository, items, existing []models.OrderItem, locationID string, now time.Time) error {
+	for i, orderItem := range items {
+		onOrder := slices.ContainsFunc(existing, func(line models.OrderItem) bool {
+			return line.ProductID == orderItem.ProductID
+		})
+		if onOrder {
+			continue
+		}
+		menuItem, err := menuRepo.GetByID(orderItem.ProductID)
 		if err != nil {
 			return err
 		}
-		if menuItem == nil {
-			return fmt.Errorf("product not found: %s", orderItem.ProductID)
+		if menuItem == nil || menuItem.DeletedAt != "" {
+			return FieldError(fmt.Sprintf("items[%d].product_id", i), "product not found: %s", orderItem.ProductID)
+		}
+		if soldOutAt(menuItem, locationID) != nil {
+			return ConflictError("%s is sold out at %s", menuItem.Name, locationID)
+		}
+		if !onSchedule(menuItem, now) {
+			return ConflictError("%s is not available at this time", menuItem.Name)
 		}
+	}
+	return nil
+}
 
-		for _, ingredient := range menuItem.Ingredients {
-			requiredIngredients[ingredient.IngredientID] += ingredient.Quantity * float64(orderItem.Quantity)
+// calculateOrderTotals sums the priced lines of an order and their cost of
+// goods.
+func calculateOrderTotals(order *models.Order) {
+	var subtotal, costOfGoods float64
+	for _, item := range order.Items {
+		subtotal += item.LineTotal
+		costOfGoods += item.LineCost
+	}
+	order.Subtotal = roundMoney(subtotal)
+	order.Total = order.Subtotal
+	order.CostOfGoods = roundMoney(costOfGoods)
+}
+
+// validateAndDeductInventory must run inside a unit of work holding the locks
+// for every ingredient in requiredIngredients. Stock is taken from locationID.
+// Every ingredient is checked before anything is deducted so that a shortage
+// reports all missing ingredients at once. It returns an alert for every
+// ingredient the deduction takes down to its reorder point at locationID.
+func (s *orderService) validateAndDeductInventory(repos repository.Repositories, requiredIngredients map[string]float64, orderID, locationID string) ([]models.LowStockAlert, error) {
+	ingredientIDs := sortedIngredientIDs(requiredIngredients)
+	inventoryItems := make([]*models.InventoryItem, 0, len(ingredientIDs))
+	now := time.Now().In(s.zone)
+	var shortages []models.ErrorDetail
+	for _, ingredientID := range ingredientIDs {
+		inventoryItem, err := repos.Inventory.GetByID(ingredientID)
+		if err != nil {
+			return nil, err
+		}
+		if inventoryItem == nil || inventoryItem.DeletedAt != "" {
+			return nil, ConflictError("ingredient not found in inventory: %s", ingredientID)
 		}
+
+		// Stock in expired lots waits for the expiry job and cannot be sold
+		requiredQty := requiredIngredients[ingredientID]
+		if available := usableQuantity(inventoryItem, locationID, now); available < requiredQty {
+			shortages = append(shortages, shortage(ingredientID, inventoryItem.Unit, requiredQty, available))
+		}
+		inventoryItems = append(inventoryItems, inventoryItem)
+	}
+	if len(shortages) > 0 {
+		return nil, InsufficientStockError(shortages)
 	}
 
-	// Check inventory and deduct ingredients
-	for ingredientID, requiredQty := range requiredIngredients {
-		inventoryItem, err := s.inventoryRepo.GetByID(ingredientID)
+	var alerts []models.LowStockAlert
+	for _, inventoryItem := range inventoryItems {
+		wasLow := isLowStock(inventoryItem, locationID)
+		err := adjustStock(repos, inventoryItem, -requiredIngredients[inventoryItem.IngredientID], &models.InventoryMovement{
+			Reason:     models.MovementReasonSale,
+			OrderID:    orderID,
+			LocationID: locationID,
+		}, s.zone)
+		if err != nil {
+			return nil, err
+		}
+		if !wasLow && isLowStock(inventoryItem, locationID) {
+			alerts = append(alerts, newLowStockAlert(inventoryItem, locationID, orderID))
+		}
+	}
+
+	return alerts, nil
+}
+
+// restoreInventory returns quantities deducted by validateAndDeductInventory.
+// It must run inside a unit of work holding the locks for every ingredient.
+func (s *orderService) restoreInventory(repos repository.Repositories, ingredients map[string]float64, orderID, locationID string) error {
+	for _, ingredientID := range sortedIngredientIDs(ingredients) {
+		quantity := ingredients[ingredientID]
+		inventoryItem, err := repos.Inventory.GetByID(ingredientID)
 		if err != nil {
 			return err
 		}
 		if inventoryItem == nil {
-			return fmt.Errorf("ingredient not found in inventory: %s", ingredientID)
+			slog.Warn("Cannot restore ingredient missing from inventory", "ingredientID", ingredientID, "quantity", quantity)
+			continue
+		}
+
+		err = adjustStock(repos, inventoryItem, quantity, &models.InventoryMovement{
+			Reason:     models.MovementReasonCancellation,
+			OrderID:    orderID,
+			LocationID: locationID,
+		}, s.zone)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// applyInventoryDelta deducts ingredients the new quantities need beyond the
+// old ones and returns those no longer needed. It must run inside a unit of
+// work holding the locks for every ingredient in both maps.
+func (s *orderService) applyInventoryDelta(repos repository.Repositories, oldIngredients, newIngredients map[string]float64, orderID, locationID string) ([]models.LowStockAlert, error) {
+	increases := make(map[string]float64)
+	decreases := make(map[string]float64)
+
+	for ingredientID, newQty := range newIngredients {
+		if delta := newQty - oldIngredients[ingredientID]; delta > 0 {
+			increases[ingredientID] = delta
+		} else if delta < 0 {
+			decreases[ingredientID] = -delta
 		}
+	}
+	for ingredientID, oldQty := range oldIngredients {
+		if _, ok := newIngredients[ingredientID]; !ok {
+			decreases[ingredientID] = oldQty
+		}
+	}
+
+	alerts, err := s.validateAndDeductInventory(repos, increases, orderID, locationID)
+	if err != nil {
+		return nil, err
+	}
+	return alerts, s.restoreInventory(repos, decreases, orderID, locationID)
+}
 
-		if inventoryItem.Quantity < requiredQty {
-			return fmt.Errorf("insufficient inventory for ingredient '%s'. Required: %.2f%s, Available: %.2f%s",
-				inventoryItem.Name, requiredQty, inventoryItem.Unit, inventoryItem.Quantity, inventoryItem.Unit)
+// ingredientsUsedBy returns the inventory deducted for an order. Orders placed
+// before deductions were recorded fall back to their products' current recipes.
+func ingredientsUsedBy(menuRepo repository.MenuRepository, inventoryRepo repository.InventoryRepository, order *models.Order) (map[string]float64, error) {
+	if order.IngredientsUsed == nil {
+		// Work on a copy so the stored line snapshots are left untouched
+		return calculateRequiredIngredients(menuRepo, inventoryRepo, slices.Clone(order.Items), true)
+	}
+
+	used := make(map[string]float64, len(order.IngredientsUsed))
+	for _, ingredient := range order.IngredientsUsed {
+		used[ingredient.IngredientID] += ingredient.Quantity
+	}
+	return used, nil
+}
+
+// executeOnOrder runs fn in a unit of work that holds the order's lock and the
+// locks of every ingredient it used, plus, given new lines for the order as
+// items, the locks of their products and of the ingredients they need. fn
+// receives the order and the ingredients items need, worked out under those
+// locks, which prices the lines of items.
+func (s *orderService) executeOnOrder(id string, items []models.OrderItem, fn func(repos repository.Repositories, order *models.Order, required map[string]float64) error) error {
+	var used, required map[string]float64
+	return executeRetrying(s.uow, fmt.Sprintf("order %s changed while it was being processed, please retry", id), func() ([]string, error) {
+		order, err := s.orderRepo.GetByID(id)
+		if err != nil {
+			return nil, err
+		}
+		if order == nil {
+			return nil, NotFoundError("order not found")
+		}
+		if used, err = ingredientsUsedBy(s.menuRepo, s.inventoryRepo, order); err != nil {
+			return nil, err
+		}
+		if required, err = calculateRequiredIngredients(s.menuRepo, s.inventoryRepo, items, false); err != nil {
+			return nil, err
 		}
 
-		// Deduct from inventory
-		inventoryItem.Quantity -= requiredQty
-		if err := s.inventoryRepo.Update(inventoryItem); err != nil {
+		keys := append(inventoryLockKeys(used), inventoryLockKeys(required)...)
+		keys = append(keys, menuItemLockKeys(items)...)
+		return append(keys, orderLockKey(id)), nil
+	}, func(repos repository.Repositories) error {
+		current, err := repos.Orders.GetByID(id)
+		if err != nil {
 			return err
 		}
+		if current == nil {
+			return NotFoundError("order not found")
+		}
+
+		currentUsed, err := ingredientsUsedBy(repos.Menu, repos.Inventory, current)
+		if err != nil {
+			return err
+		}
+		currentRequired, err := calculateRequiredIngredients(repos.Menu, repos.Inventory, items, false)
+		if err != nil {
+			return err
+		}
+		if !coversIngredients(used, currentUsed) || !coversIngredients(required, currentRequired) {
+			return errLocksChanged
+		}
+
+		return fn(repos, current, currentRequired)
+	})
+}
+
+// coversIngredients reports whether every ingredient of ingredients is one of
+// locked.
+func coversIngredients(locked, ingredients map[string]float64) bool {
+	for ingredientID := range ingredients {
+		if _, ok := locked[ingredientID]; !ok {
+			return false
+		}
 	}
+	return true
+}
 
-	return nil
+func toOrderIngredients(ingredients map[string]float64) []models.OrderIngredient {
+	result := make([]models.OrderIngredient, 0, len(ingredients))
+	for ingredientID, quantity := range ingredients {
+		result = append(result, models.OrderIngredient{IngredientID: ingredientID, Quantity: quantity})
+	}
+	slices.SortFunc(result, func(a, b models.OrderIngredient) int {
+		return strings.Compare(a.IngredientID, b.IngredientID)
+	})
+	return result
+}
+
+func inventoryLockKeys(ingredients map[string]float64) []string {
+	keys := make([]string, 0, len(ingredients))
+	for ingredientID := range ingredients {
+		keys = append(keys, inventoryLockKey(ingredientID))
+	}
+	return keys
+}
+
+func menuItemLockKeys(items []models.OrderItem) []string {
+	keys := make([]string, 0, len(items))
+	for _, item := range items {
+		keys = append(keys, menuItemLockKey(item.ProductID))
+	}
+	return keys
 }
@@ -0,0 +1,171 @@
+// internal/service/order_service_test.go
+package service
+
+import (
+	"testing"
+	"time"
+
+	"hot-coffee/internal/notify"
+	"hot-coffee/internal/repository"
+	"hot-coffee/models"
+)
+
+// changingMenu stands in for a menu update made between reading a product and
+// locking it: the first read of a product returns it as it was and then runs
+// change.
+type changingMenu struct {
+	repository.MenuRepository
+	change func()
+}
+
+func (r *changingMenu) GetByID(id string) (*models.MenuItem, error) {
+	item, err := r.MenuRepository.GetByID(id)
+	if change := r.change; change != nil {
+		r.change = nil
+		change()
+	}
+	return item, err
+}
+
+func TestOrderUsesRecipeReadUnderLocks(t *testing.T) {
+	tests := []struct {
+		name   string
+		record func(s OrderService, inventory InventoryService, arm func()) error
+	}{
+		{
+			name: "placing an order",
+			record: func(s OrderService, _ InventoryService, arm func()) error {
+				arm()
+				return s.CreateOrder(&models.Order{CustomerName: "Ann", Items: []models.OrderItem{{ProductID: "latte", Quantity: 1}}})
+			},
+		},
+		{
+			name: "changing an order",
+			record: func(s OrderService, _ InventoryService, arm func()) error {
+				order := &models.Order{CustomerName: "Ann", Items: []models.OrderItem{{ProductID: "espresso", Quantity: 1}}}
+				if err := s.CreateOrder(order); err != nil {
+					return err
+				}
+				arm()
+				order.Items = []models.OrderItem{{ProductID: "latte", Quantity: 1}}
+				return s.UpdateOrder(order)
+			},
+		},
+		{
+			name: "wasting a finished product",
+			record: func(_ OrderService, inventory InventoryService, arm func()) error {
+				arm()
+				return inventory.RecordWaste(&models.WasteRecord{Reason: models.WasteReasonDropped, Items: []models.OrderItem{{ProductID: "latte", Quantity: 1}}})
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := newTestRepositories(t)
+			addStock(t, repos, "milk", "ml", 1000, 0)
+			addStock(t, repos, "oat_milk", "ml", 1000, 0)
+			addStock(t, repos, "coffee", "g", 1000, 0)
+			addMenuItem(t, repos, &models.MenuItem{
+				ID:          "espresso",
+				Name:        "Espresso",
+				Price:       2,
+				Ingredients: []models.MenuItemIngredient{{IngredientID: "coffee", Quantity: 18}},
+			})
+			addMenuItem(t, repos, &models.MenuItem{
+				ID:          "latte",
+				Name:        "Latte",
+				Price:       4,
+				Ingredients: []models.MenuItemIngredient{{IngredientID: "milk", Quantity: 200}},
+			})
+
+			// Once armed, the recipe moves to oat milk as soon as it has been read
+			base := repos.Menu
+			menu := &changingMenu{MenuRepository: base}
+			repos.Menu = menu
+			arm := func() {
+				menu.change = func() {
+					err := base.Update(&models.MenuItem{
+						ID:          "latte",
+						Name:        "Latte",
+						Price:       4,
+						Ingredients: []models.MenuItemIngredient{{IngredientID: "oat_milk", Quantity: 200}},
+					})
+					if err != nil {
+						t.Error(err)
+					}
+				}
+			}
+			uow := repository.NewUnitOfWork(repos)
+			orders := NewOrderService(repos.Orders, repos.Menu, repos.Inventory, uow, notify.Multi(), time.UTC)
+			inventory := NewInventoryService(repos.Inventory, repos.Menu, repos.Orders, repos.Movements, repos.Locations, uow, time.UTC)
+
+			if err := tt.record(orders, inventory, arm); err != nil {
+				t.Fatal(err)
+			}
+			if got := quantityOf(t, repos, "milk"); got != 1000 {
+				t.Errorf("milk = %g, want 1000 left alone", got)
+			}
+			if got := quantityOf(t, repos, "oat_milk"); got != 800 {
+				t.Errorf("oat milk = %g, want 800", got)
+			}
+		})
+	}
+}
+
+func TestTransitionOrder(t *testing.T) {
+	const (
+		open          = models.OrderStatusOpen
+		inPreparation = models.OrderStatusInPreparation
+		ready         = models.OrderStatusReady
+		completed     = models.OrderStatusCompleted
+		cancelled     = models.OrderStatusCancelled
+		refunded      = models.OrderStatusRefunded
+		closed        = models.OrderStatusClosed
+	)
+	tests := []struct {
+		from, to string
+		allowed  bool
+	}{
+		{open, inPreparation, true},
+		{inPreparation, ready, true},
+		{ready, completed, true},
+		{open, ready, false},
+		{open, completed, false},
+		{inPreparation, completed, false},
+		{inPreparation, open, false},
+		{ready, inPreparation, false},
+		{open, cancelled, true},
+		{inPreparation, cancelled, true},
+		{ready, cancelled, true},
+		{completed, cancelled, false},
+		{completed, refunded, true},
+		{closed, refunded, true},
+		{open, refunded, false},
+		{cancelled, open, false},
+		{cancelled, refunded, false},
+		{refunded, completed, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+" to "+tt.to, func(t *testing.T) {
+			order := &models.Order{Status: tt.from}
+			err := transitionOrder(order, tt.to, "")
+			if tt.allowed {
+				if err != nil {
+					t.Fatalf("transitionOrder() error = %v", err)
+				}
+				if order.Status != tt.to || len(order.StatusHistory) != 1 || order.StatusHistory[0].Status != tt.to {
+					t.Errorf("order is %s with history %+v, want %s recorded", order.Status, order.StatusHistory, tt.to)
+				}
+				return
+			}
+			if errorKind(err) != KindConflict {
+				t.Fatalf("transitionOrder() error = %v, want a conflict", err)
+			}
+			if order.Status != tt.from || len(order.StatusHistory) != 0 {
+				t.Errorf("refused transition changed the order to %s with history %+v", order.Status, order.StatusHistory)
+			}
+		})
+	}
+}
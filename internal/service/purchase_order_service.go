@@ -0,0 +1,355 @@
+// internal/service/purchase_order_service.go
+package service
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"hot-coffee/internal/repository"
+	"hot-coffee/models"
+)
+
+type purchaseOrderService struct {
+	purchaseOrderRepo repository.PurchaseOrderRepository
+	supplierRepo      repository.SupplierRepository
+	inventoryRepo     repository.InventoryRepository
+	locationRepo      repository.LocationRepository
+	uow               repository.UnitOfWork
+}
+
+func NewPurchaseOrderService(purchaseOrderRepo repository.PurchaseOrderRepository, supplierRepo repository.SupplierRepository, inventoryRepo repository.InventoryRepository, locationRepo repository.LocationRepository, uow repository.UnitOfWork) PurchaseOrderService {
+	return &purchaseOrderService{
+		purchaseOrderRepo: purchaseOrderRepo,
+		supplierRepo:      supplierRepo,
+		inventoryRepo:     inventoryRepo,
+		locationRepo:      locationRepo,
+		uow:               uow,
+	}
+}
+
+// CreatePurchaseOrder places an order with a supplier for ingredients in the
+// inventory, delivered to its location or the default location. Lines may be
+// in any unit that converts to the stock unit.
+func (s *purchaseOrderService) CreatePurchaseOrder(order *models.PurchaseOrder) error {
+	if err := checkSupplierExists(s.supplierRepo, "supplier_id", order.SupplierID); err != nil {
+		return err
+	}
+	order.LocationID = locationOrDefault(order.LocationID)
+	if err := checkLocationExists(s.locationRepo, "location_id", order.LocationID); err != nil {
+		return err
+	}
+
+	for i := range order.Lines {
+		line := &order.Lines[i]
+		field := fmt.Sprintf("lines[%d]", i)
+		item, err := s.inventoryRepo.GetByID(line.IngredientID)
+		if err != nil {
+			return err
+		}
+		if item == nil || item.DeletedAt != "" {
+			return FieldError(field+".ingredient_id", "unknown ingredient: %s", line.IngredientID)
+		}
+		if line.Unit != "" && !unitConverter(item).Compatible(line.Unit, item.Unit) {
+			return FieldError(field+".unit", "unit %s does not convert to %s, the unit %s is stocked in", line.Unit, item.Unit, item.IngredientID)
+		}
+		line.ReceivedQuantity = 0
+	}
+
+	order.ID = generateID()
+	order.Status = models.PurchaseOrderStatusOpen
+	order.Receipts = nil
+	order.CreatedAt = time.Now().Format(time.RFC3339)
+	order.ClosedAt = ""
+
+	if err := s.purchaseOrderRepo.Create(order); err != nil {
+		slog.Error("Failed to create purchase order", "error", err)
+		return err
+	}
+
+	slog.Info("Purchase order created", "purchaseOrderID", order.ID, "supplierID", order.SupplierID)
+	return nil
+}
+
+func (s *purchaseOrderService) GetPurchaseOrderByID(id string) (*models.PurchaseOrder, error) {
+	order, err := s.purchaseOrderRepo.GetByID(id)
+	if err != nil {
+		slog.Error("Failed to get purchase order", "purchaseOrderID", id, "error", err)
+		return nil, err
+	}
+	if order == nil {
+		return nil, NotFoundError("purchase order not found")
+	}
+	return order, nil
+}
+
+// GetAllPurchaseOrders returns the purchase orders, only those in status when
+// it is given.
+func (s *purchaseOrderService) GetAllPurchaseOrders(status string) ([]*models.PurchaseOrder, error) {
+	orders, err := s.purchaseOrderRepo.GetAll()
+	if err != nil {
+		slog.Error("Failed to get all purchase orders", "error", err)
+		return nil, err
+	}
+	if status == "" {
+		return orders, nil
+	}
+
+	matching := orders[:0]
+	for _, order := range orders {
+		if order.Status == status {
+			matching = append(matching, order)
+		}
+	}
+	return matching, nil
+}
+
+// ReceivePurchaseOrder books a delivery against a purchase order and restocks
+// the delivered ingredients, each as a new lot. A receipt without lines
+// receives everything still outstanding. The order is received once every
+// line is delivered in full.
+func (s *purchaseOrderService) ReceivePurchaseOrder(id string, receipt *models.PurchaseOrderReceipt) (*models.PurchaseOrder, error) {
+	existing, err := s.GetPurchaseOrderByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// The lines of a purchase order never change, so their ingredients can be
+	// locked before reading it again
+	lockKeys := []string{purchaseOrderLockKey(id)}
+	for _, line := range existing.Lines {
+		lockKeys = append(lockKeys, inventoryLockKey(line.IngredientID))
+	}
+
+	var received *models.PurchaseOrder
+	err = s.uow.Execute(lockKeys, func(repos repository.Repositories) error {
+		order, err := repos.PurchaseOrders.GetByID(id)
+		if err != nil {
+			return err
+		}
+		if order == nil {
+			return NotFoundError("purchase order not found")
+		}
+		if !isOutstandingPurchaseOrder(order) {
+			return ConflictError("purchase order %s is %s", id, order.Status)
+		}
+
+		if len(receipt.Lines) == 0 {
+			for _, line := range order.Lines {
+				if outstanding := line.Quantity - line.ReceivedQuantity; outstanding > 0 {
+					receipt.Lines = append(receipt.Lines, models.ReceiptLine{IngredientID: line.IngredientID, Quantity: outstanding})
+				}
+			}
+		}
+
+		for i, receiptLine := range receipt.Lines {
+			field := fmt.Sprintf("lines[%d]", i)
+			line := findPurchaseOrderLine(order, receiptLine.IngredientID)
+			if line == nil {
+				return FieldError(field+".ingredient_id", "ingredient %s is not on purchase order %s", receiptLine.IngredientID, id)
+			}
+			outstanding := line.Quantity - line.ReceivedQuantity
+			if receiptLine.Quantity > outstanding+ledgerTolerance {
+				return FieldError(field+".quantity", "%g of %s received but only %g outstanding", receiptLine.Quantity, line.IngredientID, outstanding)
+			}
+
+			item, err := repos.Inventory.GetByID(line.IngredientID)
+			if err != nil {
+				return err
+			}
+			if item == nil || item.DeletedAt != "" {
+				return ConflictError("ingredient %s not found in inventory", line.IngredientID)
+			}
+			delta := receiptLine.Quantity
+			if line.Unit != "" {
+				delta, err = unitConverter(item).Convert(receiptLine.Quantity, line.Unit, item.Unit)
+				if err != nil {
+					return ConflictError("purchase order line %s: %v", line.IngredientID, err)
+				}
+			}
+			// The purchase price per stock unit is averaged into the unit cost
+			if line.UnitCost > 0 && delta > 0 {
+				averageInCost(item, delta, line.UnitCost*receiptLine.Quantity/delta)
+			}
+
+			movement := &models.InventoryMovement{
+				Reason:          models.MovementReasonRestock,
+				PurchaseOrderID: id,
+				LocationID:      order.LocationID,
+				Actor:           receipt.Actor,
+				Note:            receipt.Note,
+			}
+			if err := receiveStock(repos, item, delta, receiptLine.ExpiresAt, movement); err != nil {
+				return err
+			}
+			receipt.Lines[i].LotID = movement.LotID
+			line.ReceivedQuantity += receiptLine.Quantity
+		}
+
+		receipt.ReceivedAt = time.Now().Format(time.RFC3339)
+		order.Receipts = append(order.Receipts, *receipt)
+		order.Status = models.PurchaseOrderStatusReceived
+		for _, line := range order.Lines {
+			if line.Quantity-line.ReceivedQuantity > ledgerTolerance {
+				order.Status = models.PurchaseOrderStatusPartiallyReceived
+				break
+			}
+		}
+		if order.Status == models.PurchaseOrderStatusReceived {
+			order.ClosedAt = receipt.ReceivedAt
+		}
+
+		received = order
+		return repos.PurchaseOrders.Update(order)
+	})
+	if err != nil {
+		slog.Error("Failed to receive purchase order", "purchaseOrderID", id, "error", err)
+		return nil, err
+	}
+
+	slog.Info("Purchase order received", "purchaseOrderID", id, "status", received.Status)
+	return received, nil
+}
+
+// CancelPurchaseOrder closes a purchase order that is not fully received.
+// Deliveries already received stay in stock.
+func (s *purchaseOrderService) CancelPurchaseOrder(id string) (*models.PurchaseOrder, error) {
+	var cancelled *models.PurchaseOrder
+	err := s.uow.Execute([]string{purchaseOrderLockKey(id)}, func(repos repository.Repositories) error {
+		order, err := repos.PurchaseOrders.GetByID(id)
+		if err != nil {
+			return err
+		}
+		if order == nil {
+			return NotFoundError("purchase order not found")
+		}
+		if !isOutstandingPurchaseOrder(order) {
+			return ConflictError("purchase order %s is %s", id, order.Status)
+		}
+
+		order.Status = models.PurchaseOrderStatusCancelled
+		order.ClosedAt = time.Now().Format(time.RFC3339)
+		cancelled = order
+		return repos.PurchaseOrders.Update(order)
+	})
+	if err != nil {
+		slog.Error("Failed to cancel purchase order", "purchaseOrderID", id, "error", err)
+		return nil, err
+	}
+
+	slog.Info("Purchase order cancelled", "purchaseOrderID", id)
+	return cancelled, nil
+}
+
+// SuggestPurchaseOrders drafts one purchase order per supplier and location
+// for the ingredients at or below their reorder point there, bringing each up
+// to its par level, or to twice its reorder point without one. Quantities
+// still outstanding on purchase orders for a location count as stock there.
+// Ingredients without a supplier are grouped under an empty supplier ID. An
+// empty locationID covers every location.
+func (s *purchaseOrderService) SuggestPurchaseOrders(locationID string) ([]*models.SuggestedPurchaseOrder, error) {
+	locations, err := locationIDs(s.locationRepo, "location", locationID)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.inventoryRepo.GetAll()
+	if err != nil {
+		slog.Error("Failed to get inventory for purchase order suggestions", "error", err)
+		return nil, err
+	}
+	orders, err := s.purchaseOrderRepo.GetAll()
+	if err != nil {
+		slog.Error("Failed to get purchase orders for suggestions", "error", err)
+		return nil, err
+	}
+
+	type stockKey struct {
+		ingredientID string
+		locationID   string
+	}
+
+	onOrder := make(map[stockKey]float64)
+	for _, order := range orders {
+		if !isOutstandingPurchaseOrder(order) {
+			continue
+		}
+		for _, line := range order.Lines {
+			outstanding := line.Quantity - line.ReceivedQuantity
+			if outstanding <= 0 {
+				continue
+			}
+			// Lines for ingredients since deleted or changed are left out
+			item, err := s.inventoryRepo.GetByID(line.IngredientID)
+			if err != nil {
+				return nil, err
+			}
+			if item == nil {
+				continue
+			}
+			if line.Unit != "" {
+				if outstanding, err = unitConverter(item).Convert(outstanding, line.Unit, item.Unit); err != nil {
+					continue
+				}
+			}
+			onOrder[stockKey{line.IngredientID, locationOrDefault(order.LocationID)}] += outstanding
+		}
+	}
+
+	type suggestionKey struct {
+		supplierID string
+		locationID string
+	}
+
+	suggestions := []*models.SuggestedPurchaseOrder{}
+	bySupplier := make(map[suggestionKey]*models.SuggestedPurchaseOrder)
+	for _, location := range locations {
+		for _, item := range items {
+			if item.DeletedAt != "" || !isLowStock(item, location) {
+				continue
+			}
+			target := item.ParLevel
+			if target == 0 {
+				target = 2 * item.ReorderPoint
+			}
+			onHand := stockAt(item, location)
+			ordered := onOrder[stockKey{item.IngredientID, location}]
+			quantity := target - onHand - ordered
+			if quantity <= 0 {
+				continue
+			}
+
+			key := suggestionKey{item.SupplierID, location}
+			suggestion, ok := bySupplier[key]
+			if !ok {
+				suggestion = &models.SuggestedPurchaseOrder{SupplierID: item.SupplierID, LocationID: location}
+				bySupplier[key] = suggestion
+				suggestions = append(suggestions, suggestion)
+			}
+			suggestion.Lines = append(suggestion.Lines, models.SuggestedOrderLine{
+				IngredientID: item.IngredientID,
+				Name:         item.Name,
+				Quantity:     quantity,
+				Unit:         item.Unit,
+				OnHand:       onHand,
+				OnOrder:      ordered,
+				TargetLevel:  target,
+			})
+		}
+	}
+	return suggestions, nil
+}
+
+// isOutstandingPurchaseOrder reports whether deliveries are still expected
+// against a purchase order.
+func isOutstandingPurchaseOrder(order *models.PurchaseOrder) bool {
+	return order.Status == models.PurchaseOrderStatusOpen || order.Status == models.PurchaseOrderStatusPartiallyReceived
+}
+
+func findPurchaseOrderLine(order *models.PurchaseOrder, ingredientID string) *models.PurchaseOrderLine {
+	for i := range order.Lines {
+		if order.Lines[i].IngredientID == ingredientID {
+			return &order.Lines[i]
+		}
+	}
+	return nil
+}
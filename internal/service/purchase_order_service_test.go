@@ -0,0 +1,165 @@
+// internal/service/purchase_order_service_test.go
+package service
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"hot-coffee/internal/repository"
+	"hot-coffee/models"
+)
+
+func TestReceivePurchaseOrder(t *testing.T) {
+	tests := []struct {
+		name         string
+		receipts     [][]models.ReceiptLine
+		wantErrorOn  string
+		wantStatus   string
+		wantReceived float64
+		wantQuantity float64
+		wantLots     int
+		wantUnitCost float64
+	}{
+		{
+			name:         "a receipt without lines receives everything outstanding",
+			receipts:     [][]models.ReceiptLine{nil},
+			wantStatus:   models.PurchaseOrderStatusReceived,
+			wantReceived: 2,
+			wantQuantity: 3000,
+			wantLots:     1,
+			wantUnitCost: (1000*0.002 + 2000*0.003) / 3000,
+		},
+		{
+			name:         "a partial delivery leaves the order open",
+			receipts:     [][]models.ReceiptLine{{{IngredientID: "milk", Quantity: 0.5}}},
+			wantStatus:   models.PurchaseOrderStatusPartiallyReceived,
+			wantReceived: 0.5,
+			wantQuantity: 1500,
+			wantLots:     1,
+			wantUnitCost: (1000*0.002 + 500*0.003) / 1500,
+		},
+		{
+			name:         "the rest delivered later, each delivery as a lot",
+			receipts:     [][]models.ReceiptLine{{{IngredientID: "milk", Quantity: 0.5}}, nil},
+			wantStatus:   models.PurchaseOrderStatusReceived,
+			wantReceived: 2,
+			wantQuantity: 3000,
+			wantLots:     2,
+			wantUnitCost: (1000*0.002 + 2000*0.003) / 3000,
+		},
+		{
+			name:         "more than is outstanding",
+			receipts:     [][]models.ReceiptLine{{{IngredientID: "milk", Quantity: 1.5}}, {{IngredientID: "milk", Quantity: 1}}},
+			wantErrorOn:  "lines[0].quantity",
+			wantStatus:   models.PurchaseOrderStatusPartiallyReceived,
+			wantReceived: 1.5,
+			wantQuantity: 2500,
+			wantLots:     1,
+			wantUnitCost: (1000*0.002 + 1500*0.003) / 2500,
+		},
+		{
+			name:         "an ingredient that was not ordered",
+			receipts:     [][]models.ReceiptLine{{{IngredientID: "milk", Quantity: 1}, {IngredientID: "coffee", Quantity: 1}}},
+			wantErrorOn:  "lines[1].ingredient_id",
+			wantStatus:   models.PurchaseOrderStatusOpen,
+			wantQuantity: 1000,
+			wantUnitCost: 0.002,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := newTestRepositories(t)
+			addStock(t, repos, "milk", "ml", 1000, 0.002)
+			addStock(t, repos, "coffee", "g", 1000, 0.02)
+			if err := repos.Suppliers.Create(&models.Supplier{ID: "dairy", Name: "Dairy"}); err != nil {
+				t.Fatal(err)
+			}
+			s := NewPurchaseOrderService(repos.PurchaseOrders, repos.Suppliers, repos.Inventory, repos.Locations, repository.NewUnitOfWork(repos))
+
+			order := &models.PurchaseOrder{
+				SupplierID: "dairy",
+				Lines:      []models.PurchaseOrderLine{{IngredientID: "milk", Quantity: 2, Unit: "l", UnitCost: 3}},
+			}
+			if err := s.CreatePurchaseOrder(order); err != nil {
+				t.Fatal(err)
+			}
+
+			var err error
+			for _, lines := range tt.receipts {
+				if _, err = s.ReceivePurchaseOrder(order.ID, &models.PurchaseOrderReceipt{Lines: lines}); err != nil {
+					break
+				}
+			}
+			if tt.wantErrorOn != "" {
+				var serviceErr *Error
+				if !errors.As(err, &serviceErr) || serviceErr.Kind != KindValidation || serviceErr.Details[0].Field != tt.wantErrorOn {
+					t.Fatalf("ReceivePurchaseOrder() error = %v, want a validation error on %s", err, tt.wantErrorOn)
+				}
+			} else if err != nil {
+				t.Fatalf("ReceivePurchaseOrder() error = %v", err)
+			}
+
+			// A refused receipt changes nothing
+			stored, err := s.GetPurchaseOrderByID(order.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stored.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", stored.Status, tt.wantStatus)
+			}
+			if got := stored.Lines[0].ReceivedQuantity; got != tt.wantReceived {
+				t.Errorf("received %g l, want %g l", got, tt.wantReceived)
+			}
+			if (stored.ClosedAt != "") != (tt.wantStatus == models.PurchaseOrderStatusReceived) {
+				t.Errorf("closed at %q with status %s", stored.ClosedAt, stored.Status)
+			}
+
+			milk, err := repos.Inventory.GetByID("milk")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if milk.Quantity != tt.wantQuantity {
+				t.Errorf("milk = %g ml, want %g ml", milk.Quantity, tt.wantQuantity)
+			}
+			if math.Abs(milk.UnitCost-tt.wantUnitCost) > 1e-9 {
+				t.Errorf("milk unit cost = %g, want %g", milk.UnitCost, tt.wantUnitCost)
+			}
+			if len(milk.Lots) != tt.wantLots {
+				t.Fatalf("milk has %d lots, want %d", len(milk.Lots), tt.wantLots)
+			}
+			for _, lot := range milk.Lots {
+				if lot.PurchaseOrderID != order.ID {
+					t.Errorf("lot %s comes from purchase order %q, want %s", lot.ID, lot.PurchaseOrderID, order.ID)
+				}
+			}
+			if got := quantityOf(t, repos, "coffee"); got != 1000 {
+				t.Errorf("coffee = %g g, want 1000 g left alone", got)
+			}
+		})
+	}
+}
+
+func TestReceiveClosedPurchaseOrder(t *testing.T) {
+	repos := newTestRepositories(t)
+	addStock(t, repos, "milk", "ml", 1000, 0.002)
+	if err := repos.Suppliers.Create(&models.Supplier{ID: "dairy", Name: "Dairy"}); err != nil {
+		t.Fatal(err)
+	}
+	s := NewPurchaseOrderService(repos.PurchaseOrders, repos.Suppliers, repos.Inventory, repos.Locations, repository.NewUnitOfWork(repos))
+	order := &models.PurchaseOrder{SupplierID: "dairy", Lines: []models.PurchaseOrderLine{{IngredientID: "milk", Quantity: 500}}}
+	if err := s.CreatePurchaseOrder(order); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CancelPurchaseOrder(order.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.ReceivePurchaseOrder(order.ID, &models.PurchaseOrderReceipt{}); errorKind(err) != KindConflict {
+		t.Fatalf("ReceivePurchaseOrder() error = %v, want a conflict", err)
+	}
+	if got := quantityOf(t, repos, "milk"); got != 1000 {
+		t.Errorf("milk = %g ml, want 1000 ml", got)
+	}
+}
@@ -0,0 +1,265 @@
+// internal/service/references.go
+package service
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"hot-coffee/internal/repository"
+	"hot-coffee/models"
+)
+
+// ingredientReference is a place in a menu item that refers to an inventory
+// ingredient. field is the request field of the reference and usage describes
+// it for where-used listings. unit is the unit of a recipe quantity, and
+// substitutes the ingredient a substitution option replaces.
+type ingredientReference struct {
+	ingredientID string
+	field        string
+	usage        string
+	unit         string
+	substitutes  string
+}
+
+// ingredientReferences lists every ingredient a menu item refers to, in its
+// recipe, variants, modifiers and substitution options.
+func ingredientReferences(item *models.MenuItem) []ingredientReference {
+	var refs []ingredientReference
+	for i, ingredient := range item.Ingredients {
+		refs = append(refs, ingredientReference{
+			ingredientID: ingredient.IngredientID,
+			field:        fmt.Sprintf("ingredients[%d]", i),
+			usage:        "recipe",
+			unit:         ingredient.Unit,
+		})
+	}
+	for i, variant := range item.Variants {
+		for j, ingredient := range variant.Ingredients {
+			refs = append(refs, ingredientReference{
+				ingredientID: ingredient.IngredientID,
+				field:        fmt.Sprintf("variants[%d].ingredients[%d]", i, j),
+				usage:        "variant:" + variant.ID,
+				unit:         ingredient.Unit,
+			})
+		}
+	}
+	for i, modifier := range item.Modifiers {
+		for j, ingredient := range modifier.Ingredients {
+			refs = append(refs, ingredientReference{
+				ingredientID: ingredient.IngredientID,
+				field:        fmt.Sprintf("modifiers[%d].ingredients[%d]", i, j),
+				usage:        "modifier:" + modifier.ID,
+				unit:         ingredient.Unit,
+			})
+		}
+	}
+	for i, group := range item.SubstitutionGroups {
+		for j, option := range group.Options {
+			refs = append(refs, ingredientReference{
+				ingredientID: option.IngredientID,
+				field:        fmt.Sprintf("substitution_groups[%d].options[%d]", i, j),
+				usage:        "substitution:" + group.ID,
+				substitutes:  group.IngredientID,
+			})
+		}
+	}
+	return refs
+}
+
+// ingredientLockKeys returns the lock keys of every ingredient a menu item
+// refers to, which saving it has to hold so that none of them is deleted
+// meanwhile.
+func ingredientLockKeys(item *models.MenuItem) []string {
+	var keys []string
+	for _, ref := range ingredientReferences(item) {
+		keys = append(keys, inventoryLockKey(ref.ingredientID))
+	}
+	return keys
+}
+
+// menuItemsReferringTo returns the IDs of the menu items that refer to an
+// ingredient, deleted or not.
+func menuItemsReferringTo(menuRepo repository.MenuRepository, ingredientID string) ([]string, error) {
+	items, err := menuRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, item := range items {
+		if menuItemReferenceTo(item, ingredientID) != nil {
+			ids = append(ids, item.ID)
+		}
+	}
+	return ids, nil
+}
+
+// checkIngredientReferences rejects a menu item that refers to ingredients
+// missing from the inventory or deleted from it, or whose recipe quantities
+// are in units that do not convert to the units the ingredients are stocked in.
+func checkIngredientReferences(inventoryRepo repository.InventoryRepository, item *models.MenuItem) error {
+	refs := ingredientReferences(item)
+	stock := newStockUnits(inventoryRepo)
+
+	var message string
+	var details []models.ErrorDetail
+	for _, ref := range refs {
+		inventoryItem, err := stock.item(ref.ingredientID)
+		if err != nil {
+			return err
+		}
+		if inventoryItem == nil || inventoryItem.DeletedAt != "" {
+			if message == "" {
+				message = "menu item refers to unknown ingredient " + ref.ingredientID
+			}
+			details = append(details, models.ErrorDetail{
+				Field:        ref.field + ".ingredient_id",
+				Message:      "unknown ingredient: " + ref.ingredientID,
+				IngredientID: ref.ingredientID,
+			})
+			continue
+		}
+
+		converter := unitConverter(inventoryItem)
+		if ref.unit != "" && !converter.Compatible(ref.unit, inventoryItem.Unit) {
+			details = append(details, models.ErrorDetail{
+				Field:        ref.field + ".unit",
+				Message:      fmt.Sprintf("unit %s does not convert to %s, the unit %s is stocked in", ref.unit, inventoryItem.Unit, ref.ingredientID),
+				IngredientID: ref.ingredientID,
+				Unit:         ref.unit,
+			})
+		}
+		if ref.substitutes == "" {
+			continue
+		}
+
+		// An option takes over the replaced ingredient's recipe quantities as they are
+		for _, unit := range substitutedUnits(refs, ref.substitutes, stock) {
+			if !converter.Compatible(unit, inventoryItem.Unit) {
+				details = append(details, models.ErrorDetail{
+					Field:        ref.field + ".ingredient_id",
+					Message:      fmt.Sprintf("%s is stocked in %s and cannot replace %s measured in %s", ref.ingredientID, inventoryItem.Unit, ref.substitutes, unit),
+					IngredientID: ref.ingredientID,
+					Unit:         unit,
+				})
+				break
+			}
+		}
+	}
+	if len(details) == 0 {
+		return nil
+	}
+
+	if message == "" {
+		message = details[0].Message
+	}
+	return &Error{
+		Kind:    KindValidation,
+		Message: message,
+		Details: details,
+	}
+}
+
+// substitutedUnits lists the units the recipes of a menu item measure an
+// ingredient in, taking the stock unit for quantities without a unit.
+func substitutedUnits(refs []ingredientReference, ingredientID string, stock *stockUnits) []string {
+	var result []string
+	for _, ref := range refs {
+		if ref.ingredientID != ingredientID || ref.substitutes != "" || strings.HasPrefix(ref.usage, "modifier:") {
+			continue
+		}
+		unit := ref.unit
+		if unit == "" {
+			if item, err := stock.item(ingredientID); err == nil && item != nil {
+				unit = item.Unit
+			}
+		}
+		if unit != "" && !slices.Contains(result, unit) {
+			result = append(result, unit)
+		}
+	}
+	return result
+}
+
+// menuItemReferenceTo returns how a menu item uses an ingredient, or nil if it
+// does not use it.
+func menuItemReferenceTo(item *models.MenuItem, ingredientID string) *models.MenuItemReference {
+	var usedIn []string
+	for _, ref := range ingredientReferences(item) {
+		if ref.ingredientID == ingredientID && !slices.Contains(usedIn, ref.usage) {
+			usedIn = append(usedIn, ref.usage)
+		}
+	}
+	if usedIn == nil {
+		return nil
+	}
+	return &models.MenuItemReference{ProductID: item.ID, Name: item.Name, UsedIn: usedIn}
+}
+
+// recipeUses reports whether the recipe or a variant recipe of a menu item
+// needs an ingredient, as opposed to offering it as a modifier or option.
+func recipeUses(item *models.MenuItem, ingredientID string) bool {
+	for _, ref := range ingredientReferences(item) {
+		if ref.ingredientID == ingredientID && (ref.usage == "recipe" || strings.HasPrefix(ref.usage, "variant:")) {
+			return true
+		}
+	}
+	return false
+}
+
+// removeIngredientOptions drops the modifiers and substitution options of a
+// menu item that use an ingredient, and the substitution groups left without
+// options.
+func removeIngredientOptions(item *models.MenuItem, ingredientID string) {
+	item.Modifiers = slices.DeleteFunc(item.Modifiers, func(modifier models.MenuModifier) bool {
+		return slices.ContainsFunc(modifier.Ingredients, func(ingredient models.MenuItemIngredient) bool {
+			return ingredient.IngredientID == ingredientID
+		})
+	})
+	for i := range item.SubstitutionGroups {
+		group := &item.SubstitutionGroups[i]
+		group.Options = slices.DeleteFunc(group.Options, func(option models.SubstitutionOption) bool {
+			return option.IngredientID == ingredientID
+		})
+	}
+	item.SubstitutionGroups = slices.DeleteFunc(item.SubstitutionGroups, func(group models.SubstitutionGroup) bool {
+		return len(group.Options) == 0
+	})
+}
+
+// isActiveOrder reports whether an order is still being worked on and so
+// depends on its products and ingredients.
+func isActiveOrder(order *models.Order) bool {
+	switch order.Status {
+	case models.OrderStatusOpen, models.OrderStatusInPreparation, models.OrderStatusReady:
+		return true
+	default:
+		return false
+	}
+}
+
+func orderUsesProduct(order *models.Order, productID string) bool {
+	for _, item := range order.Items {
+		if item.ProductID == productID {
+			return true
+		}
+	}
+	return false
+}
+
+func toOrderReference(order *models.Order) models.OrderReference {
+	return models.OrderReference{OrderID: order.ID, CustomerName: order.CustomerName, Status: order.Status}
+}
+
+// referenceConflict builds the error returned when deleting a record that is
+// still referenced.
+func referenceConflict(message string, menuItems []models.MenuItemReference, orders []models.OrderReference) *Error {
+	details := make([]models.ErrorDetail, 0, len(menuItems)+len(orders))
+	for _, item := range menuItems {
+		details = append(details, models.ErrorDetail{Field: "menu_item", Message: "used by menu item " + item.ProductID})
+	}
+	for _, order := range orders {
+		details = append(details, models.ErrorDetail{Field: "order", Message: fmt.Sprintf("used by %s order %s", order.Status, order.OrderID)})
+	}
+	return &Error{Kind: KindConflict, Message: message, Details: details}
+}
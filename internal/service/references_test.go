@@ -0,0 +1,159 @@
+// internal/service/references_test.go
+package service
+
+import (
+	"testing"
+
+	"hot-coffee/models"
+)
+
+func TestDeleteInventoryItem(t *testing.T) {
+	tests := []struct {
+		name     string
+		cascade  bool
+		ordered  bool
+		wantKind ErrorKind
+		// wantMilk is "kept", "soft" or "removed"
+		wantMilk        string
+		wantLatte       string
+		wantModifiers   int
+		wantSubstitutes int
+	}{
+		{name: "refused while in use", wantKind: KindConflict, wantMilk: "kept", wantLatte: "kept", wantModifiers: 1, wantSubstitutes: 1},
+		{name: "cascade", cascade: true, wantMilk: "removed", wantLatte: "soft"},
+		{name: "cascade keeps an ingredient an order used", cascade: true, ordered: true, wantMilk: "soft", wantLatte: "soft"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := newTestRepositories(t)
+			addStock(t, repos, "milk", "ml", 1000, 0)
+			addStock(t, repos, "coffee", "g", 1000, 0)
+			addStock(t, repos, "soy_milk", "ml", 1000, 0)
+			addMenuItem(t, repos, &models.MenuItem{
+				ID:          "latte",
+				Name:        "Latte",
+				Ingredients: []models.MenuItemIngredient{{IngredientID: "milk", Quantity: 200}},
+			})
+			// Espresso only offers milk as a modifier, chai as a substitute
+			addMenuItem(t, repos, &models.MenuItem{
+				ID:          "espresso",
+				Name:        "Espresso",
+				Ingredients: []models.MenuItemIngredient{{IngredientID: "coffee", Quantity: 18}},
+				Modifiers: []models.MenuModifier{{
+					ID:          "splash",
+					Name:        "Splash of milk",
+					Ingredients: []models.MenuItemIngredient{{IngredientID: "milk", Quantity: 20}},
+				}},
+			})
+			addMenuItem(t, repos, &models.MenuItem{
+				ID:          "chai",
+				Name:        "Chai",
+				Ingredients: []models.MenuItemIngredient{{IngredientID: "soy_milk", Quantity: 200}},
+				SubstitutionGroups: []models.SubstitutionGroup{{
+					ID:           "milk_choice",
+					Name:         "Milk",
+					IngredientID: "soy_milk",
+					Options:      []models.SubstitutionOption{{IngredientID: "milk", Name: "Dairy"}},
+				}},
+			})
+			if tt.ordered {
+				order := &models.Order{CustomerName: "Ann", Items: []models.OrderItem{{ProductID: "latte", Quantity: 1}}}
+				if err := newTestOrderService(repos).CreateOrder(order); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := newTestInventoryService(repos).DeleteInventoryItem("milk", tt.cascade, "")
+			if errorKind(err) != tt.wantKind || (tt.wantKind == "" && err != nil) {
+				t.Fatalf("DeleteInventoryItem() error = %v, want kind %q", err, tt.wantKind)
+			}
+
+			milk, err := repos.Inventory.GetByID("milk")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := deletionState(milk != nil, milk != nil && milk.DeletedAt != ""); got != tt.wantMilk {
+				t.Errorf("milk is %s, want %s", got, tt.wantMilk)
+			}
+			latte, err := repos.Menu.GetByID("latte")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := deletionState(latte != nil, latte != nil && latte.DeletedAt != ""); got != tt.wantLatte {
+				t.Errorf("latte is %s, want %s", got, tt.wantLatte)
+			}
+
+			// Menu items offering the ingredient as an option keep their recipes
+			espresso, err := repos.Menu.GetByID("espresso")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if espresso.DeletedAt != "" || len(espresso.Modifiers) != tt.wantModifiers {
+				t.Errorf("espresso deleted at %q with %d modifiers, want kept with %d", espresso.DeletedAt, len(espresso.Modifiers), tt.wantModifiers)
+			}
+			chai, err := repos.Menu.GetByID("chai")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if chai.DeletedAt != "" || len(chai.SubstitutionGroups) != tt.wantSubstitutes {
+				t.Errorf("chai deleted at %q with %d substitution groups, want kept with %d", chai.DeletedAt, len(chai.SubstitutionGroups), tt.wantSubstitutes)
+			}
+		})
+	}
+}
+
+func deletionState(exists, soft bool) string {
+	switch {
+	case !exists:
+		return "removed"
+	case soft:
+		return "soft"
+	default:
+		return "kept"
+	}
+}
+
+func TestSaveMenuItemChecksIngredients(t *testing.T) {
+	tests := []struct {
+		name       string
+		ingredient string
+		wantKind   ErrorKind
+	}{
+		{name: "stocked ingredient", ingredient: "milk"},
+		{name: "unknown ingredient", ingredient: "cream", wantKind: KindValidation},
+		{name: "deleted ingredient", ingredient: "old_milk", wantKind: KindValidation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := newTestRepositories(t)
+			addStock(t, repos, "milk", "ml", 1000, 0)
+			addStock(t, repos, "old_milk", "ml", 0, 0)
+			oldMilk, err := repos.Inventory.GetByID("old_milk")
+			if err != nil {
+				t.Fatal(err)
+			}
+			oldMilk.DeletedAt = "2026-01-01T00:00:00Z"
+			if err := repos.Inventory.Update(oldMilk); err != nil {
+				t.Fatal(err)
+			}
+			s := newTestMenuService(repos)
+
+			item := &models.MenuItem{Name: "Latte", Ingredients: []models.MenuItemIngredient{{IngredientID: tt.ingredient, Quantity: 200}}}
+			err = s.CreateMenuItem(item)
+			if errorKind(err) != tt.wantKind || (tt.wantKind == "" && err != nil) {
+				t.Fatalf("CreateMenuItem() error = %v, want kind %q", err, tt.wantKind)
+			}
+
+			// An update is checked the same way
+			stored := &models.MenuItem{ID: "tea", Name: "Tea", Ingredients: []models.MenuItemIngredient{{IngredientID: "milk", Quantity: 20}}}
+			addMenuItem(t, repos, stored)
+			stored.Ingredients[0].IngredientID = tt.ingredient
+			err = s.UpdateMenuItem(stored)
+			if errorKind(err) != tt.wantKind || (tt.wantKind == "" && err != nil) {
+				t.Fatalf("UpdateMenuItem() error = %v, want kind %q", err, tt.wantKind)
+			}
+		})
+	}
+}
@@ -2,78 +2,539 @@
 package service
 
 import (
+	"cmp"
 	"log/slog"
+	"slices"
+	"time"
 
 	"hot-coffee/internal/repository"
 	"hot-coffee/models"
 )
 
 type reportsService struct {
-	orderRepo repository.OrderRepository
-	menuRepo  repository.MenuRepository
+	orderRepo      repository.OrderRepository
+	menuRepo       repository.MenuRepository
+	inventoryRepo  repository.InventoryRepository
+	movementRepo   repository.MovementRepository
+	stockCountRepo repository.StockCountRepository
+	categoryRepo   repository.CategoryRepository
 }
 
-func NewReportsService(orderRepo repository.OrderRepository, menuRepo repository.MenuRepository) ReportsService {
+func NewReportsService(orderRepo repository.OrderRepository, menuRepo repository.MenuRepository, inventoryRepo repository.InventoryRepository, movementRepo repository.MovementRepository, stockCountRepo repository.StockCountRepository, categoryRepo repository.CategoryRepository) ReportsService {
 	return &reportsService{
-		orderRepo: orderRepo,
-		menuRepo:  menuRepo,
+		orderRepo:      orderRepo,
+		menuRepo:       menuRepo,
+		inventoryRepo:  inventoryRepo,
+		movementRepo:   movementRepo,
+		stockCountRepo: stockCountRepo,
+		categoryRepo:   categoryRepo,
 	}
 }
 
-func (s *reportsService) GetTotalSales() (*models.TotalSalesResponse, error) {
+// GetTotalSales sums the completed orders at a location, or at all locations
+// when locationID is empty.
+func (s *reportsService) GetTotalSales(locationID string) (*models.TotalSalesResponse, error) {
 	orders, err := s.orderRepo.GetAll()
 	if err != nil {
 		slog.Error("Failed to get orders for total sales", "error", err)
 		return nil, err
 	}
 
+	// Orders placed before price snapshots existed fall back to the current menu
+	menu, err := s.menuByID()
+	if err != nil {
+		slog.Error("Failed to get menu items for total sales", "error", err)
+		return nil, err
+	}
+
 	var totalSales float64
 	for _, order := range orders {
-		if order.Status == "closed" {
-			for _, orderItem := range order.Items {
-				menuItem, err := s.menuRepo.GetByID(orderItem.ProductID)
-				if err != nil {
-					continue
-				}
-				if menuItem != nil {
-					totalSales += menuItem.Price * float64(orderItem.Quantity)
-				}
-			}
+		if !isCompletedOrder(order) || !atLocation(order.LocationID, locationID) {
+			continue
+		}
+		for _, orderItem := range order.Items {
+			totalSales += lineRevenue(orderItem, menu)
 		}
 	}
 
-	return &models.TotalSalesResponse{TotalSales: totalSales}, nil
+	return &models.TotalSalesResponse{LocationID: locationID, TotalSales: roundMoney(totalSales)}, nil
 }
 
-func (s *reportsService) GetPopularItems() (*models.PopularItemsResponse, error) {
+// GetPopularItems counts units sold per product, or per product variant when
+// byVariant is set, at a location or at all locations when locationID is empty.
+func (s *reportsService) GetPopularItems(byVariant bool, locationID string) (*models.PopularItemsResponse, error) {
 	orders, err := s.orderRepo.GetAll()
 	if err != nil {
 		slog.Error("Failed to get orders for popular items", "error", err)
 		return nil, err
 	}
 
-	itemCounts := make(map[string]int)
+	menu, err := s.menuByID()
+	if err != nil {
+		slog.Error("Failed to get menu items for popular items", "error", err)
+		return nil, err
+	}
+
+	type itemKey struct {
+		productID string
+		variantID string
+	}
+
+	itemCounts := make(map[itemKey]*models.PopularItem)
+	var keys []itemKey
 	for _, order := range orders {
-		if order.Status == "closed" {
-			for _, orderItem := range order.Items {
-				itemCounts[orderItem.ProductID] += orderItem.Quantity
+		if !isCompletedOrder(order) || !atLocation(order.LocationID, locationID) {
+			continue
+		}
+
+		for _, orderItem := range order.Items {
+			key := itemKey{productID: orderItem.ProductID}
+			if byVariant {
+				key.variantID = orderItem.VariantID
+			}
+
+			item, ok := itemCounts[key]
+			if !ok {
+				item = &models.PopularItem{ProductID: key.productID, VariantID: key.variantID}
+				itemCounts[key] = item
+				keys = append(keys, key)
+			}
+			item.TotalOrders += orderItem.Quantity
+
+			if orderItem.ProductName != "" {
+				item.Name = orderItem.ProductName
+			}
+			if byVariant && orderItem.VariantName != "" {
+				item.VariantName = orderItem.VariantName
+			}
+		}
+	}
+
+	popularItems := make([]models.PopularItem, 0, len(keys))
+	for _, key := range keys {
+		item := itemCounts[key]
+		if item.Name == "" {
+			// Legacy orders without a snapshot use the current menu name,
+			// and the product ID if the product no longer exists
+			item.Name = key.productID
+			if menuItem, ok := menu[key.productID]; ok {
+				item.Name = menuItem.Name
 			}
 		}
+		popularItems = append(popularItems, *item)
+	}
+
+	slices.SortStableFunc(popularItems, func(a, b models.PopularItem) int {
+		return b.TotalOrders - a.TotalOrders
+	})
+
+	return &models.PopularItemsResponse{LocationID: locationID, Items: popularItems}, nil
+}
+
+// GetWasteReport totals the waste movements created within [from, to] per
+// ingredient and per reason, valued at the unit cost when they were recorded.
+// A zero from or to leaves that end of the range open, and an empty locationID
+// covers all locations.
+func (s *reportsService) GetWasteReport(from, to time.Time, locationID string) (*models.WasteReport, error) {
+	movements, err := s.movementRepo.GetAll()
+	if err != nil {
+		slog.Error("Failed to get inventory movements for waste report", "error", err)
+		return nil, err
+	}
+
+	report := &models.WasteReport{
+		LocationID:  locationID,
+		Ingredients: []models.WastedIngredient{},
+		Reasons:     []models.WasteByReason{},
+	}
+	if !from.IsZero() {
+		report.From = from.Format(time.RFC3339)
+	}
+	if !to.IsZero() {
+		report.To = to.Format(time.RFC3339)
 	}
 
-	var popularItems []models.PopularItem
-	for productID, count := range itemCounts {
-		menuItem, err := s.menuRepo.GetByID(productID)
-		if err != nil || menuItem == nil {
+	ingredients := make(map[string]*models.WastedIngredient)
+	reasons := make(map[string]*models.WasteByReason)
+	var ingredientIDs, reasonNames []string
+	for _, movement := range movements {
+		if movement.Reason != models.MovementReasonWaste || !atLocation(movement.LocationID, locationID) {
 			continue
 		}
+		createdAt, err := time.Parse(time.RFC3339, movement.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if (!from.IsZero() && createdAt.Before(from)) || (!to.IsZero() && createdAt.After(to)) {
+			continue
+		}
+
+		quantity := -movement.Delta
+		cost := quantity * movement.UnitCost
 
-		popularItems = append(popularItems, models.PopularItem{
-			ProductID:   productID,
-			Name:        menuItem.Name,
-			TotalOrders: count,
+		ingredient, ok := ingredients[movement.IngredientID]
+		if !ok {
+			ingredient = &models.WastedIngredient{IngredientID: movement.IngredientID, Name: movement.IngredientID}
+			ingredients[movement.IngredientID] = ingredient
+			ingredientIDs = append(ingredientIDs, movement.IngredientID)
+		}
+		ingredient.Quantity += quantity
+		ingredient.Cost += cost
+
+		// Waste recorded before reasons were tracked counts as other
+		reasonName := movement.WasteReason
+		if reasonName == "" {
+			reasonName = models.WasteReasonOther
+		}
+		reason, ok := reasons[reasonName]
+		if !ok {
+			reason = &models.WasteByReason{Reason: reasonName}
+			reasons[reasonName] = reason
+			reasonNames = append(reasonNames, reasonName)
+		}
+		reason.Entries++
+		reason.Cost += cost
+
+		report.TotalCost += cost
+	}
+
+	for _, id := range ingredientIDs {
+		ingredient := ingredients[id]
+		item, err := s.inventoryRepo.GetByID(id)
+		if err != nil {
+			return nil, err
+		}
+		if item != nil {
+			ingredient.Name = item.Name
+			ingredient.Unit = item.Unit
+		}
+		ingredient.Cost = roundMoney(ingredient.Cost)
+		report.Ingredients = append(report.Ingredients, *ingredient)
+	}
+	for _, name := range reasonNames {
+		reason := reasons[name]
+		reason.Cost = roundMoney(reason.Cost)
+		report.Reasons = append(report.Reasons, *reason)
+	}
+	report.TotalCost = roundMoney(report.TotalCost)
+
+	// Costliest first
+	slices.SortStableFunc(report.Ingredients, func(a, b models.WastedIngredient) int {
+		return cmp.Compare(b.Cost, a.Cost)
+	})
+	slices.SortStableFunc(report.Reasons, func(a, b models.WasteByReason) int {
+		return cmp.Compare(b.Cost, a.Cost)
+	})
+	return report, nil
+}
+
+// GetShrinkageReport totals the variances of the stock counts committed within
+// [from, to] per ingredient, listing each count so shrinkage can be followed
+// over time. A zero from or to leaves that end of the range open, and an empty
+// locationID covers all locations.
+func (s *reportsService) GetShrinkageReport(from, to time.Time, locationID string) (*models.ShrinkageReport, error) {
+	counts, err := s.stockCountRepo.GetAll()
+	if err != nil {
+		slog.Error("Failed to get stock counts for shrinkage report", "error", err)
+		return nil, err
+	}
+
+	report := &models.ShrinkageReport{LocationID: locationID, Ingredients: []models.IngredientShrinkage{}}
+	if !from.IsZero() {
+		report.From = from.Format(time.RFC3339)
+	}
+	if !to.IsZero() {
+		report.To = to.Format(time.RFC3339)
+	}
+
+	byIngredient := make(map[string]*models.IngredientShrinkage)
+	var ingredientIDs []string
+	for _, count := range counts {
+		if count.Status != models.StockCountStatusCommitted || !atLocation(count.LocationID, locationID) {
+			continue
+		}
+		closedAt, err := time.Parse(time.RFC3339, count.ClosedAt)
+		if err != nil {
+			return nil, err
+		}
+		if (!from.IsZero() && closedAt.Before(from)) || (!to.IsZero() && closedAt.After(to)) {
+			continue
+		}
+
+		for _, line := range count.Lines {
+			if line.Counted == nil {
+				continue
+			}
+			ingredient, ok := byIngredient[line.IngredientID]
+			if !ok {
+				ingredient = &models.IngredientShrinkage{IngredientID: line.IngredientID}
+				byIngredient[line.IngredientID] = ingredient
+				ingredientIDs = append(ingredientIDs, line.IngredientID)
+			}
+			// The latest count names the ingredient
+			ingredient.Name = line.Name
+			ingredient.Unit = line.Unit
+			ingredient.Variance += line.Variance
+			ingredient.VarianceCost += line.VarianceCost
+			ingredient.Counts = append(ingredient.Counts, models.ShrinkagePoint{
+				CountID:      count.ID,
+				CountedAt:    line.CountedAt,
+				Expected:     line.Expected,
+				Counted:      *line.Counted,
+				Variance:     line.Variance,
+				VarianceCost: line.VarianceCost,
+			})
+			report.VarianceCost += line.VarianceCost
+		}
+	}
+
+	for _, id := range ingredientIDs {
+		ingredient := byIngredient[id]
+		ingredient.VarianceCost = roundMoney(ingredient.VarianceCost)
+		slices.SortStableFunc(ingredient.Counts, func(a, b models.ShrinkagePoint) int {
+			return cmp.Compare(a.CountedAt, b.CountedAt)
 		})
+		report.Ingredients = append(report.Ingredients, *ingredient)
+	}
+	report.VarianceCost = roundMoney(report.VarianceCost)
+
+	// Largest loss first
+	slices.SortStableFunc(report.Ingredients, func(a, b models.IngredientShrinkage) int {
+		return cmp.Compare(a.VarianceCost, b.VarianceCost)
+	})
+	return report, nil
+}
+
+// GetMarginReport totals the revenue and cost of goods of the completed orders
+// placed within [from, to] per product, or per product variant when byVariant
+// is set. Costs are those stamped on the order lines when they were sold, so
+// later changes to unit costs leave the report unchanged; orders sold before
+// costs were stamped count as costing nothing. A zero from or to leaves that
+// end of the range open, and an empty locationID covers all locations.
+func (s *reportsService) GetMarginReport(from, to time.Time, byVariant bool, locationID string) (*models.MarginReport, error) {
+	orders, err := s.orderRepo.GetAll()
+	if err != nil {
+		slog.Error("Failed to get orders for margin report", "error", err)
+		return nil, err
 	}
 
-	return &models.PopularItemsResponse{Items: popularItems}, nil
+	menu, err := s.menuByID()
+	if err != nil {
+		slog.Error("Failed to get menu items for margin report", "error", err)
+		return nil, err
+	}
+
+	report := &models.MarginReport{LocationID: locationID, Items: []models.ProductMargin{}}
+	if !from.IsZero() {
+		report.From = from.Format(time.RFC3339)
+	}
+	if !to.IsZero() {
+		report.To = to.Format(time.RFC3339)
+	}
+
+	type itemKey struct {
+		productID string
+		variantID string
+	}
+
+	byItem := make(map[itemKey]*models.ProductMargin)
+	var keys []itemKey
+	for _, order := range orders {
+		if !isCompletedOrder(order) || !atLocation(order.LocationID, locationID) {
+			continue
+		}
+		createdAt, err := time.Parse(time.RFC3339, order.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if (!from.IsZero() && createdAt.Before(from)) || (!to.IsZero() && createdAt.After(to)) {
+			continue
+		}
+
+		for _, orderItem := range order.Items {
+			key := itemKey{productID: orderItem.ProductID}
+			if byVariant {
+				key.variantID = orderItem.VariantID
+			}
+
+			item, ok := byItem[key]
+			if !ok {
+				item = &models.ProductMargin{ProductID: key.productID, VariantID: key.variantID}
+				byItem[key] = item
+				keys = append(keys, key)
+			}
+			if orderItem.ProductName != "" {
+				item.Name = orderItem.ProductName
+			}
+			if byVariant && orderItem.VariantName != "" {
+				item.VariantName = orderItem.VariantName
+			}
+
+			revenue := lineRevenue(orderItem, menu)
+			item.Quantity += orderItem.Quantity
+			item.Revenue += revenue
+			item.CostOfGoods += orderItem.LineCost
+			report.Revenue += revenue
+			report.CostOfGoods += orderItem.LineCost
+		}
+	}
+
+	for _, key := range keys {
+		item := byItem[key]
+		if item.Name == "" {
+			item.Name = key.productID
+			if menuItem, ok := menu[key.productID]; ok {
+				item.Name = menuItem.Name
+			}
+		}
+		item.Margin, item.MarginPercent = margin(item.Revenue, item.CostOfGoods)
+		item.Revenue = roundMoney(item.Revenue)
+		item.CostOfGoods = roundMoney(item.CostOfGoods)
+		report.Items = append(report.Items, *item)
+	}
+	report.Margin, report.MarginPercent = margin(report.Revenue, report.CostOfGoods)
+	report.Revenue = roundMoney(report.Revenue)
+	report.CostOfGoods = roundMoney(report.CostOfGoods)
+
+	// Largest contribution first
+	slices.SortStableFunc(report.Items, func(a, b models.ProductMargin) int {
+		return cmp.Compare(b.Margin, a.Margin)
+	})
+	return report, nil
+}
+
+// GetCategorySales totals the completed orders placed within [from, to] per
+// category. Lines count in the category they were sold in; orders placed
+// before lines recorded it use the product's current category. A zero from or
+// to leaves that end of the range open, and an empty locationID covers all
+// locations.
+func (s *reportsService) GetCategorySales(from, to time.Time, locationID string) (*models.CategorySalesReport, error) {
+	orders, err := s.orderRepo.GetAll()
+	if err != nil {
+		slog.Error("Failed to get orders for category sales", "error", err)
+		return nil, err
+	}
+
+	menu, err := s.menuByID()
+	if err != nil {
+		slog.Error("Failed to get menu items for category sales", "error", err)
+		return nil, err
+	}
+
+	report := &models.CategorySalesReport{LocationID: locationID, Categories: []models.CategorySales{}}
+	if !from.IsZero() {
+		report.From = from.Format(time.RFC3339)
+	}
+	if !to.IsZero() {
+		report.To = to.Format(time.RFC3339)
+	}
+
+	byCategory := make(map[string]*models.CategorySales)
+	var categoryIDs []string
+	for _, order := range orders {
+		if !isCompletedOrder(order) || !atLocation(order.LocationID, locationID) {
+			continue
+		}
+		createdAt, err := time.Parse(time.RFC3339, order.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if (!from.IsZero() && createdAt.Before(from)) || (!to.IsZero() && createdAt.After(to)) {
+			continue
+		}
+
+		for _, orderItem := range order.Items {
+			menuItem := menu[orderItem.ProductID]
+			categoryID := orderItem.CategoryID
+			if categoryID == "" && isLegacyLine(orderItem) && menuItem != nil {
+				categoryID = menuItem.CategoryID
+			}
+
+			category, ok := byCategory[categoryID]
+			if !ok {
+				category = &models.CategorySales{CategoryID: categoryID}
+				byCategory[categoryID] = category
+				categoryIDs = append(categoryIDs, categoryID)
+			}
+
+			revenue := lineRevenue(orderItem, menu)
+			category.Quantity += orderItem.Quantity
+			category.Revenue += revenue
+			category.CostOfGoods += orderItem.LineCost
+			report.TotalSales += revenue
+			report.CostOfGoods += orderItem.LineCost
+		}
+	}
+
+	for _, id := range categoryIDs {
+		category := byCategory[id]
+		category.Name = "Other"
+		if id != "" {
+			// Deleted categories still name the sales made in them
+			stored, err := s.categoryRepo.GetByID(id)
+			if err != nil {
+				return nil, err
+			}
+			category.Name = id
+			if stored != nil {
+				category.Name = stored.Name
+			}
+		}
+		category.Margin, _ = margin(category.Revenue, category.CostOfGoods)
+		category.Revenue = roundMoney(category.Revenue)
+		category.CostOfGoods = roundMoney(category.CostOfGoods)
+		report.Categories = append(report.Categories, *category)
+	}
+	report.TotalSales = roundMoney(report.TotalSales)
+	report.CostOfGoods = roundMoney(report.CostOfGoods)
+
+	// Best selling first
+	slices.SortStableFunc(report.Categories, func(a, b models.CategorySales) int {
+		return cmp.Compare(b.Revenue, a.Revenue)
+	})
+	return report, nil
+}
+
+// atLocation reports whether a record at recordLocationID is included in a
+// report filtered by locationID. An empty filter includes every location.
+func atLocation(recordLocationID, locationID string) bool {
+	return locationID == "" || locationOrDefault(recordLocationID) == locationID
+}
+
+func (s *reportsService) menuByID() (map[string]*models.MenuItem, error) {
+	items, err := s.menuRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	menu := make(map[string]*models.MenuItem, len(items))
+	for _, item := range items {
+		menu[item.ID] = item
+	}
+	return menu, nil
+}
+
+// isLegacyLine reports whether an order line was placed before lines recorded
+// the product name and prices they were sold at. Every priced line names its
+// product, so a zero price or total never marks a line as legacy.
+func isLegacyLine(orderItem models.OrderItem) bool {
+	return orderItem.ProductName == ""
+}
+
+// lineRevenue returns what an order line sold for. Legacy lines are valued at
+// the product's current menu price, or at nothing once it is gone.
+func lineRevenue(orderItem models.OrderItem, menu map[string]*models.MenuItem) float64 {
+	if !isLegacyLine(orderItem) {
+		return orderItem.LineTotal
+	}
+	if menuItem, ok := menu[orderItem.ProductID]; ok {
+		return menuItem.Price * float64(orderItem.Quantity)
+	}
+	return 0
+}
+
+// isCompletedOrder reports whether an order counts as a sale. Orders closed
+// before the lifecycle existed count as completed.
+func isCompletedOrder(order *models.Order) bool {
+	return order.Status == models.OrderStatusCompleted || order.Status == models.OrderStatusClosed
 }
@@ -0,0 +1,73 @@
+// internal/service/schedule.go
+package service
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"hot-coffee/models"
+)
+
+// yearlyDateLayout is the layout of schedule dates that repeat every year.
+const yearlyDateLayout = "01-02"
+
+// onSchedule reports whether a menu item can be ordered at t, given in the
+// shop's time zone. Items without schedules can always be ordered.
+func onSchedule(item *models.MenuItem, t time.Time) bool {
+	if len(item.Schedules) == 0 {
+		return true
+	}
+	for _, schedule := range item.Schedules {
+		if scheduleOpen(schedule, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// scheduleOpen reports whether t falls within a schedule. The part of a time
+// range that runs past midnight belongs to the day the range opened, so its
+// weekday and date are those of the day before.
+func scheduleOpen(schedule models.MenuSchedule, t time.Time) bool {
+	day := t
+	if schedule.From != "" && schedule.To != "" {
+		clock := t.Format("15:04")
+		switch {
+		case schedule.From < schedule.To:
+			if clock < schedule.From || clock >= schedule.To {
+				return false
+			}
+		case clock < schedule.To:
+			day = t.AddDate(0, 0, -1)
+		case clock < schedule.From:
+			return false
+		}
+	}
+
+	weekday := strings.ToLower(day.Weekday().String()[:3])
+	if len(schedule.Days) > 0 && !slices.Contains(schedule.Days, weekday) {
+		return false
+	}
+	return inDateRange(schedule.StartDate, schedule.EndDate, day)
+}
+
+// inDateRange reports whether the day of t lies within [start, end]. Yearly
+// dates wrap around the new year when start comes after end.
+func inDateRange(start, end string, t time.Time) bool {
+	if start == "" && end == "" {
+		return true
+	}
+
+	layout := time.DateOnly
+	if len(start) == len(yearlyDateLayout) || len(end) == len(yearlyDateLayout) {
+		layout = yearlyDateLayout
+	}
+	day := t.Format(layout)
+	afterStart := start == "" || day >= start
+	beforeEnd := end == "" || day <= end
+	if layout == yearlyDateLayout && start != "" && end != "" && start > end {
+		return afterStart || beforeEnd
+	}
+	return afterStart && beforeEnd
+}
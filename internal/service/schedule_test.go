@@ -0,0 +1,89 @@
+// internal/service/schedule_test.go
+package service
+
+import (
+	"testing"
+	"time"
+
+	"hot-coffee/models"
+)
+
+func TestScheduleOpen(t *testing.T) {
+	// 1 March 2024 is a Friday
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, time.March, day, hour, minute, 0, 0, time.UTC)
+	}
+	breakfast := models.MenuSchedule{Days: []string{"fri"}, From: "07:00", To: "11:00"}
+	lateNight := models.MenuSchedule{Days: []string{"fri"}, From: "22:00", To: "02:00"}
+
+	tests := []struct {
+		name     string
+		schedule models.MenuSchedule
+		at       time.Time
+		want     bool
+	}{
+		{name: "within a time range", schedule: breakfast, at: at(1, 8, 0), want: true},
+		{name: "at the opening time", schedule: breakfast, at: at(1, 7, 0), want: true},
+		{name: "at the closing time", schedule: breakfast, at: at(1, 11, 0), want: false},
+		{name: "before the opening time", schedule: breakfast, at: at(1, 6, 59), want: false},
+		{name: "on another day", schedule: breakfast, at: at(2, 8, 0), want: false},
+		{name: "overnight before midnight", schedule: lateNight, at: at(1, 23, 0), want: true},
+		{name: "overnight after midnight belongs to the day it opened", schedule: lateNight, at: at(2, 1, 30), want: true},
+		{name: "overnight after closing", schedule: lateNight, at: at(2, 2, 0), want: false},
+		{name: "after midnight of the day before it opens", schedule: lateNight, at: at(1, 1, 30), want: false},
+		{name: "overnight before opening", schedule: lateNight, at: at(1, 21, 59), want: false},
+		{
+			name:     "overnight after midnight takes the date of the day it opened",
+			schedule: models.MenuSchedule{From: "22:00", To: "02:00", EndDate: "2024-02-29"},
+			at:       at(1, 1, 0),
+			want:     true,
+		},
+		{name: "days only", schedule: models.MenuSchedule{Days: []string{"sat", "sun"}}, at: at(2, 12, 0), want: true},
+		{name: "days only on another day", schedule: models.MenuSchedule{Days: []string{"sat", "sun"}}, at: at(1, 12, 0), want: false},
+		{name: "no limits", at: at(1, 3, 0), want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := scheduleOpen(tt.schedule, tt.at); got != tt.want {
+				t.Errorf("scheduleOpen(%+v, %s) = %t, want %t", tt.schedule, tt.at.Format(time.RFC1123), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInDateRange(t *testing.T) {
+	tests := []struct {
+		name       string
+		start, end string
+		day        string
+		want       bool
+	}{
+		{name: "no range", day: "2024-03-01", want: true},
+		{name: "within dates", start: "2024-02-01", end: "2024-03-31", day: "2024-03-01", want: true},
+		{name: "on the end date", start: "2024-02-01", end: "2024-03-01", day: "2024-03-01", want: true},
+		{name: "after the end date", start: "2024-02-01", end: "2024-03-31", day: "2024-04-01", want: false},
+		{name: "before the start date", start: "2024-02-01", day: "2024-01-31", want: false},
+		{name: "within yearly dates", start: "06-01", end: "08-31", day: "2025-07-01", want: true},
+		{name: "outside yearly dates", start: "06-01", end: "08-31", day: "2025-03-01", want: false},
+		{name: "yearly dates wrapping before the new year", start: "11-01", end: "02-28", day: "2024-12-15", want: true},
+		{name: "yearly dates wrapping after the new year", start: "11-01", end: "02-28", day: "2025-01-10", want: true},
+		{name: "on the end of wrapping yearly dates", start: "11-01", end: "02-28", day: "2025-02-28", want: true},
+		{name: "after wrapping yearly dates", start: "11-01", end: "02-28", day: "2025-03-01", want: false},
+		{name: "before wrapping yearly dates", start: "11-01", end: "02-28", day: "2024-10-31", want: false},
+		{name: "yearly end only", end: "02-28", day: "2025-01-10", want: true},
+		{name: "after a yearly end only", end: "02-28", day: "2025-03-01", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			day, err := time.Parse(time.DateOnly, tt.day)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := inDateRange(tt.start, tt.end, day); got != tt.want {
+				t.Errorf("inDateRange(%q, %q, %s) = %t, want %t", tt.start, tt.end, tt.day, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+// internal/service/stock.go
+package service
+
+import (
+	"slices"
+	"time"
+
+	"hot-coffee/internal/repository"
+	"hot-coffee/models"
+)
+
+// adjustStock changes an ingredient's quantity at the movement's location by
+// delta and records the change as a movement in the inventory ledger. movement
+// supplies the reason and context; the remaining fields are filled in here. It
+// must run inside a unit of work holding the ingredient's lock, and is where
+// stock changes, apart from transfers between locations. Stock taken out is
+// drawn from the ingredient's lots, whose expiry dates are read in zone;
+// deliveries that form a new lot go through receiveStock.
+func adjustStock(repos repository.Repositories, item *models.InventoryItem, delta float64, movement *models.InventoryMovement, zone *time.Location) error {
+	movement.LocationID = locationOrDefault(movement.LocationID)
+	if delta < 0 {
+		if _, err := drawFromLots(item, -delta, movement.LotID, movement.LocationID, zone); err != nil {
+			return err
+		}
+	}
+	addStockAt(item, movement.LocationID, delta)
+	if err := repos.Inventory.Update(item); err != nil {
+		return err
+	}
+	return recordMovement(repos, item, delta, movement)
+}
+
+// recordMovement appends a movement for a change already applied to item,
+// valued at the item's current unit cost.
+func recordMovement(repos repository.Repositories, item *models.InventoryItem, delta float64, movement *models.InventoryMovement) error {
+	movement.ID = generateID()
+	movement.IngredientID = item.IngredientID
+	movement.LocationID = locationOrDefault(movement.LocationID)
+	movement.Delta = delta
+	movement.QuantityAfter = stockAt(item, movement.LocationID)
+	movement.UnitCost = item.UnitCost
+	movement.CreatedAt = time.Now().Format(time.RFC3339)
+	return repos.Movements.Append(movement)
+}
+
+// averageInCost folds quantity received at unitCost per stock unit into an
+// ingredient's weighted-average unit cost. Stock on hand without a known cost
+// takes the cost of the delivery.
+func averageInCost(item *models.InventoryItem, quantity, unitCost float64) {
+	onHand := max(item.Quantity, 0)
+	if item.UnitCost == 0 || onHand+quantity <= 0 {
+		item.UnitCost = unitCost
+		return
+	}
+	item.UnitCost = (onHand*item.UnitCost + quantity*unitCost) / (onHand + quantity)
+}
+
+// replayMovements returns the quantity the ledger arrives at.
+func replayMovements(movements []*models.InventoryMovement) float64 {
+	var quantity float64
+	for _, movement := range movements {
+		quantity += movement.Delta
+	}
+	return quantity
+}
+
+// sortedIngredientIDs returns the keys of an ingredient map in a stable order.
+func sortedIngredientIDs(ingredients map[string]float64) []string {
+	ids := make([]string, 0, len(ingredients))
+	for id := range ingredients {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
@@ -0,0 +1,327 @@
+// internal/service/stock_count_service.go
+package service
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"hot-coffee/internal/repository"
+	"hot-coffee/models"
+)
+
+type stockCountService struct {
+	stockCountRepo repository.StockCountRepository
+	inventoryRepo  repository.InventoryRepository
+	locationRepo   repository.LocationRepository
+	uow            repository.UnitOfWork
+	zone           *time.Location
+}
+
+// NewStockCountService returns a stock count service that reads lot expiry
+// dates in the shop's time zone.
+func NewStockCountService(stockCountRepo repository.StockCountRepository, inventoryRepo repository.InventoryRepository, locationRepo repository.LocationRepository, uow repository.UnitOfWork, zone *time.Location) StockCountService {
+	return &stockCountService{
+		stockCountRepo: stockCountRepo,
+		inventoryRepo:  inventoryRepo,
+		locationRepo:   locationRepo,
+		uow:            uow,
+		zone:           zone,
+	}
+}
+
+// StartStockCount opens a count at a location, the default location when none
+// is given, of the ingredients listed in its lines, or of every ingredient in
+// the inventory when it has none.
+func (s *stockCountService) StartStockCount(count *models.StockCount) error {
+	count.LocationID = locationOrDefault(count.LocationID)
+	if err := checkLocationExists(s.locationRepo, "location_id", count.LocationID); err != nil {
+		return err
+	}
+
+	requested := count.Lines
+	count.Lines = []models.StockCountLine{}
+	if len(requested) == 0 {
+		items, err := s.inventoryRepo.GetAll()
+		if err != nil {
+			slog.Error("Failed to get inventory for stock count", "error", err)
+			return err
+		}
+		for _, item := range items {
+			if item.DeletedAt == "" {
+				count.Lines = append(count.Lines, newStockCountLine(item, count.LocationID))
+			}
+		}
+	}
+
+	for i, line := range requested {
+		field := fmt.Sprintf("lines[%d].ingredient_id", i)
+		item, err := s.inventoryRepo.GetByID(line.IngredientID)
+		if err != nil {
+			return err
+		}
+		if item == nil || item.DeletedAt != "" {
+			return FieldError(field, "unknown ingredient: %s", line.IngredientID)
+		}
+		count.Lines = append(count.Lines, newStockCountLine(item, count.LocationID))
+	}
+
+	count.ID = generateID()
+	count.Status = models.StockCountStatusOpen
+	count.VarianceCost = 0
+	count.StartedAt = time.Now().Format(time.RFC3339)
+	count.CommittedBy = ""
+	count.ClosedAt = ""
+
+	if err := s.stockCountRepo.Create(count); err != nil {
+		slog.Error("Failed to create stock count", "error", err)
+		return err
+	}
+
+	slog.Info("Stock count started", "countID", count.ID, "ingredients", len(count.Lines))
+	return nil
+}
+
+func (s *stockCountService) GetStockCountByID(id string) (*models.StockCount, error) {
+	count, err := s.stockCountRepo.GetByID(id)
+	if err != nil {
+		slog.Error("Failed to get stock count", "countID", id, "error", err)
+		return nil, err
+	}
+	if count == nil {
+		return nil, NotFoundError("stock count not found")
+	}
+	return count, nil
+}
+
+// GetAllStockCounts returns the stock counts, only those in status when it is
+// given.
+func (s *stockCountService) GetAllStockCounts(status string) ([]*models.StockCount, error) {
+	counts, err := s.stockCountRepo.GetAll()
+	if err != nil {
+		slog.Error("Failed to get all stock counts", "error", err)
+		return nil, err
+	}
+	if status == "" {
+		return counts, nil
+	}
+
+	matching := counts[:0]
+	for _, count := range counts {
+		if count.Status == status {
+			matching = append(matching, count)
+		}
+	}
+	return matching, nil
+}
+
+// RecordCounts records counted quantities on an open count, converting them to
+// the stock unit. Each is compared with the quantity the ledger holds at the
+// time it is recorded, so sales during the count do not show as variance.
+// Counting an ingredient again replaces its earlier count, and ingredients not
+// yet on the count are added.
+func (s *stockCountService) RecordCounts(id string, submission *models.CountSubmission) (*models.StockCount, error) {
+	lockKeys := []string{stockCountLockKey(id)}
+	for _, counted := range submission.Lines {
+		lockKeys = append(lockKeys, inventoryLockKey(counted.IngredientID))
+	}
+
+	var updated *models.StockCount
+	err := s.uow.Execute(lockKeys, func(repos repository.Repositories) error {
+		count, err := openStockCount(repos, id)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now().Format(time.RFC3339)
+		for i, counted := range submission.Lines {
+			field := fmt.Sprintf("lines[%d]", i)
+			item, err := repos.Inventory.GetByID(counted.IngredientID)
+			if err != nil {
+				return err
+			}
+			if item == nil || item.DeletedAt != "" {
+				return FieldError(field+".ingredient_id", "unknown ingredient: %s", counted.IngredientID)
+			}
+			quantity := counted.Quantity
+			if counted.Unit != "" {
+				if quantity, err = unitConverter(item).Convert(counted.Quantity, counted.Unit, item.Unit); err != nil {
+					return FieldError(field+".unit", "unit %s does not convert to %s, the unit %s is stocked in", counted.Unit, item.Unit, item.IngredientID)
+				}
+			}
+
+			line := findStockCountLine(count, item.IngredientID)
+			if line == nil {
+				count.Lines = append(count.Lines, newStockCountLine(item, count.LocationID))
+				line = &count.Lines[len(count.Lines)-1]
+			}
+			line.Name = item.Name
+			line.Unit = item.Unit
+			expected := stockAt(item, count.LocationID)
+			line.Expected = expected
+			line.Counted = &quantity
+			line.Variance = quantity - expected
+			line.UnitCost = item.UnitCost
+			line.VarianceCost = roundMoney(line.Variance * item.UnitCost)
+			line.CountedBy = submission.Actor
+			line.CountedAt = now
+		}
+
+		sumVarianceCost(count)
+		updated = count
+		return repos.StockCounts.Update(count)
+	})
+	if err != nil {
+		slog.Error("Failed to record stock count", "countID", id, "error", err)
+		return nil, err
+	}
+
+	slog.Info("Stock counted", "countID", id, "ingredients", len(submission.Lines))
+	return updated, nil
+}
+
+// CommitStockCount closes a count and records each counted variance as an
+// adjustment movement. The variance is applied to the current quantity, so
+// stock sold or received since the ingredient was counted is kept. Lines never
+// counted are left unchanged. Each counted line keeps its variance and gets
+// the adjustment applied, which is less than the variance when stock used
+// since the count would otherwise go below zero, and none for ingredients
+// deleted since.
+func (s *stockCountService) CommitStockCount(id, actor string) (*models.StockCount, error) {
+	existing, err := s.GetStockCountByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Lines are only added while the count is open, which this lock prevents
+	// from now on; a line added in between is caught below
+	lockKeys := []string{stockCountLockKey(id)}
+	for _, line := range existing.Lines {
+		lockKeys = append(lockKeys, inventoryLockKey(line.IngredientID))
+	}
+
+	var committed *models.StockCount
+	err = s.uow.Execute(lockKeys, func(repos repository.Repositories) error {
+		count, err := openStockCount(repos, id)
+		if err != nil {
+			return err
+		}
+		if len(count.Lines) != len(existing.Lines) {
+			return ConflictError("stock count %s changed while committing, try again", id)
+		}
+
+		for i := range count.Lines {
+			line := &count.Lines[i]
+			if line.Counted == nil {
+				continue
+			}
+			delta := 0.0
+			line.Adjusted = &delta
+			if line.Variance == 0 {
+				continue
+			}
+			item, err := repos.Inventory.GetByID(line.IngredientID)
+			if err != nil {
+				return err
+			}
+			if item != nil && item.DeletedAt == "" {
+				// Stock used since the count cannot take the quantity below zero
+				delta = max(line.Variance, -stockAt(item, count.LocationID))
+			}
+			if delta == 0 {
+				continue
+			}
+			movement := &models.InventoryMovement{
+				Reason:       models.MovementReasonAdjustment,
+				LocationID:   count.LocationID,
+				StockCountID: id,
+				Actor:        actor,
+			}
+			if err := adjustStock(repos, item, delta, movement, s.zone); err != nil {
+				return err
+			}
+		}
+
+		sumVarianceCost(count)
+		count.Status = models.StockCountStatusCommitted
+		count.CommittedBy = actor
+		count.ClosedAt = time.Now().Format(time.RFC3339)
+		committed = count
+		return repos.StockCounts.Update(count)
+	})
+	if err != nil {
+		slog.Error("Failed to commit stock count", "countID", id, "error", err)
+		return nil, err
+	}
+
+	slog.Info("Stock count committed", "countID", id, "varianceCost", committed.VarianceCost)
+	return committed, nil
+}
+
+// CancelStockCount closes an open count without changing any stock.
+func (s *stockCountService) CancelStockCount(id string) (*models.StockCount, error) {
+	var cancelled *models.StockCount
+	err := s.uow.Execute([]string{stockCountLockKey(id)}, func(repos repository.Repositories) error {
+		count, err := openStockCount(repos, id)
+		if err != nil {
+			return err
+		}
+
+		count.Status = models.StockCountStatusCancelled
+		count.ClosedAt = time.Now().Format(time.RFC3339)
+		cancelled = count
+		return repos.StockCounts.Update(count)
+	})
+	if err != nil {
+		slog.Error("Failed to cancel stock count", "countID", id, "error", err)
+		return nil, err
+	}
+
+	slog.Info("Stock count cancelled", "countID", id)
+	return cancelled, nil
+}
+
+// openStockCount reads a count within a unit of work and checks it is open.
+func openStockCount(repos repository.Repositories, id string) (*models.StockCount, error) {
+	count, err := repos.StockCounts.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if count == nil {
+		return nil, NotFoundError("stock count not found")
+	}
+	if count.Status != models.StockCountStatusOpen {
+		return nil, ConflictError("stock count %s is %s", id, count.Status)
+	}
+	return count, nil
+}
+
+// newStockCountLine returns an uncounted line expecting the ingredient's
+// current quantity at a location.
+func newStockCountLine(item *models.InventoryItem, locationID string) models.StockCountLine {
+	return models.StockCountLine{
+		IngredientID: item.IngredientID,
+		Name:         item.Name,
+		Unit:         item.Unit,
+		Expected:     stockAt(item, locationID),
+		UnitCost:     item.UnitCost,
+	}
+}
+
+func findStockCountLine(count *models.StockCount, ingredientID string) *models.StockCountLine {
+	for i := range count.Lines {
+		if count.Lines[i].IngredientID == ingredientID {
+			return &count.Lines[i]
+		}
+	}
+	return nil
+}
+
+// sumVarianceCost totals the variance costs of the lines of a count.
+func sumVarianceCost(count *models.StockCount) {
+	count.VarianceCost = 0
+	for _, line := range count.Lines {
+		count.VarianceCost += line.VarianceCost
+	}
+	count.VarianceCost = roundMoney(count.VarianceCost)
+}
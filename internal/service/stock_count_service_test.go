@@ -0,0 +1,132 @@
+// internal/service/stock_count_service_test.go
+package service
+
+import (
+	"testing"
+	"time"
+
+	"hot-coffee/internal/repository"
+	"hot-coffee/models"
+)
+
+func TestCommitStockCount(t *testing.T) {
+	tests := []struct {
+		name         string
+		counted      float64
+		unit         string
+		usedSince    float64
+		deleted      bool
+		wantVariance float64
+		wantAdjusted float64
+		wantQuantity float64
+	}{
+		{
+			name:         "a shortfall is adjusted",
+			counted:      900,
+			wantVariance: -100,
+			wantAdjusted: -100,
+			wantQuantity: 900,
+		},
+		{
+			name:         "a surplus is adjusted",
+			counted:      1.02,
+			unit:         "l",
+			wantVariance: 20,
+			wantAdjusted: 20,
+			wantQuantity: 1020,
+		},
+		{
+			name:         "stock used since the count is kept",
+			counted:      900,
+			usedSince:    300,
+			wantVariance: -100,
+			wantAdjusted: -100,
+			wantQuantity: 600,
+		},
+		{
+			name:         "a shortfall larger than what is left adjusts what is left",
+			counted:      900,
+			usedSince:    950,
+			wantVariance: -100,
+			wantAdjusted: -50,
+			wantQuantity: 0,
+		},
+		{
+			name:         "an ingredient deleted since is not adjusted",
+			counted:      900,
+			deleted:      true,
+			wantVariance: -100,
+			wantQuantity: 1000,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := newTestRepositories(t)
+			addStock(t, repos, "milk", "ml", 1000, 0.002)
+			uow := repository.NewUnitOfWork(repos)
+			s := NewStockCountService(repos.StockCounts, repos.Inventory, repos.Locations, uow, time.UTC)
+
+			count := &models.StockCount{Lines: []models.StockCountLine{{IngredientID: "milk"}}}
+			if err := s.StartStockCount(count); err != nil {
+				t.Fatal(err)
+			}
+			submission := &models.CountSubmission{Lines: []models.CountedQuantity{{IngredientID: "milk", Quantity: tt.counted, Unit: tt.unit}}}
+			if _, err := s.RecordCounts(count.ID, submission); err != nil {
+				t.Fatal(err)
+			}
+			if tt.usedSince > 0 {
+				movement := &models.InventoryMovement{Delta: -tt.usedSince, Reason: models.MovementReasonWaste}
+				if err := newTestInventoryService(repos).RecordMovement("milk", movement); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.deleted {
+				milk, err := repos.Inventory.GetByID("milk")
+				if err != nil {
+					t.Fatal(err)
+				}
+				milk.DeletedAt = time.Now().Format(time.RFC3339)
+				if err := repos.Inventory.Update(milk); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			committed, err := s.CommitStockCount(count.ID, "sam")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if committed.Status != models.StockCountStatusCommitted {
+				t.Errorf("status = %s, want %s", committed.Status, models.StockCountStatusCommitted)
+			}
+			line := committed.Lines[0]
+			if line.Variance != tt.wantVariance {
+				t.Errorf("variance = %g, want %g as counted", line.Variance, tt.wantVariance)
+			}
+			if want := roundMoney(tt.wantVariance * 0.002); line.VarianceCost != want || committed.VarianceCost != want {
+				t.Errorf("variance cost = %g on the line and %g on the count, want %g", line.VarianceCost, committed.VarianceCost, want)
+			}
+			if line.Adjusted == nil || *line.Adjusted != tt.wantAdjusted {
+				t.Errorf("adjusted = %v, want %g", line.Adjusted, tt.wantAdjusted)
+			}
+			if got := quantityOf(t, repos, "milk"); got != tt.wantQuantity {
+				t.Errorf("quantity = %g, want %g", got, tt.wantQuantity)
+			}
+
+			// The adjustment is recorded in the ledger against the count
+			movements, err := repos.Movements.GetByIngredient("milk")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var adjusted float64
+			for _, movement := range movements {
+				if movement.StockCountID == count.ID {
+					adjusted += movement.Delta
+				}
+			}
+			if adjusted != tt.wantAdjusted {
+				t.Errorf("ledger adjusted %g for the count, want %g", adjusted, tt.wantAdjusted)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+// internal/service/supplier_service.go
+package service
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+
+	"hot-coffee/internal/repository"
+	"hot-coffee/models"
+)
+
+type supplierService struct {
+	supplierRepo      repository.SupplierRepository
+	inventoryRepo     repository.InventoryRepository
+	purchaseOrderRepo repository.PurchaseOrderRepository
+}
+
+func NewSupplierService(supplierRepo repository.SupplierRepository, inventoryRepo repository.InventoryRepository, purchaseOrderRepo repository.PurchaseOrderRepository) SupplierService {
+	return &supplierService{
+		supplierRepo:      supplierRepo,
+		inventoryRepo:     inventoryRepo,
+		purchaseOrderRepo: purchaseOrderRepo,
+	}
+}
+
+// CreateSupplier adds a supplier, deriving its ID from the name when none is given.
+func (s *supplierService) CreateSupplier(supplier *models.Supplier) error {
+	supplier.DeletedAt = ""
+	if supplier.ID == "" {
+		id, err := uniqueSlug(supplier.Name, func(id string) (bool, error) {
+			existing, err := s.supplierRepo.GetByID(id)
+			return existing != nil, err
+		})
+		if err != nil {
+			return err
+		}
+		supplier.ID = id
+	}
+
+	if err := s.supplierRepo.Create(supplier); err != nil {
+		slog.Error("Failed to create supplier", "error", err)
+		if errors.Is(err, repository.ErrDuplicateID) {
+			return ConflictError("supplier %s already exists", supplier.ID)
+		}
+		return err
+	}
+
+	slog.Info("Supplier created", "supplierID", supplier.ID, "name", supplier.Name)
+	return nil
+}
+
+func (s *supplierService) GetSupplierByID(id string) (*models.Supplier, error) {
+	supplier, err := s.supplierRepo.GetByID(id)
+	if err != nil {
+		slog.Error("Failed to get supplier", "supplierID", id, "error", err)
+		return nil, err
+	}
+	if supplier == nil {
+		return nil, NotFoundError("supplier not found")
+	}
+	return supplier, nil
+}
+
+// GetAllSuppliers returns the suppliers that are not deleted.
+func (s *supplierService) GetAllSuppliers() ([]*models.Supplier, error) {
+	suppliers, err := s.supplierRepo.GetAll()
+	if err != nil {
+		slog.Error("Failed to get all suppliers", "error", err)
+		return nil, err
+	}
+
+	active := suppliers[:0]
+	for _, supplier := range suppliers {
+		if supplier.DeletedAt == "" {
+			active = append(active, supplier)
+		}
+	}
+	return active, nil
+}
+
+func (s *supplierService) UpdateSupplier(supplier *models.Supplier) error {
+	existing, err := s.supplierRepo.GetByID(supplier.ID)
+	if err != nil {
+		return err
+	}
+	if existing == nil || existing.DeletedAt != "" {
+		return NotFoundError("supplier not found")
+	}
+
+	supplier.DeletedAt = ""
+	if err := s.supplierRepo.Update(supplier); err != nil {
+		slog.Error("Failed to update supplier", "supplierID", supplier.ID, "error", err)
+		if errors.Is(err, repository.ErrNotFound) {
+			return NotFoundError("supplier not found")
+		}
+		return err
+	}
+
+	slog.Info("Supplier updated", "supplierID", supplier.ID, "name", supplier.Name)
+	return nil
+}
+
+// DeleteSupplier refuses to delete a supplier that ingredients are bought from
+// or that has purchase orders outstanding. A supplier with past purchase orders
+// is soft-deleted so that they keep resolving; others are removed.
+func (s *supplierService) DeleteSupplier(id string) error {
+	supplier, err := s.supplierRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if supplier == nil || supplier.DeletedAt != "" {
+		return NotFoundError("supplier not found")
+	}
+
+	items, err := s.inventoryRepo.GetAll()
+	if err != nil {
+		return err
+	}
+	purchaseOrders, err := s.purchaseOrderRepo.GetAll()
+	if err != nil {
+		return err
+	}
+
+	var details []models.ErrorDetail
+	for _, item := range items {
+		if item.SupplierID == id && item.DeletedAt == "" {
+			details = append(details, models.ErrorDetail{
+				Field:        "inventory_item",
+				Message:      "supplies ingredient " + item.IngredientID,
+				IngredientID: item.IngredientID,
+			})
+		}
+	}
+	referenced := false
+	for _, order := range purchaseOrders {
+		if order.SupplierID != id {
+			continue
+		}
+		referenced = true
+		if isOutstandingPurchaseOrder(order) {
+			details = append(details, models.ErrorDetail{
+				Field:   "purchase_order",
+				Message: "has " + order.Status + " purchase order " + order.ID,
+			})
+		}
+	}
+	if len(details) > 0 {
+		return &Error{Kind: KindConflict, Message: "supplier " + id + " is still in use", Details: details}
+	}
+
+	if referenced {
+		supplier.DeletedAt = time.Now().Format(time.RFC3339)
+		err = s.supplierRepo.Update(supplier)
+	} else {
+		err = s.supplierRepo.Delete(id)
+	}
+	if err != nil {
+		slog.Error("Failed to delete supplier", "supplierID", id, "error", err)
+		if errors.Is(err, repository.ErrNotFound) {
+			return NotFoundError("supplier not found")
+		}
+		return err
+	}
+
+	slog.Info("Supplier deleted", "supplierID", id, "soft", referenced)
+	return nil
+}
+
+// checkSupplierExists rejects a reference to a supplier that does not exist or
+// is deleted. An empty ID refers to no supplier.
+func checkSupplierExists(supplierRepo repository.SupplierRepository, field, id string) error {
+	if id == "" {
+		return nil
+	}
+	supplier, err := supplierRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if supplier == nil || supplier.DeletedAt != "" {
+		return FieldError(field, "unknown supplier: %s", id)
+	}
+	return nil
+}
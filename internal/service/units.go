@@ -0,0 +1,96 @@
+// internal/service/units.go
+package service
+
+import (
+	"hot-coffee/internal/repository"
+	"hot-coffee/internal/units"
+	"hot-coffee/models"
+)
+
+// unitConverter returns a converter that knows an ingredient's custom units.
+func unitConverter(item *models.InventoryItem) *units.Converter {
+	return units.NewConverter(item.Conversions...)
+}
+
+// stockUnits converts recipe quantities to the units ingredients are stocked
+// in. It caches the inventory items it looks up.
+type stockUnits struct {
+	inventoryRepo repository.InventoryRepository
+	items         map[string]*models.InventoryItem
+}
+
+func newStockUnits(inventoryRepo repository.InventoryRepository) *stockUnits {
+	return &stockUnits{inventoryRepo: inventoryRepo, items: make(map[string]*models.InventoryItem)}
+}
+
+func (s *stockUnits) item(ingredientID string) (*models.InventoryItem, error) {
+	if item, ok := s.items[ingredientID]; ok {
+		return item, nil
+	}
+	item, err := s.inventoryRepo.GetByID(ingredientID)
+	if err != nil {
+		return nil, err
+	}
+	s.items[ingredientID] = item
+	return item, nil
+}
+
+// unitOf returns the unit an ingredient is stocked in, or "" if it is not in
+// the inventory.
+func (s *stockUnits) unitOf(ingredientID string) (string, error) {
+	item, err := s.item(ingredientID)
+	if err != nil || item == nil {
+		return "", err
+	}
+	return item.Unit, nil
+}
+
+// toStock converts a quantity of an ingredient in unit to its stock unit. An
+// empty unit already is the stock unit. Ingredients missing from the inventory
+// are left to the stock check.
+func (s *stockUnits) toStock(ingredientID string, quantity float64, unit string) (float64, error) {
+	if unit == "" {
+		return quantity, nil
+	}
+	item, err := s.item(ingredientID)
+	if err != nil || item == nil {
+		return quantity, err
+	}
+	converted, err := unitConverter(item).Convert(quantity, unit, item.Unit)
+	if err != nil {
+		return 0, ConflictError("recipe quantity of %s: %v", ingredientID, err)
+	}
+	return converted, nil
+}
+
+// checkUnitChange refuses an update to an ingredient's units that would change
+// the meaning of the recipe quantities menu items give for it.
+func checkUnitChange(menuRepo repository.MenuRepository, existing, updated *models.InventoryItem) error {
+	menuItems, err := menuRepo.GetAll()
+	if err != nil {
+		return err
+	}
+
+	unitChanged := units.Normalize(existing.Unit) != units.Normalize(updated.Unit)
+	converter := unitConverter(updated)
+	for _, menuItem := range menuItems {
+		if menuItem.DeletedAt != "" {
+			continue
+		}
+		for _, ref := range ingredientReferences(menuItem) {
+			if ref.ingredientID != updated.IngredientID || ref.substitutes != "" {
+				continue
+			}
+			if ref.unit == "" {
+				if unitChanged {
+					return ConflictError("%s gives quantities of %s without a unit; declare their unit before changing the stock unit", menuItem.ID, updated.IngredientID)
+				}
+				continue
+			}
+			if !converter.Compatible(ref.unit, updated.Unit) {
+				return ConflictError("%s measures %s in %s, which does not convert to %s", menuItem.ID, updated.IngredientID, ref.unit, updated.Unit)
+			}
+		}
+	}
+	return nil
+}
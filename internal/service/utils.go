@@ -4,10 +4,111 @@ package service
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+
+	"hot-coffee/internal/repository"
 )
 
+// errLocksChanged signals that what an operation has to lock changed between
+// reading it and locking it, so the operation has to be retried.
+var errLocksChanged = errors.New("locked records changed concurrently")
+
+const maxLockAttempts = 3
+
 func generateID() string {
 	bytes := make([]byte, 8)
 	rand.Read(bytes)
 	return hex.EncodeToString(bytes)
 }
+
+// slugify derives an ID from a name, e.g. "Caffe Latte" becomes "caffe_latte".
+func slugify(name string) string {
+	var b strings.Builder
+	pendingSeparator := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if pendingSeparator && b.Len() > 0 {
+				b.WriteByte('_')
+			}
+			pendingSeparator = false
+			b.WriteRune(r)
+			continue
+		}
+		pendingSeparator = true
+	}
+	return b.String()
+}
+
+// uniqueSlug derives an ID from name that taken reports as free, adding a
+// numeric suffix if the plain slug is in use.
+func uniqueSlug(name string, taken func(id string) (bool, error)) (string, error) {
+	slug := slugify(name)
+	if slug == "" {
+		return "", FieldError("name", "cannot derive an ID from name %q", name)
+	}
+
+	id := slug
+	for n := 2; ; n++ {
+		exists, err := taken(id)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return id, nil
+		}
+		id = slug + "_" + strconv.Itoa(n)
+	}
+}
+
+// executeRetrying runs a unit of work holding the keys lockKeys returns. The
+// keys are worked out from records read before they are locked, so fn has to
+// check what it reads under the locks and return errLocksChanged when the
+// locks do not cover it; the keys are then worked out again and the unit of
+// work retried. The last attempt failing that way gives a conflict error with
+// message conflict.
+func executeRetrying(uow repository.UnitOfWork, conflict string, lockKeys func() ([]string, error), fn func(repos repository.Repositories) error) error {
+	for attempt := 0; attempt < maxLockAttempts; attempt++ {
+		keys, err := lockKeys()
+		if err != nil {
+			return err
+		}
+		err = uow.Execute(keys, fn)
+		if !errors.Is(err, errLocksChanged) {
+			return err
+		}
+	}
+	return ConflictError("%s", conflict)
+}
+
+// inventoryLockKey returns the unit of work lock key guarding an ingredient's stock.
+func inventoryLockKey(ingredientID string) string {
+	return "inventory:" + ingredientID
+}
+
+// menuItemLockKey returns the unit of work lock key guarding a menu item.
+func menuItemLockKey(productID string) string {
+	return "menu:" + productID
+}
+
+// orderLockKey returns the unit of work lock key guarding an order.
+func orderLockKey(orderID string) string {
+	return "order:" + orderID
+}
+
+// purchaseOrderLockKey returns the unit of work lock key guarding a purchase order.
+func purchaseOrderLockKey(purchaseOrderID string) string {
+	return "purchase_order:" + purchaseOrderID
+}
+
+// stockCountLockKey returns the unit of work lock key guarding a stock count.
+func stockCountLockKey(countID string) string {
+	return "stock_count:" + countID
+}
+
+// roundMoney rounds an amount to whole cents.
+func roundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
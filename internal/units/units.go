@@ -0,0 +1,142 @@
+// internal/units/units.go
+package units
+
+import (
+	"fmt"
+	"strings"
+
+	"hot-coffee/models"
+)
+
+// Dimension is the physical quantity a unit measures. Only units of the same
+// dimension convert into each other.
+type Dimension string
+
+const (
+	Mass   Dimension = "mass"
+	Volume Dimension = "volume"
+	Count  Dimension = "count"
+)
+
+// Unit is a known unit of measure. Factor converts a quantity in the unit to
+// the base unit of its dimension: grams, milliliters or pieces.
+type Unit struct {
+	Symbol    string
+	Dimension Dimension
+	Factor    float64
+}
+
+var knownUnits = map[string]Unit{
+	"mg":    {"mg", Mass, 0.001},
+	"g":     {"g", Mass, 1},
+	"kg":    {"kg", Mass, 1000},
+	"oz":    {"oz", Mass, 28.349523125},
+	"lb":    {"lb", Mass, 453.59237},
+	"ml":    {"ml", Volume, 1},
+	"cl":    {"cl", Volume, 10},
+	"dl":    {"dl", Volume, 100},
+	"l":     {"l", Volume, 1000},
+	"tsp":   {"tsp", Volume, 4.92892159375},
+	"tbsp":  {"tbsp", Volume, 14.78676478125},
+	"fl_oz": {"fl_oz", Volume, 29.5735295625},
+	"cup":   {"cup", Volume, 236.5882365},
+	"gal":   {"gal", Volume, 3785.411784},
+	"pc":    {"pc", Count, 1},
+	"dozen": {"dozen", Count, 12},
+}
+
+var aliases = map[string]string{
+	"milligram": "mg", "milligrams": "mg",
+	"gram": "g", "grams": "g", "gr": "g",
+	"kilogram": "kg", "kilograms": "kg", "kgs": "kg",
+	"ounce": "oz", "ounces": "oz",
+	"pound": "lb", "pounds": "lb", "lbs": "lb",
+	"milliliter": "ml", "milliliters": "ml", "millilitre": "ml", "millilitres": "ml",
+	"centiliter": "cl", "centiliters": "cl", "centilitre": "cl", "centilitres": "cl",
+	"deciliter": "dl", "deciliters": "dl", "decilitre": "dl", "decilitres": "dl",
+	"liter": "l", "liters": "l", "litre": "l", "litres": "l",
+	"teaspoon": "tsp", "teaspoons": "tsp",
+	"tablespoon": "tbsp", "tablespoons": "tbsp",
+	"fl oz": "fl_oz", "floz": "fl_oz", "fluid ounce": "fl_oz", "fluid ounces": "fl_oz",
+	"cups":   "cup",
+	"gallon": "gal", "gallons": "gal",
+	"pcs": "pc", "piece": "pc", "pieces": "pc", "each": "pc", "ea": "pc",
+	"dozens": "dozen",
+}
+
+// Normalize returns the canonical spelling of a unit name. Unknown names are
+// lowercased and trimmed.
+func Normalize(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if symbol, ok := aliases[name]; ok {
+		return symbol
+	}
+	return name
+}
+
+// Lookup returns the known unit with the given name or alias.
+func Lookup(name string) (Unit, bool) {
+	unit, ok := knownUnits[Normalize(name)]
+	return unit, ok
+}
+
+// maxConversionDepth bounds chains of custom conversions and stops cycles.
+const maxConversionDepth = 8
+
+// Converter converts quantities between known units and the custom units it
+// was given. A unit that is neither, such as "shots", only converts to itself.
+type Converter struct {
+	custom map[string]models.UnitConversion
+}
+
+// NewConverter returns a converter that also knows the given custom units,
+// such as an ingredient's 1 bag = 1000 g.
+func NewConverter(conversions ...models.UnitConversion) *Converter {
+	custom := make(map[string]models.UnitConversion, len(conversions))
+	for _, conversion := range conversions {
+		custom[Normalize(conversion.Unit)] = conversion
+	}
+	return &Converter{custom: custom}
+}
+
+// Convert converts quantity from one unit to another.
+func (c *Converter) Convert(quantity float64, from, to string) (float64, error) {
+	fromRoot, fromFactor, err := c.resolve(from)
+	if err != nil {
+		return 0, err
+	}
+	toRoot, toFactor, err := c.resolve(to)
+	if err != nil {
+		return 0, err
+	}
+	if fromRoot != toRoot {
+		return 0, fmt.Errorf("cannot convert %s to %s", from, to)
+	}
+	return quantity * fromFactor / toFactor, nil
+}
+
+// Compatible reports whether quantities in one unit convert to the other.
+func (c *Converter) Compatible(from, to string) bool {
+	_, err := c.Convert(1, from, to)
+	return err == nil
+}
+
+// resolve returns the unit a name is ultimately measured in, its dimension's
+// base unit for known units, and the factor converting to it.
+func (c *Converter) resolve(name string) (string, float64, error) {
+	name = Normalize(name)
+	factor := 1.0
+	for depth := 0; depth < maxConversionDepth; depth++ {
+		if unit, ok := knownUnits[name]; ok {
+			return string(unit.Dimension), factor * unit.Factor, nil
+		}
+		conversion, ok := c.custom[name]
+		if !ok {
+			// An unknown unit is its own root, apart from every dimension
+			return "unit:" + name, factor, nil
+		}
+		factor *= conversion.Quantity
+		name = Normalize(conversion.To)
+	}
+	return "", 0, fmt.Errorf("custom unit %s does not resolve to a unit", name)
+}
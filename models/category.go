@@ -0,0 +1,48 @@
+package models
+
+// Category groups menu items, such as hot drinks or bakery. Categories and the
+// items in each are shown in ascending DisplayOrder, then by name.
+type Category struct {
+	ID           string `json:"category_id"`
+	Name         string `json:"name"`
+	Description  string `json:"description,omitempty"`
+	DisplayOrder int    `json:"display_order"`
+	DeletedAt    string `json:"deleted_at,omitempty"`
+}
+
+// StructuredMenu is the menu grouped by category for ordering clients, as it
+// is at a location at time At. Items without a category come last in a section
+// without a category ID.
+type StructuredMenu struct {
+	LocationID string        `json:"location_id"`
+	At         string        `json:"at"`
+	Categories []MenuSection `json:"categories"`
+}
+
+type MenuSection struct {
+	CategoryID   string     `json:"category_id,omitempty"`
+	Name         string     `json:"name"`
+	Description  string     `json:"description,omitempty"`
+	DisplayOrder int        `json:"display_order"`
+	Items        []MenuItem `json:"items"`
+}
+
+// CategorySalesReport totals the completed orders placed within a date range
+// per category, using the category each line was sold in.
+type CategorySalesReport struct {
+	LocationID  string          `json:"location_id,omitempty"`
+	From        string          `json:"from,omitempty"`
+	To          string          `json:"to,omitempty"`
+	TotalSales  float64         `json:"total_sales"`
+	CostOfGoods float64         `json:"cost_of_goods"`
+	Categories  []CategorySales `json:"categories"`
+}
+
+type CategorySales struct {
+	CategoryID  string  `json:"category_id,omitempty"`
+	Name        string  `json:"name"`
+	Quantity    int     `json:"quantity"`
+	Revenue     float64 `json:"revenue"`
+	CostOfGoods float64 `json:"cost_of_goods"`
+	Margin      float64 `json:"margin"`
+}
@@ -0,0 +1,62 @@
+package models
+
+// MenuItemCosting breaks down what one unit of a menu item, and of each of its
+// variants, costs to make at the ingredients' current unit costs. Uncosted
+// lists the ingredients used that have no unit cost yet.
+type MenuItemCosting struct {
+	ProductID     string           `json:"product_id"`
+	Name          string           `json:"name"`
+	Price         float64          `json:"price"`
+	Cost          float64          `json:"cost"`
+	Margin        float64          `json:"margin"`
+	MarginPercent float64          `json:"margin_percent"`
+	Ingredients   []IngredientCost `json:"ingredients"`
+	Variants      []VariantCosting `json:"variants,omitempty"`
+	Uncosted      []string         `json:"uncosted,omitempty"`
+}
+
+type VariantCosting struct {
+	VariantID     string           `json:"variant_id"`
+	Name          string           `json:"name"`
+	Price         float64          `json:"price"`
+	Cost          float64          `json:"cost"`
+	Margin        float64          `json:"margin"`
+	MarginPercent float64          `json:"margin_percent"`
+	Ingredients   []IngredientCost `json:"ingredients"`
+}
+
+// IngredientCost is the cost of an ingredient in a recipe. Quantity is in the
+// unit the ingredient is stocked in.
+type IngredientCost struct {
+	IngredientID string  `json:"ingredient_id"`
+	Name         string  `json:"name"`
+	Quantity     float64 `json:"quantity"`
+	Unit         string  `json:"unit"`
+	UnitCost     float64 `json:"unit_cost"`
+	Cost         float64 `json:"cost"`
+}
+
+// MarginReport compares the revenue of completed orders with the cost of goods
+// stamped on their lines when they were sold.
+type MarginReport struct {
+	LocationID    string          `json:"location_id,omitempty"`
+	From          string          `json:"from,omitempty"`
+	To            string          `json:"to,omitempty"`
+	Revenue       float64         `json:"revenue"`
+	CostOfGoods   float64         `json:"cost_of_goods"`
+	Margin        float64         `json:"margin"`
+	MarginPercent float64         `json:"margin_percent"`
+	Items         []ProductMargin `json:"items"`
+}
+
+type ProductMargin struct {
+	ProductID     string  `json:"product_id"`
+	Name          string  `json:"name"`
+	VariantID     string  `json:"variant_id,omitempty"`
+	VariantName   string  `json:"variant_name,omitempty"`
+	Quantity      int     `json:"quantity"`
+	Revenue       float64 `json:"revenue"`
+	CostOfGoods   float64 `json:"cost_of_goods"`
+	Margin        float64 `json:"margin"`
+	MarginPercent float64 `json:"margin_percent"`
+}
@@ -1,5 +1,20 @@
 package models
 
 type ErrorResponse struct {
-	Error string `json:"error"`
+	Code    string        `json:"code,omitempty"`
+	Error   string        `json:"error"`
+	Details []ErrorDetail `json:"details,omitempty"`
+}
+
+// ErrorDetail describes one problem with a request. Required, Available and
+// Shortfall are set on insufficient-stock details, where zero is a real
+// quantity.
+type ErrorDetail struct {
+	Field        string   `json:"field,omitempty"`
+	Message      string   `json:"message,omitempty"`
+	IngredientID string   `json:"ingredient_id,omitempty"`
+	Unit         string   `json:"unit,omitempty"`
+	Required     *float64 `json:"required,omitempty"`
+	Available    *float64 `json:"available,omitempty"`
+	Shortfall    *float64 `json:"shortfall,omitempty"`
 }
@@ -1,8 +1,70 @@
 package models
 
+// InventoryItem is an ingredient in stock. Quantity is the total across all
+// locations; Stock breaks it down by location once stock is held anywhere but
+// the default location, which otherwise holds all of it.
 type InventoryItem struct {
-	IngredientID string  `json:"ingredient_id"`
-	Name         string  `json:"name"`
-	Quantity     float64 `json:"quantity"`
-	Unit         string  `json:"unit"`
+	IngredientID string           `json:"ingredient_id"`
+	Name         string           `json:"name"`
+	Quantity     float64          `json:"quantity"`
+	Unit         string           `json:"unit"`
+	UnitCost     float64          `json:"unit_cost,omitempty"`
+	Conversions  []UnitConversion `json:"conversions,omitempty"`
+	SupplierID   string           `json:"supplier_id,omitempty"`
+	Stock        []LocationStock  `json:"stock,omitempty"`
+	Lots         []StockLot       `json:"lots,omitempty"`
+	ReorderPoint float64          `json:"reorder_point,omitempty"`
+	ParLevel     float64          `json:"par_level,omitempty"`
+	DeletedAt    string           `json:"deleted_at,omitempty"`
+}
+
+// UnitConversion defines a unit specific to one ingredient, such as a bag of
+// beans: one Unit equals Quantity of To.
+type UnitConversion struct {
+	Unit     string  `json:"unit"`
+	Quantity float64 `json:"quantity"`
+	To       string  `json:"to"`
+}
+
+// StockLot is stock received in one delivery. Quantity is what remains of it.
+// ExpiresAt is an RFC 3339 timestamp, or a date for stock usable through that
+// day. Stock held before lots were tracked belongs to no lot. A lot without a
+// location is held at the default location.
+type StockLot struct {
+	ID              string  `json:"lot_id"`
+	Quantity        float64 `json:"quantity"`
+	ReceivedAt      string  `json:"received_at"`
+	ExpiresAt       string  `json:"expires_at,omitempty"`
+	PurchaseOrderID string  `json:"purchase_order_id,omitempty"`
+	LocationID      string  `json:"location_id,omitempty"`
+}
+
+// ExpiringLot is a lot of an ingredient that expires soon or has expired.
+type ExpiringLot struct {
+	IngredientID string `json:"ingredient_id"`
+	Name         string `json:"name"`
+	Unit         string `json:"unit"`
+	StockLot
+}
+
+// LowStockItem is an ingredient at or below its reorder point at a location.
+// Quantity is the stock held there and ReorderQuantity the amount that brings
+// it back up to its par level.
+type LowStockItem struct {
+	IngredientID    string  `json:"ingredient_id"`
+	Name            string  `json:"name"`
+	LocationID      string  `json:"location_id"`
+	Quantity        float64 `json:"quantity"`
+	Unit            string  `json:"unit"`
+	ReorderPoint    float64 `json:"reorder_point"`
+	ParLevel        float64 `json:"par_level,omitempty"`
+	ReorderQuantity float64 `json:"reorder_quantity,omitempty"`
+}
+
+// LowStockAlert is sent when a deduction takes an ingredient down to its
+// reorder point.
+type LowStockAlert struct {
+	LowStockItem
+	OrderID     string `json:"order_id,omitempty"`
+	TriggeredAt string `json:"triggered_at"`
 }
@@ -0,0 +1,48 @@
+package models
+
+const (
+	MovementReasonOpening      = "opening"
+	MovementReasonSale         = "sale"
+	MovementReasonRestock      = "restock"
+	MovementReasonWaste        = "waste"
+	MovementReasonAdjustment   = "adjustment"
+	MovementReasonCancellation = "cancellation"
+	MovementReasonTransfer     = "transfer"
+	// MovementReasonCostAdjustment revalues an ingredient without changing its
+	// quantity. Its UnitCost is the new unit cost.
+	MovementReasonCostAdjustment = "cost_adjustment"
+)
+
+// InventoryMovement is an immutable ledger entry recording one change to an
+// ingredient's quantity at one location. QuantityAfter is the quantity at that
+// location once it was applied and UnitCost the ingredient's cost per unit at
+// the time. Movements without a location happened at the default location.
+type InventoryMovement struct {
+	ID              string  `json:"movement_id"`
+	IngredientID    string  `json:"ingredient_id"`
+	Delta           float64 `json:"delta"`
+	QuantityAfter   float64 `json:"quantity_after"`
+	Reason          string  `json:"reason"`
+	LocationID      string  `json:"location_id,omitempty"`
+	OrderID         string  `json:"order_id,omitempty"`
+	PurchaseOrderID string  `json:"purchase_order_id,omitempty"`
+	StockCountID    string  `json:"stock_count_id,omitempty"`
+	TransferID      string  `json:"transfer_id,omitempty"`
+	LotID           string  `json:"lot_id,omitempty"`
+	ExpiresAt       string  `json:"expires_at,omitempty"`
+	WasteReason     string  `json:"waste_reason,omitempty"`
+	UnitCost        float64 `json:"unit_cost,omitempty"`
+	Actor           string  `json:"actor,omitempty"`
+	Note            string  `json:"note,omitempty"`
+	CreatedAt       string  `json:"created_at"`
+}
+
+// InventoryMovementsResponse lists an ingredient's movements. LedgerQuantity is
+// the quantity replayed from the whole ledger and matches Quantity unless the
+// data was changed outside the application.
+type InventoryMovementsResponse struct {
+	IngredientID   string              `json:"ingredient_id"`
+	Quantity       float64             `json:"quantity"`
+	LedgerQuantity float64             `json:"ledger_quantity"`
+	Movements      []InventoryMovement `json:"movements"`
+}
@@ -0,0 +1,32 @@
+package models
+
+// DefaultLocationID is the location that holds stock and takes orders when no
+// other location is given, as in data from before locations existed.
+const DefaultLocationID = "main"
+
+type Location struct {
+	ID        string `json:"location_id"`
+	Name      string `json:"name"`
+	Address   string `json:"address,omitempty"`
+	DeletedAt string `json:"deleted_at,omitempty"`
+}
+
+// LocationStock is the quantity of an ingredient held at one location.
+type LocationStock struct {
+	LocationID string  `json:"location_id"`
+	Quantity   float64 `json:"quantity"`
+}
+
+// StockTransfer moves a quantity of an ingredient from one location to
+// another. Quantity may be given in any unit that converts to the stock unit.
+type StockTransfer struct {
+	ID             string              `json:"transfer_id"`
+	IngredientID   string              `json:"ingredient_id"`
+	FromLocationID string              `json:"from_location_id"`
+	ToLocationID   string              `json:"to_location_id"`
+	Quantity       float64             `json:"quantity"`
+	Unit           string              `json:"unit,omitempty"`
+	Note           string              `json:"note,omitempty"`
+	Actor          string              `json:"actor,omitempty"`
+	Movements      []InventoryMovement `json:"movements"`
+}
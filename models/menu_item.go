@@ -1,14 +1,92 @@
 package models
 
+// MenuItem is a product on the menu; its computed fields are never stored.
 type MenuItem struct {
-	ID          string               `json:"product_id"`
+	ID                 string               `json:"product_id"`
+	Name               string               `json:"name"`
+	Description        string               `json:"description"`
+	CategoryID         string               `json:"category_id,omitempty"`
+	DisplayOrder       int                  `json:"display_order,omitempty"`
+	Tags               []string             `json:"tags,omitempty"`
+	Price              float64              `json:"price"`
+	Cost               float64              `json:"cost,omitempty"`
+	Margin             float64              `json:"margin,omitempty"`
+	Available          *bool                `json:"available,omitempty"`
+	Portions           *int                 `json:"portions,omitempty"`
+	SoldOut            bool                 `json:"sold_out,omitempty"`
+	SoldOutAt          string               `json:"sold_out_at,omitempty"`
+	SoldOutLocations   []SoldOutLocation    `json:"sold_out_locations,omitempty"`
+	Schedules          []MenuSchedule       `json:"schedules,omitempty"`
+	OnSchedule         *bool                `json:"on_schedule,omitempty"`
+	Ingredients        []MenuItemIngredient `json:"ingredients"`
+	Variants           []MenuItemVariant    `json:"variants,omitempty"`
+	Modifiers          []MenuModifier       `json:"modifiers,omitempty"`
+	SubstitutionGroups []SubstitutionGroup  `json:"substitution_groups,omitempty"`
+	DeletedAt          string               `json:"deleted_at,omitempty"`
+}
+
+// SoldOutLocation is a location where a menu item is marked sold out.
+type SoldOutLocation struct {
+	LocationID string `json:"location_id"`
+	SoldOutAt  string `json:"sold_out_at"`
+}
+
+// MenuSchedule is a window in which a menu item can be ordered, in the shop's
+// time zone. Days are weekdays such as "mon"; From and To are HH:MM times, and
+// a To before From runs past midnight, its early hours counting as the day
+// before. StartDate and EndDate are inclusive, as YYYY-MM-DD or, to repeat
+// every year, as MM-DD. Empty fields leave the window open in that respect.
+type MenuSchedule struct {
+	Days      []string `json:"days,omitempty"`
+	From      string   `json:"from,omitempty"`
+	To        string   `json:"to,omitempty"`
+	StartDate string   `json:"start_date,omitempty"`
+	EndDate   string   `json:"end_date,omitempty"`
+}
+
+// MenuItemIngredient is an ingredient of a recipe. Quantity is in Unit, or in
+// the unit the ingredient is stocked in when Unit is empty.
+type MenuItemIngredient struct {
+	IngredientID string  `json:"ingredient_id"`
+	Quantity     float64 `json:"quantity"`
+	Unit         string  `json:"unit,omitempty"`
+}
+
+// MenuItemVariant is a size or other option of a menu item with its own price
+// and recipe. When a menu item has variants, every order line must pick one.
+type MenuItemVariant struct {
+	ID          string               `json:"variant_id"`
 	Name        string               `json:"name"`
-	Description string               `json:"description"`
 	Price       float64              `json:"price"`
+	Cost        float64              `json:"cost,omitempty"`
+	Margin      float64              `json:"margin,omitempty"`
+	Available   *bool                `json:"available,omitempty"`
+	Portions    *int                 `json:"portions,omitempty"`
 	Ingredients []MenuItemIngredient `json:"ingredients"`
 }
 
-type MenuItemIngredient struct {
+// MenuModifier is an add-on such as an extra shot. Its ingredient quantities
+// are added to the recipe per unit; a negative quantity uses less of a recipe
+// ingredient, e.g. less syrup.
+type MenuModifier struct {
+	ID          string               `json:"modifier_id"`
+	Name        string               `json:"name"`
+	Price       float64              `json:"price"`
+	MaxQuantity int                  `json:"max_quantity,omitempty"`
+	Ingredients []MenuItemIngredient `json:"ingredients"`
+}
+
+// SubstitutionGroup lets the customer replace one recipe ingredient with one
+// of the options, e.g. milk with oat milk, in the same quantity.
+type SubstitutionGroup struct {
+	ID           string               `json:"group_id"`
+	Name         string               `json:"name"`
+	IngredientID string               `json:"ingredient_id"`
+	Options      []SubstitutionOption `json:"options"`
+}
+
+type SubstitutionOption struct {
 	IngredientID string  `json:"ingredient_id"`
-	Quantity     float64 `json:"quantity"`
+	Name         string  `json:"name"`
+	Price        float64 `json:"price"`
 }
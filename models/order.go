@@ -1,14 +1,77 @@
 package models
 
+// Order lifecycle statuses. An order moves open -> in_preparation -> ready ->
+// completed; it can be cancelled before completion and refunded after it.
+const (
+	OrderStatusOpen          = "open"
+	OrderStatusInPreparation = "in_preparation"
+	OrderStatusReady         = "ready"
+	OrderStatusCompleted     = "completed"
+	OrderStatusCancelled     = "cancelled"
+	OrderStatusRefunded      = "refunded"
+
+	// OrderStatusClosed is the terminal status used before the lifecycle
+	// existed. It is treated as completed.
+	OrderStatusClosed = "closed"
+)
+
 type Order struct {
-	ID           string      `json:"order_id"`
-	CustomerName string      `json:"customer_name"`
-	Items        []OrderItem `json:"items"`
-	Status       string      `json:"status"`
-	CreatedAt    string      `json:"created_at"`
+	ID              string              `json:"order_id"`
+	CustomerName    string              `json:"customer_name"`
+	LocationID      string              `json:"location_id,omitempty"`
+	Items           []OrderItem         `json:"items"`
+	Status          string              `json:"status"`
+	StatusHistory   []OrderStatusChange `json:"status_history,omitempty"`
+	IngredientsUsed []OrderIngredient   `json:"ingredients_used,omitempty"`
+	Subtotal        float64             `json:"subtotal,omitempty"`
+	Total           float64             `json:"total,omitempty"`
+	CostOfGoods     float64             `json:"cost_of_goods,omitempty"`
+	CreatedAt       string              `json:"created_at"`
 }
 
+// OrderItem is a line of an order. Prices and costs are snapshots taken when
+// the line was priced; UnitCost is what the ingredients of one unit cost at
+// the time.
 type OrderItem struct {
-	ProductID string `json:"product_id"`
-	Quantity  int    `json:"quantity"`
+	ProductID     string                  `json:"product_id"`
+	ProductName   string                  `json:"product_name,omitempty"`
+	CategoryID    string                  `json:"category_id,omitempty"`
+	VariantID     string                  `json:"variant_id,omitempty"`
+	VariantName   string                  `json:"variant_name,omitempty"`
+	Quantity      int                     `json:"quantity"`
+	Modifiers     []OrderItemModifier     `json:"modifiers,omitempty"`
+	Substitutions []OrderItemSubstitution `json:"substitutions,omitempty"`
+	UnitPrice     float64                 `json:"unit_price,omitempty"`
+	LineTotal     float64                 `json:"line_total,omitempty"`
+	UnitCost      float64                 `json:"unit_cost,omitempty"`
+	LineCost      float64                 `json:"line_cost,omitempty"`
+}
+
+type OrderItemModifier struct {
+	ModifierID string  `json:"modifier_id"`
+	Name       string  `json:"name,omitempty"`
+	Quantity   int     `json:"quantity"`
+	UnitPrice  float64 `json:"unit_price,omitempty"`
+}
+
+type OrderItemSubstitution struct {
+	GroupID      string  `json:"group_id"`
+	IngredientID string  `json:"ingredient_id"`
+	Name         string  `json:"name,omitempty"`
+	Price        float64 `json:"price,omitempty"`
+}
+
+type OrderIngredient struct {
+	IngredientID string  `json:"ingredient_id"`
+	Quantity     float64 `json:"quantity"`
+}
+
+type OrderStatusChange struct {
+	Status    string `json:"status"`
+	ChangedAt string `json:"changed_at"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+type OrderStatusRequest struct {
+	Reason string `json:"reason"`
 }
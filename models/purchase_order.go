@@ -0,0 +1,70 @@
+package models
+
+const (
+	PurchaseOrderStatusOpen              = "open"
+	PurchaseOrderStatusPartiallyReceived = "partially_received"
+	PurchaseOrderStatusReceived          = "received"
+	PurchaseOrderStatusCancelled         = "cancelled"
+)
+
+// PurchaseOrder is stock ordered from a supplier. Each delivery against it is
+// recorded as a receipt.
+type PurchaseOrder struct {
+	ID         string                 `json:"purchase_order_id"`
+	SupplierID string                 `json:"supplier_id"`
+	LocationID string                 `json:"location_id,omitempty"`
+	Status     string                 `json:"status"`
+	Lines      []PurchaseOrderLine    `json:"lines"`
+	Receipts   []PurchaseOrderReceipt `json:"receipts,omitempty"`
+	Note       string                 `json:"note,omitempty"`
+	CreatedAt  string                 `json:"created_at"`
+	ClosedAt   string                 `json:"closed_at,omitempty"`
+}
+
+// PurchaseOrderLine is an ingredient ordered. Quantity and ReceivedQuantity
+// are in Unit, or in the unit the ingredient is stocked in when Unit is empty.
+type PurchaseOrderLine struct {
+	IngredientID     string  `json:"ingredient_id"`
+	Quantity         float64 `json:"quantity"`
+	Unit             string  `json:"unit,omitempty"`
+	UnitCost         float64 `json:"unit_cost,omitempty"`
+	ReceivedQuantity float64 `json:"received_quantity"`
+}
+
+// PurchaseOrderReceipt is one delivery against a purchase order.
+type PurchaseOrderReceipt struct {
+	ReceivedAt string        `json:"received_at"`
+	Actor      string        `json:"actor,omitempty"`
+	Note       string        `json:"note,omitempty"`
+	Lines      []ReceiptLine `json:"lines"`
+}
+
+// ReceiptLine is the quantity of an ingredient delivered, in the unit of its
+// purchase order line, and when the delivered lot expires.
+type ReceiptLine struct {
+	IngredientID string  `json:"ingredient_id"`
+	Quantity     float64 `json:"quantity"`
+	LotID        string  `json:"lot_id,omitempty"`
+	ExpiresAt    string  `json:"expires_at,omitempty"`
+}
+
+// SuggestedPurchaseOrder is a draft purchase order for the ingredients of one
+// supplier that are low on stock. Its supplier and lines can be posted as a
+// purchase order as they are.
+type SuggestedPurchaseOrder struct {
+	SupplierID string               `json:"supplier_id"`
+	LocationID string               `json:"location_id"`
+	Lines      []SuggestedOrderLine `json:"lines"`
+}
+
+// SuggestedOrderLine brings an ingredient up to its target level, counting
+// quantities already on order as stock.
+type SuggestedOrderLine struct {
+	IngredientID string  `json:"ingredient_id"`
+	Name         string  `json:"name"`
+	Quantity     float64 `json:"quantity"`
+	Unit         string  `json:"unit"`
+	OnHand       float64 `json:"on_hand"`
+	OnOrder      float64 `json:"on_order,omitempty"`
+	TargetLevel  float64 `json:"target_level"`
+}
@@ -1,15 +1,22 @@
 package models
 
+// Reports cover every location unless LocationID names the one they are
+// filtered by.
+
 type TotalSalesResponse struct {
+	LocationID string  `json:"location_id,omitempty"`
 	TotalSales float64 `json:"total_sales"`
 }
 
 type PopularItemsResponse struct {
-	Items []PopularItem `json:"popular_items"`
+	LocationID string        `json:"location_id,omitempty"`
+	Items      []PopularItem `json:"popular_items"`
 }
 
 type PopularItem struct {
 	ProductID   string `json:"product_id"`
 	Name        string `json:"name"`
+	VariantID   string `json:"variant_id,omitempty"`
+	VariantName string `json:"variant_name,omitempty"`
 	TotalOrders int    `json:"total_orders"`
 }
@@ -0,0 +1,81 @@
+package models
+
+const (
+	StockCountStatusOpen      = "open"
+	StockCountStatusCommitted = "committed"
+	StockCountStatusCancelled = "cancelled"
+)
+
+// StockCount is a physical count of the inventory. Each counted line compares
+// the quantity on hand when it was counted with the quantity expected from the
+// ledger; committing the count records the variances as adjustments.
+type StockCount struct {
+	ID           string           `json:"count_id"`
+	Status       string           `json:"status"`
+	LocationID   string           `json:"location_id"`
+	Note         string           `json:"note,omitempty"`
+	Lines        []StockCountLine `json:"lines"`
+	VarianceCost float64          `json:"variance_cost"`
+	StartedBy    string           `json:"started_by,omitempty"`
+	StartedAt    string           `json:"started_at"`
+	CommittedBy  string           `json:"committed_by,omitempty"`
+	ClosedAt     string           `json:"closed_at,omitempty"`
+}
+
+// StockCountLine is one ingredient of a count in its stock unit. Counted is
+// null until the ingredient is counted, and Adjusted until the count is
+// committed, when it is the adjustment that was recorded for Variance.
+type StockCountLine struct {
+	IngredientID string   `json:"ingredient_id"`
+	Name         string   `json:"name"`
+	Unit         string   `json:"unit"`
+	Expected     float64  `json:"expected"`
+	Counted      *float64 `json:"counted"`
+	Variance     float64  `json:"variance"`
+	UnitCost     float64  `json:"unit_cost,omitempty"`
+	VarianceCost float64  `json:"variance_cost"`
+	Adjusted     *float64 `json:"adjusted,omitempty"`
+	CountedBy    string   `json:"counted_by,omitempty"`
+	CountedAt    string   `json:"counted_at,omitempty"`
+}
+
+// CountSubmission carries quantities counted during a stock count.
+type CountSubmission struct {
+	Lines []CountedQuantity `json:"lines"`
+	Actor string            `json:"-"`
+}
+
+type CountedQuantity struct {
+	IngredientID string  `json:"ingredient_id"`
+	Quantity     float64 `json:"quantity"`
+	Unit         string  `json:"unit,omitempty"`
+}
+
+// ShrinkageReport totals the variances of the stock counts committed within a
+// date range, per ingredient.
+type ShrinkageReport struct {
+	LocationID   string                `json:"location_id,omitempty"`
+	From         string                `json:"from,omitempty"`
+	To           string                `json:"to,omitempty"`
+	VarianceCost float64               `json:"variance_cost"`
+	Ingredients  []IngredientShrinkage `json:"ingredients"`
+}
+
+type IngredientShrinkage struct {
+	IngredientID string           `json:"ingredient_id"`
+	Name         string           `json:"name"`
+	Unit         string           `json:"unit"`
+	Variance     float64          `json:"variance"`
+	VarianceCost float64          `json:"variance_cost"`
+	Counts       []ShrinkagePoint `json:"counts"`
+}
+
+// ShrinkagePoint is an ingredient's variance in one committed count.
+type ShrinkagePoint struct {
+	CountID      string  `json:"count_id"`
+	CountedAt    string  `json:"counted_at"`
+	Expected     float64 `json:"expected"`
+	Counted      float64 `json:"counted"`
+	Variance     float64 `json:"variance"`
+	VarianceCost float64 `json:"variance_cost"`
+}
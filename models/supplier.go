@@ -0,0 +1,11 @@
+package models
+
+type Supplier struct {
+	ID           string `json:"supplier_id"`
+	Name         string `json:"name"`
+	ContactName  string `json:"contact_name,omitempty"`
+	Email        string `json:"email,omitempty"`
+	Phone        string `json:"phone,omitempty"`
+	LeadTimeDays int    `json:"lead_time_days,omitempty"`
+	DeletedAt    string `json:"deleted_at,omitempty"`
+}
@@ -0,0 +1,26 @@
+package models
+
+type InventoryUsage struct {
+	IngredientID string              `json:"ingredient_id"`
+	MenuItems    []MenuItemReference `json:"menu_items"`
+	OpenOrders   []OrderReference    `json:"open_orders"`
+}
+
+type MenuItemUsage struct {
+	ProductID  string           `json:"product_id"`
+	OpenOrders []OrderReference `json:"open_orders"`
+}
+
+// MenuItemReference lists where a menu item uses an ingredient, such as
+// "recipe", "variant:large", "modifier:extra_shot" or "substitution:milk".
+type MenuItemReference struct {
+	ProductID string   `json:"product_id"`
+	Name      string   `json:"name"`
+	UsedIn    []string `json:"used_in"`
+}
+
+type OrderReference struct {
+	OrderID      string `json:"order_id"`
+	CustomerName string `json:"customer_name"`
+	Status       string `json:"status"`
+}
@@ -0,0 +1,51 @@
+package models
+
+const (
+	WasteReasonSpillage = "spillage"
+	WasteReasonDropped  = "dropped"
+	WasteReasonExpired  = "expired"
+	WasteReasonDamaged  = "damaged"
+	WasteReasonQuality  = "quality"
+	WasteReasonOther    = "other"
+)
+
+// WasteRecord is stock thrown away: a quantity of one ingredient, optionally
+// in another unit or from a given lot, or finished menu items whose recipe
+// ingredients are deducted. Cost is valued at the ingredients' unit costs.
+type WasteRecord struct {
+	IngredientID string              `json:"ingredient_id,omitempty"`
+	Quantity     float64             `json:"quantity,omitempty"`
+	Unit         string              `json:"unit,omitempty"`
+	LotID        string              `json:"lot_id,omitempty"`
+	LocationID   string              `json:"location_id,omitempty"`
+	Items        []OrderItem         `json:"items,omitempty"`
+	Reason       string              `json:"reason"`
+	Note         string              `json:"note,omitempty"`
+	Actor        string              `json:"actor,omitempty"`
+	Cost         float64             `json:"cost"`
+	Movements    []InventoryMovement `json:"movements"`
+}
+
+// WasteReport totals the waste recorded within a date range.
+type WasteReport struct {
+	LocationID  string             `json:"location_id,omitempty"`
+	From        string             `json:"from,omitempty"`
+	To          string             `json:"to,omitempty"`
+	TotalCost   float64            `json:"total_cost"`
+	Ingredients []WastedIngredient `json:"ingredients"`
+	Reasons     []WasteByReason    `json:"reasons"`
+}
+
+type WastedIngredient struct {
+	IngredientID string  `json:"ingredient_id"`
+	Name         string  `json:"name"`
+	Unit         string  `json:"unit"`
+	Quantity     float64 `json:"quantity"`
+	Cost         float64 `json:"cost"`
+}
+
+type WasteByReason struct {
+	Reason  string  `json:"reason"`
+	Entries int     `json:"entries"`
+	Cost    float64 `json:"cost"`
+}